@@ -2,6 +2,7 @@ package chain
 
 import (
 	"bytes"
+	"encoding/json"
 	"testing"
 
 	"github.com/drand/drand/common/scheme"
@@ -66,3 +67,19 @@ func TestChainInfo(t *testing.T) {
 	require.NotNil(t, c13)
 	require.Equal(t, c1, c13)
 }
+
+func TestChainInfoJSONMatchesKey(t *testing.T) {
+	sch := scheme.GetSchemeFromEnv()
+	for _, beaconID := range []string{"test_beacon", ""} {
+		_, g := test.BatchIdentities(5, sch, beaconID)
+		var buf bytes.Buffer
+		require.NoError(t, NewChainInfo(g).ToJSON(&buf, nil))
+
+		info, err := key.ChainInfo(g, g.PublicKey)
+		require.NoError(t, err)
+		buff, err := json.Marshal(info)
+		require.NoError(t, err)
+		require.JSONEq(t, buf.String(), string(buff))
+		require.Equal(t, NewChainInfo(g).Hash(), key.ChainHash(g))
+	}
+}
@@ -1,12 +1,17 @@
 package chain
 
 import (
-	"crypto/sha256"
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
 
 	"github.com/drand/drand/common/scheme"
 
 	"github.com/drand/drand/key"
 	"github.com/drand/kyber"
+	"github.com/drand/kyber/util/random"
 )
 
 // Verifier allows verifying the beacons signature based on a scheme.
@@ -22,13 +27,7 @@ func NewVerifier(sch scheme.Scheme) *Verifier {
 // MessageChained returns a slice of bytes as the message to sign or to verify
 // alongside a beacon signature.
 func (v Verifier) DigestMessage(currRound uint64, prevSig []byte) []byte {
-	h := sha256.New()
-
-	if !v.scheme.DecouplePrevSig {
-		_, _ = h.Write(prevSig)
-	}
-	_, _ = h.Write(RoundToBytes(currRound))
-	return h.Sum(nil)
+	return v.scheme.DigestBeacon(currRound, prevSig)
 }
 
 // VerifyChainedBeacon returns an error if the given beacon does not verify given the
@@ -43,3 +42,172 @@ func (v Verifier) VerifyBeacon(b Beacon, pubkey kyber.Point) error {
 
 	return key.Scheme.VerifyRecovered(pubkey, msg, b.Signature)
 }
+
+// ErrBrokenChain is returned when a sequence of beacons does not form a valid
+// chain.
+var ErrBrokenChain = errors.New("chain: broken beacon chain")
+
+// ChainBreakError reports the first beacon of a sequence which does not verify
+// or is not linked to the previous one.
+type ChainBreakError struct {
+	Round uint64
+	Err   error
+}
+
+func (e *ChainBreakError) Error() string {
+	return fmt.Sprintf("%v at round %d: %v", ErrBrokenChain, e.Round, e.Err)
+}
+
+func (e *ChainBreakError) Is(target error) bool {
+	return target == ErrBrokenChain
+}
+
+func (e *ChainBreakError) Unwrap() error {
+	return e.Err
+}
+
+// VerifyChain verifies a sequence of beacons ordered by round against the
+// distributed public key: each signature must verify as with VerifyBeacon and,
+// with a chained scheme, each beacon must follow the previous one and commit to
+// its signature through its previous signature. With an unchained scheme, the
+// rounds only need to be increasing. The first beacon is trusted to commit to
+// the right previous signature. It returns a *ChainBreakError for the first
+// round where the chain breaks, which matches ErrBrokenChain.
+func (v Verifier) VerifyChain(dp *key.DistPublic, beacons []Beacon) error {
+	if dp == nil || len(dp.Coefficients) == 0 {
+		return errors.New("chain: no distributed public key")
+	}
+	pubkey := dp.Key()
+	for i, b := range beacons {
+		if i > 0 {
+			prev := beacons[i-1]
+			switch {
+			case v.scheme.DecouplePrevSig && b.Round <= prev.Round:
+				return &ChainBreakError{Round: b.Round, Err: fmt.Errorf("round does not follow round %d", prev.Round)}
+			case !v.scheme.DecouplePrevSig && b.Round != prev.Round+1:
+				return &ChainBreakError{Round: b.Round, Err: fmt.Errorf("round does not follow round %d", prev.Round)}
+			case !v.scheme.DecouplePrevSig && !bytes.Equal(b.PreviousSig, prev.Signature):
+				return &ChainBreakError{Round: b.Round, Err: errors.New("previous signature is not the signature of the previous round")}
+			}
+		}
+		if err := v.VerifyBeacon(b, pubkey); err != nil {
+			return &ChainBreakError{Round: b.Round, Err: err}
+		}
+	}
+	return nil
+}
+
+// batchRandomizerBytes is the size of the random coefficients of a batch
+// verification: invalid signatures pass a batch with probability 2^-64, an
+// online attack that would need as many verifications to succeed.
+const batchRandomizerBytes = 8
+
+// hashablePoint is implemented by the points of the signature group.
+type hashablePoint interface {
+	Hash([]byte) kyber.Point
+}
+
+// VerifyBeaconsBatch verifies the signatures of the beacons against the
+// distributed public key as VerifyBeacon would, but all together: for random
+// coefficients r_i, it checks that e(key, sum r_i H(m_i)) equals
+// e(g, sum r_i sig_i), which costs two pairings instead of one pairing check
+// per beacon. The messages still have to be hashed one by one, which is the
+// bulk of the remaining work and is spread over GOMAXPROCS goroutines. If the
+// batch fails, it is split in halves until the first invalid beacon is found,
+// for which it returns a *ChainBreakError, which matches ErrBrokenChain.
+// Unlike VerifyChain, it does not check that the beacons are linked to each
+// other.
+func (v Verifier) VerifyBeaconsBatch(dp *key.DistPublic, beacons []Beacon) error {
+	if dp == nil || len(dp.Coefficients) == 0 {
+		return errors.New("chain: no distributed public key")
+	}
+	if _, ok := key.SigGroup.Point().(hashablePoint); !ok {
+		return errors.New("chain: signature group can't hash to points")
+	}
+	pubkey := dp.Key()
+	hashes := make([]kyber.Point, len(beacons))
+	sigs := make([]kyber.Point, len(beacons))
+	errs := make([]error, len(beacons))
+	inParallel(len(beacons), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			sigs[i] = key.SigGroup.Point()
+			if err := sigs[i].UnmarshalBinary(beacons[i].Signature); err != nil {
+				errs[i] = fmt.Errorf("malformed signature: %w", err)
+				continue
+			}
+			msg := v.DigestMessage(beacons[i].Round, beacons[i].PreviousSig)
+			hashes[i] = key.SigGroup.Point().(hashablePoint).Hash(msg)
+		}
+	})
+	for i, err := range errs {
+		if err != nil {
+			return &ChainBreakError{Round: beacons[i].Round, Err: err}
+		}
+	}
+	if verifyBatch(pubkey, hashes, sigs) {
+		return nil
+	}
+	b := beacons[firstInvalid(pubkey, hashes, sigs)]
+	err := v.VerifyBeacon(b, pubkey)
+	if err == nil {
+		// only possible if the coefficients were unlucky
+		err = errors.New("batch verification failed")
+	}
+	return &ChainBreakError{Round: b.Round, Err: err}
+}
+
+// firstInvalid returns the position of the first signature failing to verify,
+// given that the batch of all of them fails.
+func firstInvalid(pubkey kyber.Point, hashes, sigs []kyber.Point) int {
+	if len(sigs) == 1 {
+		return 0
+	}
+	half := len(sigs) / 2
+	if !verifyBatch(pubkey, hashes[:half], sigs[:half]) {
+		return firstInvalid(pubkey, hashes[:half], sigs[:half])
+	}
+	return half + firstInvalid(pubkey, hashes[half:], sigs[half:])
+}
+
+// verifyBatch returns true if a random linear combination of the signatures
+// verifies against the same combination of the hashes of their messages.
+func verifyBatch(pubkey kyber.Point, hashes, sigs []kyber.Point) bool {
+	var mu sync.Mutex
+	aggHash := key.SigGroup.Point().Null()
+	aggSig := key.SigGroup.Point().Null()
+	inParallel(len(sigs), func(lo, hi int) {
+		rand := random.New()
+		partHash := key.SigGroup.Point().Null()
+		partSig := key.SigGroup.Point().Null()
+		coeff := make([]byte, batchRandomizerBytes)
+		for i := lo; i < hi; i++ {
+			random.Bytes(coeff, rand)
+			r := key.SigGroup.Scalar().SetBytes(coeff)
+			partHash.Add(partHash, key.SigGroup.Point().Mul(r, hashes[i]))
+			partSig.Add(partSig, key.SigGroup.Point().Mul(r, sigs[i]))
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		aggHash.Add(aggHash, partHash)
+		aggSig.Add(aggSig, partSig)
+	})
+	return key.Pairing.ValidatePairing(pubkey, aggHash, key.KeyGroup.Point().Base(), aggSig)
+}
+
+// inParallel splits the range [0, n) in GOMAXPROCS chunks and calls f on each
+// of them concurrently.
+func inParallel(n int, f func(lo, hi int)) {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			f(lo, hi)
+		}(w*n/workers, (w+1)*n/workers)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,143 @@
+package chain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/drand/drand/common/scheme"
+	"github.com/drand/drand/key"
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/util/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyChain(t *testing.T) {
+	secret := key.KeyGroup.Scalar().Pick(random.New())
+	dp := &key.DistPublic{Coefficients: []kyber.Point{key.KeyGroup.Point().Mul(secret, nil)}}
+
+	for _, id := range []string{scheme.DefaultSchemeID, scheme.UnchainedSchemeID} {
+		sch, ok := scheme.GetSchemeByID(id)
+		require.True(t, ok)
+		verifier := NewVerifier(sch)
+		sign := func(round uint64, prevSig []byte) Beacon {
+			if sch.DecouplePrevSig {
+				prevSig = nil
+			}
+			sig, err := key.AuthScheme.Sign(secret, verifier.DigestMessage(round, prevSig))
+			require.NoError(t, err)
+			return Beacon{Round: round, PreviousSig: prevSig, Signature: sig}
+		}
+		beacons := []Beacon{sign(10, []byte("genesis"))}
+		for round := uint64(11); round < 15; round++ {
+			beacons = append(beacons, sign(round, beacons[len(beacons)-1].Signature))
+		}
+		require.NoError(t, verifier.VerifyChain(dp, beacons), id)
+		require.NoError(t, verifier.VerifyChain(dp, nil), id)
+
+		// a tampered signature
+		tampered := append([]Beacon{}, beacons...)
+		tampered[2].Signature = append([]byte{}, tampered[2].Signature...)
+		tampered[2].Signature[0] ^= 1
+		err := verifier.VerifyChain(dp, tampered)
+		require.ErrorIs(t, err, ErrBrokenChain, id)
+		var breakErr *ChainBreakError
+		require.True(t, errors.As(err, &breakErr))
+		require.Equal(t, uint64(12), breakErr.Round)
+
+		// another key
+		other := &key.DistPublic{Coefficients: []kyber.Point{key.KeyGroup.Point().Pick(random.New())}}
+		require.ErrorIs(t, verifier.VerifyChain(other, beacons), ErrBrokenChain, id)
+
+		// rounds out of order
+		swapped := append([]Beacon{}, beacons...)
+		swapped[3], swapped[4] = swapped[4], swapped[3]
+		require.True(t, errors.As(verifier.VerifyChain(dp, swapped), &breakErr))
+		if sch.DecouplePrevSig {
+			// round 14 may follow round 12 without chaining
+			require.Equal(t, uint64(13), breakErr.Round)
+		} else {
+			require.Equal(t, uint64(14), breakErr.Round)
+		}
+	}
+
+	// with a chained scheme, a gap or a beacon signed over another previous
+	// signature breaks the chain even if all signatures verify
+	sch, _ := scheme.GetSchemeByID(scheme.DefaultSchemeID)
+	verifier := NewVerifier(sch)
+	first := Beacon{Round: 1, PreviousSig: []byte("genesis")}
+	first.Signature, _ = key.AuthScheme.Sign(secret, verifier.DigestMessage(1, first.PreviousSig))
+	fork := Beacon{Round: 2, PreviousSig: []byte("fork")}
+	fork.Signature, _ = key.AuthScheme.Sign(secret, verifier.DigestMessage(2, fork.PreviousSig))
+	var breakErr *ChainBreakError
+	require.True(t, errors.As(verifier.VerifyChain(dp, []Beacon{first, fork}), &breakErr))
+	require.Equal(t, uint64(2), breakErr.Round)
+	gap := Beacon{Round: 3, PreviousSig: first.Signature}
+	gap.Signature, _ = key.AuthScheme.Sign(secret, verifier.DigestMessage(3, gap.PreviousSig))
+	require.ErrorIs(t, verifier.VerifyChain(dp, []Beacon{first, gap}), ErrBrokenChain)
+}
+
+// signedBeacons returns n chained beacons from round 1 signed with the secret.
+func signedBeacons(t testing.TB, verifier *Verifier, secret kyber.Scalar, n int) []Beacon {
+	beacons := make([]Beacon, 0, n)
+	prev := []byte("genesis")
+	for round := uint64(1); round <= uint64(n); round++ {
+		sig, err := key.AuthScheme.Sign(secret, verifier.DigestMessage(round, prev))
+		require.NoError(t, err)
+		beacons = append(beacons, Beacon{Round: round, PreviousSig: prev, Signature: sig})
+		prev = sig
+	}
+	return beacons
+}
+
+func TestVerifyBeaconsBatch(t *testing.T) {
+	secret := key.KeyGroup.Scalar().Pick(random.New())
+	dp := &key.DistPublic{Coefficients: []kyber.Point{key.KeyGroup.Point().Mul(secret, nil)}}
+	for _, id := range []string{scheme.DefaultSchemeID, scheme.UnchainedSchemeID} {
+		sch, _ := scheme.GetSchemeByID(id)
+		verifier := NewVerifier(sch)
+		beacons := signedBeacons(t, verifier, secret, 33)
+		require.NoError(t, verifier.VerifyBeaconsBatch(dp, beacons), id)
+		require.NoError(t, verifier.VerifyBeaconsBatch(dp, nil), id)
+
+		for _, bad := range []int{0, 17, 32} {
+			corrupt := append([]Beacon{}, beacons...)
+			// a valid signature of another round
+			corrupt[bad].Signature = beacons[(bad+1)%len(beacons)].Signature
+			err := verifier.VerifyBeaconsBatch(dp, corrupt)
+			require.ErrorIs(t, err, ErrBrokenChain, id)
+			var breakErr *ChainBreakError
+			require.True(t, errors.As(err, &breakErr))
+			require.Equal(t, corrupt[bad].Round, breakErr.Round, id)
+		}
+
+		malformed := append([]Beacon{}, beacons...)
+		malformed[5].Signature = []byte("not a point")
+		var breakErr *ChainBreakError
+		require.True(t, errors.As(verifier.VerifyBeaconsBatch(dp, malformed), &breakErr))
+		require.Equal(t, uint64(6), breakErr.Round)
+
+		other := &key.DistPublic{Coefficients: []kyber.Point{key.KeyGroup.Point().Pick(random.New())}}
+		require.True(t, errors.As(verifier.VerifyBeaconsBatch(other, beacons), &breakErr))
+		require.Equal(t, uint64(1), breakErr.Round)
+	}
+}
+
+func BenchmarkVerifyBeacons(b *testing.B) {
+	secret := key.KeyGroup.Scalar().Pick(random.New())
+	dp := &key.DistPublic{Coefficients: []kyber.Point{key.KeyGroup.Point().Mul(secret, nil)}}
+	sch, _ := scheme.GetSchemeByID(scheme.DefaultSchemeID)
+	verifier := NewVerifier(sch)
+	beacons := signedBeacons(b, verifier, secret, 10000)
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, beacon := range beacons {
+				require.NoError(b, verifier.VerifyBeacon(beacon, dp.Key()))
+			}
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			require.NoError(b, verifier.VerifyBeaconsBatch(dp, beacons))
+		}
+	})
+}
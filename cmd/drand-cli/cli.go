@@ -619,24 +619,23 @@ func keygenCmd(c *cli.Context) error {
 		addr = addr + ":" + askPort()
 	}
 
-	var priv *key.Pair
-	if c.Bool(insecureFlag.Name) {
+	insecure := c.Bool(insecureFlag.Name)
+	if insecure {
 		fmt.Println("Generating private / public key pair without TLS.")
-		priv = key.NewKeyPair(addr)
 	} else {
 		fmt.Println("Generating private / public key pair with TLS indication")
-		priv = key.NewTLSKeyPair(addr)
 	}
 
 	config := contextToConfig(c)
 	beaconID := getBeaconID(c)
 	fileStore := key.NewFileStore(config.ConfigFolderMB(), beaconID)
 
-	if _, err := fileStore.LoadKeyPair(); err == nil {
+	priv, err := key.Init(fileStore, addr, key.WithTLS(!insecure))
+	if errors.Is(err, key.ErrExists) {
 		fmt.Fprintf(output, "Keypair already present in `%s`.\nRemove them before generating new one\n", config.ConfigFolderMB())
 		return nil
 	}
-	if err := fileStore.SaveKeyPair(priv); err != nil {
+	if err != nil {
 		return fmt.Errorf("could not save key: %s", err)
 	}
 
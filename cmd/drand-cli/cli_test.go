@@ -172,7 +172,7 @@ func TestKeySelfSign(t *testing.T) {
 	pair, err := fileStore.LoadKeyPair()
 	require.NoError(t, err)
 	pair.Public.Signature = nil
-	require.NoError(t, fileStore.SaveKeyPair(pair))
+	require.NoError(t, fileStore.SaveKeyPair(pair, key.WithOverwrite(true)))
 
 	expectedOutput = "identity self signed"
 	testCommand(t, selfSign, expectedOutput)
@@ -510,7 +510,7 @@ func TestClientTLS(t *testing.T) {
 
 	config := core.NewConfig(core.WithConfigFolder(tmpPath))
 	fileStore := key.NewFileStore(config.ConfigFolderMB(), beaconID)
-	fileStore.SaveKeyPair(priv)
+	require.NoError(t, fileStore.SaveKeyPair(priv))
 
 	if httpscerts.Check(certPath, keyPath) != nil {
 		fmt.Println("generating on the fly")
@@ -542,7 +542,7 @@ func TestClientTLS(t *testing.T) {
 	scalarOne := key.KeyGroup.Scalar().One()
 	s := &share.PriShare{I: 2, V: scalarOne}
 	fakeShare := &key.Share{Share: s}
-	fileStore.SaveShare(fakeShare)
+	require.NoError(t, fileStore.SaveShare(fakeShare))
 
 	startArgs := []string{
 		"drand",
@@ -746,7 +746,7 @@ func launchDrandInstances(t *testing.T, n int) ([]*drandInstance, string) {
 		require.NoError(t, key.Save(pubPath, priv.Public, false))
 		config := core.NewConfig(core.WithConfigFolder(nodePath))
 		fileStore := key.NewFileStore(config.ConfigFolderMB(), beaconID)
-		fileStore.SaveKeyPair(priv)
+		require.NoError(t, fileStore.SaveKeyPair(priv))
 
 		h, _, _ := gnet.SplitHostPort(addr)
 		if err := httpscerts.Generate(certPath, keyPath, h); err != nil {
@@ -564,7 +564,7 @@ func selfSign(c *cli.Context) error {
 	}
 
 	pair.SelfSign()
-	if err := fs.SaveKeyPair(pair); err != nil {
+	if err := fs.SaveKeyPair(pair, key.WithOverwrite(true)); err != nil {
 		return fmt.Errorf("beacon id [%s] - saving identity: %s", beaconID, err)
 	}
 
@@ -1,6 +1,8 @@
 package scheme
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
 	"os"
 )
@@ -17,8 +19,24 @@ type Scheme struct {
 	DecouplePrevSig bool
 }
 
+// schemes is the table of the known schemes, keyed by their ID
 var schemes = []Scheme{{ID: DefaultSchemeID, DecouplePrevSig: false}, {ID: UnchainedSchemeID, DecouplePrevSig: true}}
 
+// DigestBeacon returns the message signed by the beacon of the given round
+// under this scheme: the hash of the round, preceded by the signature of the
+// previous round for chained schemes. The previous signature is ignored by
+// the schemes decoupling it.
+func (s Scheme) DigestBeacon(round uint64, prevSig []byte) []byte {
+	h := sha256.New()
+	if !s.DecouplePrevSig {
+		_, _ = h.Write(prevSig)
+	}
+	var buff [8]byte
+	binary.BigEndian.PutUint64(buff[:], round)
+	_, _ = h.Write(buff[:])
+	return h.Sum(nil)
+}
+
 // GetSchemeByID allows the user to retrieve the scheme configuration looking by its ID. It will return a boolean which indicates
 // if the scheme was found or not.
 func GetSchemeByID(id string) (scheme Scheme, found bool) {
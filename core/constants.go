@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/drand/drand/fs"
+	"github.com/drand/drand/key"
 )
 
 // DefaultConfigFolderName is the name of the folder containing all key materials
@@ -13,8 +14,12 @@ import (
 // directory.
 const DefaultConfigFolderName = ".drand"
 
-// DefaultConfigFolder returns the default path of the configuration folder.
+// DefaultConfigFolder returns the default path of the configuration folder,
+// as resolved by key.DefaultConfigFolder.
 func DefaultConfigFolder() string {
+	if folder, err := key.DefaultConfigFolder(); err == nil {
+		return folder
+	}
 	return path.Join(fs.HomeFolder(), DefaultConfigFolderName)
 }
 
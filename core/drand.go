@@ -96,6 +96,12 @@ func initDrand(s key.Store, c *Config) (*Drand, error) {
 	if err := priv.Public.ValidSignature(); err != nil {
 		logger.Errorw("", "INVALID SELF SIGNATURE", err, "action", "run `drand util self-sign`")
 	}
+	var expiring *key.ExpiryWarning
+	if err := priv.CheckExpiry(c.clock.Now()); errors.As(err, &expiring) {
+		logger.Warnw("", "key_pair", "expires soon", "not_after", expiring.NotAfter, "action", "rotate the key pair")
+	} else if err != nil {
+		return nil, err
+	}
 
 	// trick to always set the listening address by default based on the
 	// identity. If there is an option to set the address, it will override the
@@ -204,7 +210,7 @@ func (d *Drand) WaitDKG() (*key.Group, error) {
 
 	s := key.Share(*res.Result.Key)
 	d.share = &s
-	if err := d.store.SaveShare(d.share); err != nil {
+	if err := d.store.SaveDKGResult(d.share, d.share.Public(), key.WithOverwrite(d.replacesShare())); err != nil {
 		return nil, err
 	}
 	targetGroup := d.dkgInfo.target
@@ -212,6 +218,11 @@ func (d *Drand) WaitDKG() (*key.Group, error) {
 	targetGroup.Nodes = qualNodes
 	// setup the dist. public key
 	targetGroup.PublicKey = d.share.Public()
+	if kept := d.keptDistPublic(); kept != nil {
+		if err := d.share.VerifyAgainst(kept, targetGroup); err != nil {
+			return nil, fmt.Errorf("drand: refreshed share: %w", err)
+		}
+	}
 	d.group = targetGroup
 	var output []string
 	for _, node := range qualNodes {
@@ -227,6 +238,35 @@ func (d *Drand) WaitDKG() (*key.Group, error) {
 	return d.group, nil
 }
 
+// replacesShare tells whether the result of the running DKG may replace the
+// stored share. A resharing replaces the share of the current group. A fresh
+// DKG only replaces a share left by a setup that did not complete, i.e. when
+// no group holding a distributed key is stored, never the share of a running
+// network.
+func (d *Drand) replacesShare() bool {
+	if d.dkgInfo.conf != nil && d.dkgInfo.conf.OldNodes != nil {
+		return true
+	}
+	group, err := d.store.LoadGroup()
+	return err != nil || group.PublicKey == nil
+}
+
+// keptDistPublic returns the distributed public key of the previous group when
+// the running DKG is a resharing, which must keep it, or nil otherwise.
+func (d *Drand) keptDistPublic() *key.DistPublic {
+	conf := d.dkgInfo.conf
+	if conf == nil || conf.OldNodes == nil {
+		return nil
+	}
+	if conf.Share != nil {
+		return &key.DistPublic{Coefficients: conf.Share.Commits}
+	}
+	if len(conf.PublicCoeffs) > 0 {
+		return &key.DistPublic{Coefficients: conf.PublicCoeffs}
+	}
+	return nil
+}
+
 // StartBeacon initializes the beacon if needed and launch a go
 // routine that runs the generation loop.
 func (d *Drand) StartBeacon(catchup bool) {
@@ -3,8 +3,6 @@ package core
 import (
 	"bytes"
 	"context"
-	"crypto/sha256"
-	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -157,17 +155,13 @@ func (d *Drand) runDKG(leader bool, group *key.Group, timeout uint32, randomness
 	beaconID := group.ID
 
 	reader, user := extractEntropy(randomness)
-	config := &dkg.Config{
-		Suite:          key.KeyGroup.(dkg.Suite),
-		NewNodes:       group.DKGNodes(),
-		Longterm:       d.priv.Key,
-		Reader:         reader,
-		UserReaderOnly: user,
-		FastSync:       true,
-		Threshold:      group.Threshold,
-		Nonce:          getNonce(group),
-		Auth:           key.DKGAuthScheme,
+	dkgConf, err := group.ToDKGConfig()
+	if err != nil {
+		return nil, err
 	}
+	config := dkgConf.Config(d.priv.Key)
+	config.Reader = reader
+	config.UserReaderOnly = user
 	phaser := d.getPhaser(timeout, beaconID)
 	board := newEchoBroadcast(d.log, d.version, beaconID, d.privGateway.ProtocolClient,
 		d.priv.Public.Address(), group.Nodes, func(p dkg.Packet) error {
@@ -243,18 +237,18 @@ func (d *Drand) runResharing(leader bool, oldGroup, newGroup *key.Group, timeout
 	}
 	newNode := newGroup.Find(d.priv.Public)
 	newPresent := newNode != nil
-	config := &dkg.Config{
-		Suite:        key.KeyGroup.(dkg.Suite),
-		NewNodes:     newGroup.DKGNodes(),
-		OldNodes:     oldGroup.DKGNodes(),
-		Longterm:     d.priv.Key,
-		Threshold:    newGroup.Threshold,
-		OldThreshold: oldGroup.Threshold,
-		FastSync:     true,
-		Nonce:        getNonce(newGroup),
-		Auth:         key.DKGAuthScheme,
-	}
-	err := func() error {
+	newConf, err := newGroup.ToDKGConfig()
+	if err != nil {
+		return nil, err
+	}
+	oldConf, err := oldGroup.ToDKGConfig()
+	if err != nil {
+		return nil, err
+	}
+	config := newConf.Config(d.priv.Key)
+	config.OldNodes = oldConf.Nodes
+	config.OldThreshold = oldConf.Threshold
+	err = func() error {
 		d.state.Lock()
 		defer d.state.Unlock()
 		// gives the share to the dkg if we are a current node
@@ -403,13 +397,13 @@ func (d *Drand) setupAutomaticDKG(_ context.Context, in *drand.InitDKGPacket) (*
 		return nil, errors.New("control: group with genesis time in the past")
 	}
 
-	node := group.Find(d.priv.Public)
-	if node == nil {
+	index, err := group.NodeIndex(d.priv.Public.Key)
+	if err != nil {
 		d.log.Errorw("", "beacon_id", beaconID, "init_dkg", "absent_public_key_in_received_group")
 		return nil, errors.New("drand: public key not found in group")
 	}
 	d.state.Lock()
-	d.index = int(node.Index)
+	d.index = index
 	d.state.Unlock()
 
 	// run the dkg
@@ -494,17 +488,17 @@ func (d *Drand) setupAutomaticResharing(_ context.Context, oldGroup *key.Group,
 		return nil, err
 	}
 
-	node := newGroup.Find(d.priv.Public)
-	if node == nil {
+	index, err := newGroup.NodeIndex(d.priv.Public.Key)
+	if err != nil {
 		// It is ok to not have our key found in the new group since we may just
 		// be a node that is leaving the network, but leaving gracefully, by
 		// still participating in the resharing.
 		d.log.Infow("", "beacon_id", beaconID, "setup_reshare", "not_found_in_new_group")
 	} else {
 		d.state.Lock()
-		d.index = int(node.Index)
+		d.index = index
 		d.state.Unlock()
-		d.log.Infow("", "beacon_id", beaconID, "setup_reshare", "participate_newgroup", "index", node.Index)
+		d.log.Infow("", "beacon_id", beaconID, "setup_reshare", "participate_newgroup", "index", index)
 	}
 
 	// run the dkg !
@@ -979,16 +973,6 @@ func (d *Drand) pushDKGInfo(outgoing, incoming []*key.Node, previousThreshold in
 	return nil
 }
 
-func getNonce(g *key.Group) []byte {
-	h := sha256.New()
-	if g.TransitionTime != 0 {
-		_ = binary.Write(h, binary.BigEndian, g.TransitionTime)
-	} else {
-		_ = binary.Write(h, binary.BigEndian, g.GenesisTime)
-	}
-	return h.Sum(nil)
-}
-
 // StartFollowChain syncs up with a chain from other nodes
 //nolint:funlen
 func (d *Drand) StartFollowChain(req *drand.StartFollowRequest, stream drand.Control_StartFollowChainServer) error {
@@ -17,6 +17,8 @@ import (
 	"github.com/drand/drand/key"
 	"github.com/drand/drand/net"
 	"github.com/drand/drand/protobuf/drand"
+	"github.com/drand/drand/test"
+	"github.com/drand/kyber/share/dkg"
 	"github.com/stretchr/testify/require"
 )
 
@@ -926,3 +928,33 @@ func TestDrandPublicStreamProxy(t *testing.T) {
 		require.Equal(t, round, beacon.Round())
 	}
 }
+
+func TestDrandReplacesShare(t *testing.T) {
+	store := key.NewFileStore(t.TempDir(), "")
+	d := &Drand{store: store, dkgInfo: &dkgInfo{conf: &dkg.Config{}}}
+
+	// a share left by a setup that did not complete
+	require.True(t, d.replacesShare())
+
+	// the share of a running network is only replaced by a resharing
+	_, group := test.BatchIdentities(3, scheme.GetSchemeFromEnv(), common.DefaultBeaconID)
+	require.NoError(t, store.SaveGroup(group))
+	require.False(t, d.replacesShare())
+	d.dkgInfo.conf.OldNodes = []dkg.Node{{Index: 0}}
+	require.True(t, d.replacesShare())
+}
+
+func TestDrandKeptDistPublic(t *testing.T) {
+	d := &Drand{dkgInfo: &dkgInfo{conf: &dkg.Config{}}}
+	require.Nil(t, d.keptDistPublic())
+
+	// a new node of a resharing keeps the key of the old group
+	_, group := test.BatchIdentities(3, scheme.GetSchemeFromEnv(), common.DefaultBeaconID)
+	d.dkgInfo.conf.OldNodes = []dkg.Node{{Index: 0}}
+	d.dkgInfo.conf.PublicCoeffs = group.PublicKey.Coefficients
+	require.True(t, d.keptDistPublic().Equal(group.PublicKey))
+
+	// an old node keeps the key its share commits to
+	d.dkgInfo.conf.Share = &dkg.DistKeyShare{Commits: group.PublicKey.Coefficients[:1]}
+	require.True(t, d.keptDistPublic().Key().Equal(group.PublicKey.Key()))
+}
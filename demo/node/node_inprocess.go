@@ -109,7 +109,10 @@ func (l *LocalNode) Start(certFolder string) error {
 	}
 	conf := core.NewConfig(opts...)
 	fs := key.NewFileStore(conf.ConfigFolderMB(), l.beaconID)
-	fs.SaveKeyPair(l.priv)
+	// the node keeps its key pair across restarts
+	if err := fs.SaveKeyPair(l.priv, key.WithOverwrite(true)); err != nil {
+		return err
+	}
 	key.Save(path.Join(l.base, "public.toml"), l.priv.Public, false)
 	if l.daemon == nil {
 		drand, err := core.NewDrand(fs, conf)
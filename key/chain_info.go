@@ -0,0 +1,193 @@
+package key
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/drand/drand/common/scheme"
+	kyber "github.com/drand/kyber"
+)
+
+// ChainInfoJSON is the information clients need to verify the beacons of a
+// chain, in the canonical JSON form served at the /info endpoint. Binary
+// fields are hex encoded.
+type ChainInfoJSON struct {
+	// PublicKey is the collective public key verifying the beacons
+	PublicKey string `json:"public_key,omitempty"`
+	// Period is the period of the beacons, in seconds
+	Period      uint32 `json:"period,omitempty"`
+	GenesisTime int64  `json:"genesis_time,omitempty"`
+	// Hash is the chain hash, identifying the chain whatever the nodes
+	// running it
+	Hash string `json:"hash,omitempty"`
+	// GroupHash is the genesis seed of the chain, the hash of the group
+	// which ran the first DKG
+	GroupHash string `json:"groupHash,omitempty"`
+	SchemeID  string `json:"schemeID,omitempty"`
+	Metadata  struct {
+		BeaconID string `json:"beaconID,omitempty"`
+	} `json:"metadata"`
+}
+
+// ChainInfo returns the chain information of the group, as served to clients.
+// It only needs the public objects of the store: the group and the distributed
+// public key, which can be nil if the group holds it. The chain hash is the
+// one returned by ChainHash.
+func ChainInfo(g *Group, dp *DistPublic) (*ChainInfoJSON, error) {
+	public, err := chainPublicKey(g, dp)
+	if err != nil {
+		return nil, err
+	}
+	period := uint32(g.Period.Seconds())
+	seed := g.GetGenesisSeed()
+
+	info := &ChainInfoJSON{
+		PublicKey:   hex.EncodeToString(public),
+		Period:      period,
+		GenesisTime: g.GenesisTime,
+		Hash:        hex.EncodeToString(chainHash(g, public)),
+		GroupHash:   hex.EncodeToString(seed),
+		SchemeID:    g.Scheme.ID,
+	}
+	info.Metadata.BeaconID = g.ID
+	return info, nil
+}
+
+// chainPublicKey returns the marshaled collective key of the chain, from the
+// distributed public key or, if nil, the one of the group.
+func chainPublicKey(g *Group, dp *DistPublic) ([]byte, error) {
+	switch {
+	case dp == nil && g.PublicKey == nil:
+		return nil, errors.New("chain info: no distributed public key")
+	case dp == nil:
+		dp = g.PublicKey
+	case g.PublicKey != nil && !g.PublicKey.Equal(dp):
+		return nil, errors.New("chain info: distributed public key differs from the one of the group")
+	case g.DistPublicHash() != nil && !bytes.Equal(g.DistPublicHash(), dp.Hash()):
+		return nil, fmt.Errorf("chain info: %w", ErrDistPublicHash)
+	}
+	if len(dp.Coefficients) == 0 {
+		return nil, errors.New("chain info: empty distributed public key")
+	}
+	return dp.Key().MarshalBinary()
+}
+
+// ChainHash returns the hash identifying the chain run by the group, as
+// computed by the chain package and the clients. It is the SHA-256 hash of, in
+// order:
+//   - the period, in whole seconds, as a big endian uint32
+//   - the genesis time, in seconds since the epoch, as a big endian int64
+//   - the collective public key, i.e. the first coefficient of the
+//     distributed public key, in its compressed binary form
+//   - the genesis seed, the hash of the group which ran the first DKG, as
+//     returned by GetGenesisSeed
+//   - the beacon id, as raw bytes, unless it is empty so that the hash of
+//     chains created before beacon ids is unchanged
+//
+// The scheme is not part of the hash: chains differing only by their scheme
+// have different collective keys. It returns nil if the group holds no
+// distributed public key.
+func ChainHash(g *Group) []byte {
+	if g.PublicKey == nil || len(g.PublicKey.Coefficients) == 0 {
+		return nil
+	}
+	public, err := g.PublicKey.Key().MarshalBinary()
+	if err != nil {
+		return nil
+	}
+	return chainHash(g, public)
+}
+
+func chainHash(g *Group, public []byte) []byte {
+	h := sha256.New()
+	_ = binary.Write(h, binary.BigEndian, uint32(g.Period.Seconds()))
+	_ = binary.Write(h, binary.BigEndian, g.GenesisTime)
+	_, _ = h.Write(public)
+	_, _ = h.Write(g.GetGenesisSeed())
+	if g.ID != "" {
+		_, _ = h.Write([]byte(g.ID))
+	}
+	return h.Sum(nil)
+}
+
+// ErrChainInfoHash is returned when importing chain information whose chain
+// hash is not the one of its other fields.
+var ErrChainInfoHash = errors.New("chain info: hash does not match the chain parameters")
+
+// ImportChainInfo reads the chain information in its canonical JSON form, as
+// served at the /info endpoint of a network, and saves to the store the
+// objects a verifier needs: a verifier group, without nodes and with a zero
+// threshold, holding the parameters of the chain, and the distributed public
+// key reduced to the collective key. It checks the chain hash of the
+// information is the one of its other fields, and returns an error wrapping
+// ErrChainInfoHash otherwise. It is the bootstrap path of the stores of the
+// nodes following a chain without taking part in it.
+func ImportChainInfo(s Store, r io.Reader) (*Group, *DistPublic, error) {
+	data, err := readLimited(r, DefaultMaxFileSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	info := new(ChainInfoJSON)
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, nil, fmt.Errorf("chain info: %v", err)
+	}
+	g, err := info.verifierGroup()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.SaveGroup(g); err != nil {
+		return nil, nil, err
+	}
+	if err := s.SaveDistPublic(g.PublicKey); err != nil {
+		return nil, nil, err
+	}
+	return g, g.PublicKey, nil
+}
+
+// verifierGroup returns the verifier group described by the chain information,
+// after checking the chain hash.
+func (info *ChainInfoJSON) verifierGroup() (*Group, error) {
+	public, err := hex.DecodeString(info.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("chain info: decoding the public key: %v", err)
+	}
+	key := KeyGroup.Point()
+	if err := key.UnmarshalBinary(public); err != nil {
+		return nil, fmt.Errorf("chain info: %w: %v", ErrInvalidPoint, err)
+	}
+	seed, err := hex.DecodeString(info.GroupHash)
+	if err != nil || len(seed) == 0 {
+		return nil, fmt.Errorf("chain info: invalid group hash %q", info.GroupHash)
+	}
+	hash, err := hex.DecodeString(info.Hash)
+	if err != nil || len(hash) == 0 {
+		return nil, fmt.Errorf("chain info: invalid hash %q", info.Hash)
+	}
+	if info.Period == 0 {
+		return nil, errors.New("chain info: period is zero")
+	}
+	sch, err := scheme.GetSchemeByIDWithDefault(info.SchemeID)
+	if err != nil {
+		return nil, fmt.Errorf("chain info: %v", err)
+	}
+	g := &Group{
+		Period:      time.Duration(info.Period) * time.Second,
+		GenesisTime: info.GenesisTime,
+		GenesisSeed: seed,
+		Scheme:      sch,
+		ID:          info.Metadata.BeaconID,
+		PublicKey:   &DistPublic{Coefficients: []kyber.Point{key}},
+		verifier:    true,
+	}
+	if computed := chainHash(g, public); !bytes.Equal(computed, hash) {
+		return nil, fmt.Errorf("%w: %x announced, %x computed", ErrChainInfoHash, hash, computed)
+	}
+	return g, nil
+}
@@ -0,0 +1,65 @@
+package key
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/drand/drand/protobuf/common"
+	"github.com/drand/drand/protobuf/drand"
+)
+
+// ChainInfoProto returns the chain information of the group, as ChainInfo
+// does, encoded as a drand ChainInfoPacket protobuf message, the form served
+// to gRPC clients. The fields are, by number:
+//  1. the collective public key, in its compressed binary form
+//  2. the period, in whole seconds
+//  3. the genesis time, in seconds since the epoch
+//  4. the chain hash, see ChainHash
+//  5. the genesis seed, the hash of the group which ran the first DKG
+//  6. the scheme id
+//  7. the metadata, holding the beacon id in its field 2
+//
+// The encoding is deterministic, so that the same chain always gets the same
+// bytes.
+func ChainInfoProto(g *Group, dp *DistPublic) ([]byte, error) {
+	public, err := chainPublicKey(g, dp)
+	if err != nil {
+		return nil, err
+	}
+	packet := &drand.ChainInfoPacket{
+		PublicKey:   public,
+		Period:      uint32(g.Period.Seconds()),
+		GenesisTime: g.GenesisTime,
+		Hash:        chainHash(g, public),
+		GroupHash:   g.GetGenesisSeed(),
+		SchemeID:    g.Scheme.ID,
+		Metadata:    &common.Metadata{BeaconID: g.ID},
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(packet)
+}
+
+// DecodeChainInfoProto decodes the chain information encoded by
+// ChainInfoProto, or served by a node over gRPC. As ImportChainInfo, it checks
+// the chain hash is the one of the other fields, and returns an error wrapping
+// ErrChainInfoHash otherwise.
+func DecodeChainInfoProto(data []byte) (*ChainInfoJSON, error) {
+	packet := new(drand.ChainInfoPacket)
+	if err := proto.Unmarshal(data, packet); err != nil {
+		return nil, fmt.Errorf("chain info: %v", err)
+	}
+	info := &ChainInfoJSON{
+		PublicKey:   hex.EncodeToString(packet.PublicKey),
+		Period:      packet.Period,
+		GenesisTime: packet.GenesisTime,
+		Hash:        hex.EncodeToString(packet.Hash),
+		GroupHash:   hex.EncodeToString(packet.GroupHash),
+		SchemeID:    packet.SchemeID,
+	}
+	info.Metadata.BeaconID = packet.GetMetadata().GetBeaconID()
+	if _, err := info.verifierGroup(); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
@@ -0,0 +1,85 @@
+package key
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drand/drand/common/scheme"
+	kyber "github.com/drand/kyber"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainInfoProtoGolden(t *testing.T) {
+	// the League of Entropy mainnet
+	public, err := StringToPoint(KeyGroup, "868f005eb8e6e4ca0a47c8a77ceaa5309a47978a7c71bc5cce96366b5d7a569937c529eeda66c7293784a9402801af31")
+	require.NoError(t, err)
+	seed, err := hex.DecodeString("176f93498eac9ca337150b46d21dd58673ea4e3581185f869672e59fa4cb390a")
+	require.NoError(t, err)
+	sch, err := scheme.GetSchemeByIDWithDefault(scheme.DefaultSchemeID)
+	require.NoError(t, err)
+	mainnet := &Group{
+		Period:      30 * time.Second,
+		GenesisTime: 1595431050,
+		GenesisSeed: seed,
+		Scheme:      sch,
+	}
+	golden := strings.Join([]string{
+		// 1: public key, 48 bytes
+		"0a30868f005eb8e6e4ca0a47c8a77ceaa5309a47978a7c71bc5cce96366b5d7a569937c529eeda66c7293784a9402801af31",
+		// 2: period, varint
+		"101e",
+		// 3: genesis time, varint
+		"188ab1e1f805",
+		// 4: chain hash, 32 bytes
+		"22208990e7a9aaed2ffed73dbd7092123d6f289930540d7651336225dc172e51b2ce",
+		// 5: genesis seed, 32 bytes
+		"2a20176f93498eac9ca337150b46d21dd58673ea4e3581185f869672e59fa4cb390a",
+		// 6: scheme id
+		"3214706564657273656e2d626c732d636861696e6564",
+		// 7: metadata, without a beacon id
+		"3a00",
+	}, "")
+
+	data, err := ChainInfoProto(mainnet, &DistPublic{Coefficients: []kyber.Point{public}})
+	require.NoError(t, err)
+	require.Equal(t, golden, hex.EncodeToString(data))
+
+	raw, err := hex.DecodeString(golden)
+	require.NoError(t, err)
+	info, err := DecodeChainInfoProto(raw)
+	require.NoError(t, err)
+	require.Equal(t, "8990e7a9aaed2ffed73dbd7092123d6f289930540d7651336225dc172e51b2ce", info.Hash)
+	require.Equal(t, uint32(30), info.Period)
+	require.Equal(t, scheme.DefaultSchemeID, info.SchemeID)
+}
+
+func TestChainInfoProto(t *testing.T) {
+	_, group := BatchIdentities(3)
+	_, dist := dealShares(3, group.Threshold)
+	group.ID = "test_beacon"
+	group.Period = 3 * time.Second
+	group.GenesisTime = 1600000000
+	group.PublicKey = dist
+
+	data, err := ChainInfoProto(group, nil)
+	require.NoError(t, err)
+	decoded, err := DecodeChainInfoProto(data)
+	require.NoError(t, err)
+	info, err := ChainInfo(group, nil)
+	require.NoError(t, err)
+	require.Equal(t, info, decoded)
+
+	// a tampered field no longer matches the chain hash
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = DecodeChainInfoProto(tampered)
+	require.ErrorIs(t, err, ErrChainInfoHash)
+
+	_, err = DecodeChainInfoProto([]byte{0xff})
+	require.Error(t, err)
+	group.PublicKey = nil
+	_, err = ChainInfoProto(group, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,128 @@
+package key
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drand/drand/common/scheme"
+	kyber "github.com/drand/kyber"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainInfo(t *testing.T) {
+	_, group := BatchIdentities(3)
+	group.PublicKey = nil
+	_, err := ChainInfo(group, nil)
+	require.Error(t, err)
+
+	_, dist := dealShares(3, group.Threshold)
+	group.ID = "test_beacon"
+	group.Period = 3 * time.Second
+	group.GenesisTime = 1600000000
+	group.Scheme = scheme.GetSchemeFromEnv()
+	info, err := ChainInfo(group, dist)
+	require.NoError(t, err)
+	require.Equal(t, uint32(group.Period.Seconds()), info.Period)
+	require.Equal(t, PointToString(dist.Key()), info.PublicKey)
+	require.Equal(t, group.Scheme.ID, info.SchemeID)
+
+	group.PublicKey = dist
+	fromGroup, err := ChainInfo(group, nil)
+	require.NoError(t, err)
+	require.Equal(t, info, fromGroup)
+	ref, err := ChainInfo(group.WithDistPublicReference(), dist)
+	require.NoError(t, err)
+	require.Equal(t, info, ref)
+
+	_, other := dealShares(3, group.Threshold)
+	_, err = ChainInfo(group, other)
+	require.Error(t, err)
+	_, err = ChainInfo(group.WithDistPublicReference(), other)
+	require.ErrorIs(t, err, ErrDistPublicHash)
+
+	buff, err := json.Marshal(info)
+	require.NoError(t, err)
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(buff, &fields))
+	for _, field := range []string{"public_key", "period", "genesis_time", "hash", "groupHash", "schemeID", "metadata"} {
+		require.Contains(t, fields, field)
+	}
+	require.Equal(t, map[string]interface{}{"beaconID": "test_beacon"}, fields["metadata"])
+}
+
+func TestChainHash(t *testing.T) {
+	// the League of Entropy mainnet, created before beacon ids
+	public, err := StringToPoint(KeyGroup, "868f005eb8e6e4ca0a47c8a77ceaa5309a47978a7c71bc5cce96366b5d7a569937c529eeda66c7293784a9402801af31")
+	require.NoError(t, err)
+	seed, err := hex.DecodeString("176f93498eac9ca337150b46d21dd58673ea4e3581185f869672e59fa4cb390a")
+	require.NoError(t, err)
+	mainnet := &Group{
+		Period:      30 * time.Second,
+		GenesisTime: 1595431050,
+		GenesisSeed: seed,
+		PublicKey:   &DistPublic{Coefficients: []kyber.Point{public}},
+	}
+	require.Equal(t, "8990e7a9aaed2ffed73dbd7092123d6f289930540d7651336225dc172e51b2ce", hex.EncodeToString(ChainHash(mainnet)))
+
+	// the beacon id is part of the hash
+	mainnet.ID = "default"
+	require.NotEqual(t, "8990e7a9aaed2ffed73dbd7092123d6f289930540d7651336225dc172e51b2ce", hex.EncodeToString(ChainHash(mainnet)))
+	info, err := ChainInfo(mainnet, nil)
+	require.NoError(t, err)
+	require.Equal(t, info.Hash, hex.EncodeToString(ChainHash(mainnet)))
+
+	mainnet.PublicKey = nil
+	require.Nil(t, ChainHash(mainnet))
+}
+
+func TestImportChainInfo(t *testing.T) {
+	// as published by the League of Entropy mainnet
+	mainnet := `{
+		"public_key": "868f005eb8e6e4ca0a47c8a77ceaa5309a47978a7c71bc5cce96366b5d7a569937c529eeda66c7293784a9402801af31",
+		"period": 30,
+		"genesis_time": 1595431050,
+		"hash": "8990e7a9aaed2ffed73dbd7092123d6f289930540d7651336225dc172e51b2ce",
+		"groupHash": "176f93498eac9ca337150b46d21dd58673ea4e3581185f869672e59fa4cb390a"
+	}`
+	store := NewFileStore(t.TempDir(), "")
+	group, dist, err := ImportChainInfo(store, strings.NewReader(mainnet))
+	require.NoError(t, err)
+	require.Zero(t, group.Len())
+	require.Equal(t, 30*time.Second, group.Period)
+	require.Equal(t, scheme.DefaultSchemeID, group.Scheme.ID)
+	require.Len(t, dist.Coefficients, 1)
+
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(group))
+	loadedDist, err := store.LoadDistPublic()
+	require.NoError(t, err)
+	require.True(t, loadedDist.Equal(dist))
+	info, err := ChainInfo(loaded, loadedDist)
+	require.NoError(t, err)
+	require.Equal(t, "8990e7a9aaed2ffed73dbd7092123d6f289930540d7651336225dc172e51b2ce", info.Hash)
+	// a verifier group can't run a DKG
+	require.Error(t, loaded.Valid())
+
+	// only groups marked as verifiers can be empty
+	emptied := &Group{Period: group.Period, GenesisTime: group.GenesisTime, Scheme: group.Scheme}
+	require.NoError(t, Save(store.(*fileStore).groupFile, emptied, false))
+	_, err = store.LoadGroup()
+	require.Error(t, err)
+
+	for name, tampered := range map[string]string{
+		"hash":   strings.Replace(mainnet, `"hash": "8990`, `"hash": "8991`, 1),
+		"period": strings.Replace(mainnet, `"period": 30`, `"period": 3`, 1),
+		"beacon": strings.Replace(mainnet, `"period": 30`, `"period": 30, "metadata": {"beaconID": "other"}`, 1),
+	} {
+		_, _, err := ImportChainInfo(NewFileStore(t.TempDir(), ""), strings.NewReader(tampered))
+		require.ErrorIs(t, err, ErrChainInfoHash, name)
+	}
+	_, _, err = ImportChainInfo(NewFileStore(t.TempDir(), ""), strings.NewReader(strings.Replace(mainnet, `"868f`, `"968f`, 1)))
+	require.ErrorIs(t, err, ErrInvalidPoint)
+	_, _, err = ImportChainInfo(NewFileStore(t.TempDir(), ""), strings.NewReader("{"))
+	require.Error(t, err)
+}
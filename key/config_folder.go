@@ -0,0 +1,39 @@
+package key
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// configFolderName is the name of the drand folder in the user configuration
+// directory, see os.UserConfigDir.
+const configFolderName = "drand"
+
+// legacyConfigFolderName is the name of the drand folder in the home
+// directory, used before drand followed the convention of the OS and when the
+// user configuration directory is unknown.
+const legacyConfigFolderName = ".drand"
+
+// DefaultConfigFolder returns the folder where drand keeps its key material
+// by default. It follows the convention of the OS, see os.UserConfigDir, and
+// resolves to $XDG_CONFIG_HOME/drand, or ~/.config/drand, on Linux,
+// ~/Library/Application Support/drand on macOS and %AppData%\drand on
+// Windows. A ~/.drand folder that already exists is always used, so existing
+// nodes keep their keys, and ~/.drand is also used when the configuration
+// directory is unknown or relative. It does not create any folder.
+func DefaultConfigFolder() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("config folder: %w", err)
+	}
+	legacy := filepath.Join(home, legacyConfigFolderName)
+	if _, err := os.Stat(legacy); err == nil {
+		return legacy, nil
+	}
+	// relative paths must be ignored as per the XDG specification
+	if config, err := os.UserConfigDir(); err == nil && filepath.IsAbs(config) {
+		return filepath.Join(config, configFolderName), nil
+	}
+	return legacy, nil
+}
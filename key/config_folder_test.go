@@ -0,0 +1,43 @@
+package key
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultConfigFolder(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the folders are only read from HOME and XDG_CONFIG_HOME on linux")
+	}
+	home, xdg := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	folder, err := DefaultConfigFolder()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(home, ".config", "drand"), folder)
+
+	t.Setenv("XDG_CONFIG_HOME", "relative/config")
+	folder, err = DefaultConfigFolder()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(home, ".drand"), folder)
+
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	folder, err = DefaultConfigFolder()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(xdg, "drand"), folder)
+
+	// an existing folder in the home directory takes precedence
+	require.NoError(t, os.Mkdir(filepath.Join(home, ".drand"), 0740))
+	folder, err = DefaultConfigFolder()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(home, ".drand"), folder)
+
+	// nothing is ever created
+	_, err = os.Stat(filepath.Join(xdg, "drand"))
+	require.True(t, os.IsNotExist(err))
+}
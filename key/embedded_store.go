@@ -0,0 +1,116 @@
+package key
+
+import (
+	"bytes"
+	iofs "io/fs"
+	"path"
+)
+
+// embeddedStore is a read-only Store reading its objects from an io/fs file
+// system, such as an embed.FS bundled in a binary. It uses the same layout as
+// the file store under its base folder.
+type embeddedStore struct {
+	fsys           iofs.FS
+	privateKeyFile string
+	publicKeyFile  string
+	shareFile      string
+	distKeyFile    string
+	groupFile      string
+	codecs         []Codec
+	closed         *closeState
+}
+
+// NewEmbeddedStore returns a read-only store loading the objects found in the
+// base folder of the given file system. The base folder is the folder of a
+// beacon, i.e. the one holding the key and groups folders. All save
+// operations return ErrReadOnly.
+func NewEmbeddedStore(fsys iofs.FS, base string) Store {
+	keyFolder := path.Join(base, KeyFolderName)
+	groupFolder := path.Join(base, GroupFolderName)
+	return &embeddedStore{
+		fsys:           fsys,
+		privateKeyFile: path.Join(keyFolder, keyFileName) + privateExtension,
+		publicKeyFile:  path.Join(keyFolder, keyFileName) + publicExtension,
+		groupFile:      path.Join(groupFolder, groupFileName),
+		shareFile:      path.Join(groupFolder, shareFileName),
+		distKeyFile:    path.Join(groupFolder, distKeyFileName),
+		closed:         newCloseState(),
+	}
+}
+
+func (e *embeddedStore) load(name string, t Tomler) error {
+	return wrapFileError(name, e.loadFile(name, t))
+}
+
+func (e *embeddedStore) loadFile(name string, t Tomler) error {
+	if err := e.closed.check(); err != nil {
+		return err
+	}
+	fd, err := e.fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	data, err := decodeAny(fd, e.codecs, DefaultMaxFileSize)
+	if err != nil {
+		return err
+	}
+	return Decode(bytes.NewReader(data), t)
+}
+
+func (e *embeddedStore) WithCodec(c Codec) Store {
+	derived := *e
+	derived.codecs = append([]Codec{c}, e.codecs...)
+	return &derived
+}
+
+func (e *embeddedStore) LoadKeyPair() (*Pair, error) {
+	p := new(Pair)
+	if err := e.load(e.privateKeyFile, p); err != nil {
+		return nil, err
+	}
+	return p, e.load(e.publicKeyFile, p.Public)
+}
+
+func (e *embeddedStore) LoadShare() (*Share, error) {
+	s := new(Share)
+	return s, e.load(e.shareFile, s)
+}
+
+func (e *embeddedStore) LoadGroup() (*Group, error) {
+	g := new(Group)
+	return g, e.load(e.groupFile, g)
+}
+
+func (e *embeddedStore) LoadDistPublic() (*DistPublic, error) {
+	d := new(DistPublic)
+	return d, e.load(e.distKeyFile, d)
+}
+
+func (e *embeddedStore) SaveDistPublic(*DistPublic, ...SaveOption) error {
+	return e.closed.readOnly()
+}
+
+func (e *embeddedStore) SaveDKGResult(*Share, *DistPublic, ...SaveOption) error {
+	return e.closed.readOnly()
+}
+
+func (e *embeddedStore) SaveKeyPair(*Pair, ...SaveOption) error {
+	return e.closed.readOnly()
+}
+
+func (e *embeddedStore) SaveShare(*Share, ...SaveOption) error {
+	return e.closed.readOnly()
+}
+
+func (e *embeddedStore) SaveGroup(*Group, ...SaveOption) error {
+	return e.closed.readOnly()
+}
+
+func (e *embeddedStore) CompareAndSwapGroup(_, _ *Group) error {
+	return e.closed.readOnly()
+}
+
+func (e *embeddedStore) Reset(...ResetOption) error {
+	return e.closed.readOnly()
+}
@@ -0,0 +1,42 @@
+package key
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedStore(t *testing.T) {
+	ps, group := BatchIdentities(3)
+	tmp := t.TempDir()
+	fileStore := NewFileStore(tmp, "").(*fileStore)
+	require.NoError(t, fileStore.SaveKeyPair(ps[0]))
+	require.NoError(t, fileStore.SaveGroup(group))
+
+	fsys := fstest.MapFS{}
+	for _, file := range []string{fileStore.privateKeyFile, fileStore.publicKeyFile, fileStore.groupFile} {
+		buff, err := os.ReadFile(file)
+		require.NoError(t, err)
+		rel, err := filepath.Rel(tmp, file)
+		require.NoError(t, err)
+		fsys[path.Join("bundle", filepath.ToSlash(rel))] = &fstest.MapFile{Data: buff}
+	}
+
+	store := NewEmbeddedStore(fsys, path.Join("bundle", fileStore.beaconID))
+	pair, err := store.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, pair.Public.Equal(ps[0].Public))
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.Equal(t, group.Hash(), loaded.Hash())
+
+	_, err = store.LoadShare()
+	require.Error(t, err)
+	require.ErrorIs(t, store.SaveGroup(group), ErrReadOnly)
+	require.ErrorIs(t, store.SaveShare(nil), ErrReadOnly)
+	require.ErrorIs(t, store.Reset(), ErrReadOnly)
+}
@@ -0,0 +1,163 @@
+package key
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrDecrypt is returned when encrypted data can't be decrypted, either
+// because the passphrase is wrong or because the data is corrupted.
+var ErrDecrypt = errors.New("key: wrong passphrase or corrupted data")
+
+// encryptionMagic starts all the data encrypted with a passphrase codec.
+var encryptionMagic = []byte("drandenc")
+
+const (
+	encryptionVersion = 1
+	kdfScrypt         = 1
+	saltSize          = 16
+	encryptionKeySize = 32
+	// maxScryptLogN and maxScryptCost bound the parameters read from a
+	// header, so that crafted data can't exhaust the memory or the CPU of the
+	// node: scrypt uses 128·r·N bytes of memory and p times as much work
+	maxScryptLogN = 20
+	maxScryptCost = 128 * 8 << maxScryptLogN
+)
+
+// scryptParams are the parameters of the key derivation, stored in the header
+// of the encrypted data.
+type scryptParams struct {
+	logN, r, p uint8
+}
+
+var defaultScryptParams = scryptParams{logN: 15, r: 8, p: 1}
+
+// check returns an error if the parameters are out of the bounds accepted when
+// decrypting, see maxScryptCost.
+func (p scryptParams) check() error {
+	if p.logN > maxScryptLogN || p.r == 0 || p.p == 0 {
+		return fmt.Errorf("%w: invalid key derivation parameters N=2^%d r=%d p=%d", ErrDecrypt, p.logN, p.r, p.p)
+	}
+	if cost := uint64(128) * uint64(p.r) * uint64(p.p) << p.logN; cost > maxScryptCost {
+		return fmt.Errorf("%w: key derivation cost %d too high", ErrDecrypt, cost)
+	}
+	return nil
+}
+
+// NewPassphraseCodec returns a codec encrypting the data with AES-256-GCM
+// under a key derived from the passphrase with scrypt. The encrypted data
+// starts with a header holding the parameters of the derivation and a random
+// salt, so it can be decrypted knowing only the passphrase. Reading data
+// encrypted under another passphrase fails with ErrDecrypt.
+func NewPassphraseCodec(passphrase []byte) Codec {
+	return &passphraseCodec{passphrase: passphrase, params: defaultScryptParams}
+}
+
+// NewEncryptedStore returns a store encrypting all the objects with
+// NewPassphraseCodec before handing them to the inner store, which must be a
+// CodecStore as the file, object, keyring and embedded stores are, or a
+// retrying store around one, or the error wraps ErrNoCodecs. The encryption applies to the serialized objects, so the
+// backend of the inner store, e.g. a shared remote for NewObjectStore, only
+// ever sees ciphertext. Loading objects encrypted under another passphrase
+// fails with ErrDecrypt.
+func NewEncryptedStore(inner Store, passphrase []byte) (Store, error) {
+	return withCodec(inner, NewPassphraseCodec(passphrase))
+}
+
+type passphraseCodec struct {
+	passphrase []byte
+	params     scryptParams
+}
+
+// header returns the header of the encrypted data, which is authenticated
+// along with the ciphertext.
+func (p scryptParams) header(salt []byte) []byte {
+	h := append([]byte{}, encryptionMagic...)
+	h = append(h, encryptionVersion, kdfScrypt, p.logN, p.r, p.p)
+	return append(h, salt...)
+}
+
+func (c *passphraseCodec) aead(params scryptParams, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(c.passphrase, salt, 1<<params.logN, int(params.r), int(params.p), encryptionKeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (c *passphraseCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return &encryptingWriter{codec: c, w: w}, nil
+}
+
+// encryptingWriter buffers the plaintext and writes it encrypted on Close.
+type encryptingWriter struct {
+	codec *passphraseCodec
+	w     io.Writer
+	buf   bytes.Buffer
+}
+
+func (e *encryptingWriter) Write(p []byte) (int, error) {
+	return e.buf.Write(p)
+}
+
+func (e *encryptingWriter) Close() error {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	aead, err := e.codec.aead(e.codec.params, salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	header := e.codec.params.header(salt)
+	out := append(append(header, nonce...), aead.Seal(nil, nonce, e.buf.Bytes(), header)...)
+	_, err = e.w.Write(out)
+	return err
+}
+
+func (c *passphraseCodec) NewReader(r io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	headerSize := len(encryptionMagic) + 5 + saltSize
+	if len(data) < headerSize || !bytes.HasPrefix(data, encryptionMagic) {
+		return nil, fmt.Errorf("%w: not encrypted data", ErrDecrypt)
+	}
+	fields := data[len(encryptionMagic):]
+	if fields[0] != encryptionVersion || fields[1] != kdfScrypt {
+		return nil, fmt.Errorf("%w: unsupported version %d or key derivation %d", ErrDecrypt, fields[0], fields[1])
+	}
+	params := scryptParams{logN: fields[2], r: fields[3], p: fields[4]}
+	if err := params.check(); err != nil {
+		return nil, err
+	}
+	header, rest := data[:headerSize], data[headerSize:]
+	aead, err := c.aead(params, header[headerSize-saltSize:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+	if len(rest) < aead.NonceSize() {
+		return nil, fmt.Errorf("%w: truncated data", ErrDecrypt)
+	}
+	plain, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], header)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return bytes.NewReader(plain), nil
+}
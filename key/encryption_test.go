@@ -0,0 +1,102 @@
+package key
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func encryptedStore(t *testing.T, inner Store, passphrase []byte) Store {
+	t.Helper()
+	store, err := NewEncryptedStore(inner, passphrase)
+	require.NoError(t, err)
+	return store
+}
+
+func TestEncryptedStore(t *testing.T) {
+	backend := new(memoryObjects)
+	passphrase := []byte("correct horse battery staple")
+	store := encryptedStore(t, NewObjectStore(backend, ""), passphrase)
+	ps, group := BatchIdentities(3)
+	shares, dist := dealShares(3, 2)
+	group.PublicKey = dist
+
+	require.NoError(t, store.SaveKeyPair(ps[0]))
+	require.NoError(t, store.SaveGroup(group))
+	require.NoError(t, store.SaveDKGResult(shares[0], dist))
+
+	pair, err := store.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, pair.Equal(ps[0]))
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.Equal(t, group.Hash(), loaded.Hash())
+	share, err := store.LoadShare()
+	require.NoError(t, err)
+	require.True(t, share.Share.V.Equal(shares[0].Share.V))
+	loadedDist, err := store.LoadDistPublic()
+	require.NoError(t, err)
+	require.True(t, loadedDist.Equal(dist))
+
+	// the backend only sees ciphertext
+	require.Len(t, backend.objects, 5)
+	for name, data := range backend.objects {
+		require.True(t, bytes.HasPrefix(data, encryptionMagic), name)
+		require.NotContains(t, string(data), ps[0].Public.Addr, name)
+		require.NotContains(t, string(data), ScalarToString(ps[0].Key), name)
+		require.NotContains(t, string(data), PointToString(dist.Key()), name)
+	}
+
+	wrong := encryptedStore(t, NewObjectStore(backend, ""), []byte("wrong"))
+	_, err = wrong.LoadKeyPair()
+	require.ErrorIs(t, err, ErrDecrypt)
+	plain := NewObjectStore(backend, "")
+	_, err = plain.LoadGroup()
+	require.Error(t, err)
+
+	// the encryption applies to any store supporting codecs
+	files := encryptedStore(t, NewFileStore(t.TempDir(), ""), passphrase)
+	require.NoError(t, files.SaveKeyPair(ps[1]))
+	pair, err = files.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, pair.Equal(ps[1]))
+	_, err = NewEncryptedStore(&nonCodecStore{files}, passphrase)
+	require.ErrorIs(t, err, ErrNoCodecs)
+
+	// the secrets of a keyring store are encrypted too
+	secrets := new(memorySecrets)
+	keyring := encryptedStore(t, NewKeyringStore(secrets, t.TempDir(), ""), passphrase)
+	require.NoError(t, keyring.SaveKeyPair(ps[2]))
+	pair, err = keyring.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, pair.Equal(ps[2]))
+	for name, secret := range secrets.secrets {
+		require.True(t, bytes.HasPrefix(secret, encryptionMagic), name)
+	}
+
+	// as are the objects of a retrying store around one supporting codecs
+	retrying := encryptedStore(t, NewRetryingStore(NewObjectStore(backend, "retried"), RetryPolicy{}), passphrase)
+	require.NoError(t, retrying.SaveKeyPair(ps[2]))
+	pair, err = retrying.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, pair.Equal(ps[2]))
+	_, err = NewEncryptedStore(NewRetryingStore(&nonCodecStore{files}, RetryPolicy{}), passphrase)
+	require.ErrorIs(t, err, ErrNoCodecs)
+}
+
+func TestPassphraseCodecCost(t *testing.T) {
+	codec := NewPassphraseCodec([]byte("secret"))
+	salt := make([]byte, saltSize)
+	for _, params := range []scryptParams{
+		{logN: 21, r: 1, p: 1},
+		{logN: 20, r: 255, p: 1},
+		{logN: 15, r: 8, p: 255},
+		{logN: 15, r: 0, p: 1},
+	} {
+		data := append(params.header(salt), make([]byte, 64)...)
+		_, err := codec.NewReader(bytes.NewReader(data))
+		require.ErrorIs(t, err, ErrDecrypt, "%+v", params)
+	}
+	require.NoError(t, defaultScryptParams.check())
+}
@@ -0,0 +1,54 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrKeyExpired is returned when the key pair of the node is past its expiry
+// date.
+var ErrKeyExpired = errors.New("key pair expired")
+
+// ExpiryWarningPeriod is how long before its expiry date a key pair is
+// reported as expiring soon.
+const ExpiryWarningPeriod = 30 * 24 * time.Hour
+
+// ExpiryWarning is returned when the key pair expires within
+// ExpiryWarningPeriod. The key pair is still valid, but should be rotated.
+type ExpiryWarning struct {
+	NotAfter  time.Time
+	Remaining time.Duration
+}
+
+func (w *ExpiryWarning) Error() string {
+	return fmt.Sprintf("key pair expires in %s, on %s", w.Remaining, w.NotAfter.Format(time.RFC3339))
+}
+
+// CheckExpiry returns an error wrapping ErrKeyExpired if the key pair is
+// expired at the given time, and an *ExpiryWarning if it expires soon. A key
+// pair without expiry date never expires.
+func (p *Pair) CheckExpiry(now time.Time) error {
+	if p.NotAfter.IsZero() {
+		return nil
+	}
+	remaining := p.NotAfter.Sub(now)
+	switch {
+	case remaining <= 0:
+		return fmt.Errorf("%w on %s", ErrKeyExpired, p.NotAfter.Format(time.RFC3339))
+	case remaining <= ExpiryWarningPeriod:
+		return &ExpiryWarning{NotAfter: p.NotAfter, Remaining: remaining}
+	default:
+		return nil
+	}
+}
+
+// CheckExpiry loads the key pair of the store and checks its expiry date, see
+// Pair.CheckExpiry.
+func CheckExpiry(s Store, now time.Time) error {
+	p, err := s.LoadKeyPair()
+	if err != nil {
+		return err
+	}
+	return p.CheckExpiry(now)
+}
@@ -0,0 +1,38 @@
+package key
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyPairExpiry(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	store := NewFileStore(t.TempDir(), "")
+	pair := NewKeyPair("127.0.0.1:8080")
+
+	// no expiry
+	require.NoError(t, store.SaveKeyPair(pair))
+	require.NoError(t, CheckExpiry(store, now))
+
+	// far from expiry
+	pair.NotAfter = now.Add(2 * ExpiryWarningPeriod)
+	require.NoError(t, store.SaveKeyPair(pair, WithOverwrite(true)))
+	loaded, err := store.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, pair.NotAfter.Equal(loaded.NotAfter))
+	require.NoError(t, CheckExpiry(store, now))
+
+	// near expiry
+	err = CheckExpiry(store, pair.NotAfter.Add(-time.Hour))
+	var warning *ExpiryWarning
+	require.True(t, errors.As(err, &warning))
+	require.Equal(t, time.Hour, warning.Remaining)
+	require.False(t, errors.Is(err, ErrKeyExpired))
+
+	// expired
+	require.ErrorIs(t, CheckExpiry(store, pair.NotAfter), ErrKeyExpired)
+	require.ErrorIs(t, CheckExpiry(store, pair.NotAfter.Add(time.Hour)), ErrKeyExpired)
+}
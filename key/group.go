@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"hash"
 	"sort"
+	"sync/atomic"
 	"time"
 
 	commonutils "github.com/drand/drand/common"
@@ -55,6 +56,17 @@ type Group struct {
 	// The distributed public key of this group. It is nil if the group has not
 	// ran a DKG protocol yet.
 	PublicKey *DistPublic
+
+	// addrIndex holds the *addressIndex speeding up NodeByAddress
+	addrIndex atomic.Value
+	// metadata holds the annotations of the operators, see SetMetadata
+	metadata map[string]string
+	// distPublicHash is the hash of the distributed public key the group
+	// references instead of holding it, see WithDistPublicReference
+	distPublicHash []byte
+	// verifier is set for the groups without nodes only used to verify the
+	// beacons of a chain, see ImportChainInfo
+	verifier bool
 }
 
 // Find returns the Node that is equal to the given identity (without the
@@ -68,6 +80,19 @@ func (g *Group) Find(pub *Identity) *Node {
 	return nil
 }
 
+// NodeIndex returns the index of the member of the group holding the given
+// public key. It is the index of the share dealt to that member during the DKG,
+// i.e. its position in the list of members sorted by key when the group was
+// created. It returns an error if no member holds that key.
+func (g *Group) NodeIndex(pub kyber.Point) (int, error) {
+	for _, n := range g.Nodes {
+		if n.Identity != nil && n.Key.Equal(pub) {
+			return int(n.Index), nil
+		}
+	}
+	return 0, fmt.Errorf("group: public key %s is not a member", pub)
+}
+
 // Node returns the node at the given index if it exists in the group. If it does
 // not, Node() returns nil.
 func (g *Group) Node(i Index) *Node {
@@ -114,6 +139,8 @@ func (g *Group) Hash() []byte {
 
 	if g.PublicKey != nil {
 		_, _ = h.Write(g.PublicKey.Hash())
+	} else if g.distPublicHash != nil {
+		_, _ = h.Write(g.distPublicHash)
 	}
 
 	// Use it only if ID is not empty. Keep backward compatibility
@@ -138,6 +165,31 @@ func (g *Group) Len() int {
 	return len(g.Nodes)
 }
 
+// VerificationThreshold returns the number of partial signatures from distinct
+// nodes needed to recover a beacon signed with the distributed key of the
+// group. The threshold of the group is the one used for its next DKG or
+// resharing, while beacons are signed with the polynomial of the current
+// distributed key, whose degree is fixed by the DKG that produced it: once the
+// distributed key is known, the signing threshold is its number of
+// coefficients. Both are at least MinimumT and at most the size of the group.
+func (g *Group) VerificationThreshold() int {
+	if g.PublicKey != nil && len(g.PublicKey.Coefficients) > 0 {
+		return len(g.PublicKey.Coefficients)
+	}
+	return g.Threshold
+}
+
+// AllowedIndices returns, in increasing order, the indexes of the nodes whose
+// partial signatures can be used to recover a beacon.
+func (g *Group) AllowedIndices() []int {
+	indices := make([]int, 0, g.Len())
+	for _, n := range g.Nodes {
+		indices = append(indices, int(n.Index))
+	}
+	sort.Ints(indices)
+	return indices
+}
+
 func (g *Group) String() string {
 	var b bytes.Buffer
 	_ = toml.NewEncoder(&b).Encode(g.TOML())
@@ -183,7 +235,7 @@ func (g *Group) Equal(g2 *Group) bool {
 		return false
 	}
 
-	return true
+	return bytes.Equal(g.distPublicHash, g2.distPublicHash)
 }
 
 // GroupTOML is the representation of a Group TOML compatible
@@ -193,11 +245,29 @@ type GroupTOML struct {
 	CatchupPeriod  string
 	Nodes          []*NodeTOML
 	GenesisTime    int64
-	TransitionTime int64           `toml:",omitempty"`
-	GenesisSeed    string          `toml:",omitempty"`
-	PublicKey      *DistPublicTOML `toml:",omitempty"`
+	TransitionTime int64  `toml:",omitempty"`
+	GenesisSeed    string `toml:",omitempty"`
+	// SetupSeed tells the genesis seed was derived from the group at its
+	// setup, see ComputeGenesisSeed, so that it is checked when loading.
+	SetupSeed bool `toml:",omitempty"`
+	// Verifier tells the group has no nodes as it only verifies the beacons
+	// of a chain, see ImportChainInfo. Other groups must have nodes.
+	Verifier  bool            `toml:",omitempty"`
+	PublicKey *DistPublicTOML `toml:",omitempty"`
+	// DistPublicHash is the hash of the distributed public key, distributed
+	// separately from a group which does not hold it.
+	DistPublicHash string `toml:"dist_public_hash,omitempty"`
 	SchemeID       string
 	ID             string
+	// Include lists group fragments, by path or URL, whose nodes are merged
+	// into this group when it is loaded from a file. URLs are only fetched by
+	// LoadWithRemoteIncludes, never by the loads of a store. A reference can
+	// pin the SHA-256 of the fragment with a "#sha256=<hex>" suffix, which
+	// plain HTTP URLs require.
+	Include []string `toml:",omitempty"`
+	// Metadata holds free form annotations, which are not part of the hash
+	// of the group.
+	Metadata map[string]string `toml:",omitempty"`
 }
 
 // FromTOML decodes the group from the toml struct
@@ -206,7 +276,13 @@ func (g *Group) FromTOML(i interface{}) (err error) {
 	if !ok {
 		return fmt.Errorf("grouptoml unknown")
 	}
-	g.Threshold = gt.Threshold
+	checker := newSchemeKeyChecker(gt.schemeID())
+	for i, ptoml := range gt.Nodes {
+		checker.check(i, ptoml)
+	}
+	if err := checker.err(); err != nil {
+		return err
+	}
 	g.Nodes = make([]*Node, len(gt.Nodes))
 	for i, ptoml := range gt.Nodes {
 		g.Nodes[i] = new(Node)
@@ -214,14 +290,25 @@ func (g *Group) FromTOML(i interface{}) (err error) {
 			return fmt.Errorf("group: unwrapping node[%d]: %v", i, err)
 		}
 	}
+	return g.fromTOMLHeader(gt)
+}
 
+// fromTOMLHeader decodes all the fields of the group but its nodes, which must
+// already be set.
+func (g *Group) fromTOMLHeader(gt *GroupTOML) (err error) {
+	g.Threshold = gt.Threshold
 	if g.Scheme, err = scheme.GetSchemeByIDWithDefault(gt.SchemeID); err != nil {
 		return err
 	}
 
-	if g.Threshold < dkg.MinimumT(len(gt.Nodes)) {
+	g.verifier = gt.Verifier
+	switch {
+	case g.verifier && g.Len() == 0 && g.Threshold == 0:
+	case g.verifier:
+		return errors.New("group: a verifier group can't have nodes or a threshold")
+	case g.Threshold == 0 || g.Threshold < dkg.MinimumT(g.Len()):
 		return errors.New("group file have threshold 0")
-	} else if g.Threshold > g.Len() {
+	case g.Threshold > g.Len():
 		return errors.New("group file threshold greater than number of participants")
 	}
 
@@ -232,6 +319,11 @@ func (g *Group) FromTOML(i interface{}) (err error) {
 			return fmt.Errorf("group: unwrapping distributed public key: %v", err)
 		}
 	}
+	if gt.DistPublicHash != "" {
+		if err = g.setDistPublicHash(gt.DistPublicHash); err != nil {
+			return err
+		}
+	}
 	g.Period, err = time.ParseDuration(gt.Period)
 	if err != nil {
 		return err
@@ -255,24 +347,34 @@ func (g *Group) FromTOML(i interface{}) (err error) {
 	}
 
 	g.ID = gt.ID
+	g.metadata = copyMetadata(gt.Metadata)
 
+	if gt.SetupSeed {
+		return g.verifyGenesisSeed()
+	}
 	return nil
 }
 
 // TOML returns a TOML-encodable version of the Group
 func (g *Group) TOML() interface{} {
-	gtoml := &GroupTOML{
-		Threshold: g.Threshold,
-	}
-	gtoml.Nodes = make([]*NodeTOML, g.Len())
+	nodes := make([]*NodeTOML, g.Len())
 	for i, n := range g.Nodes {
-		gtoml.Nodes[i] = n.TOML().(*NodeTOML)
+		nodes[i] = n.TOML().(*NodeTOML)
 	}
-
+	gtoml := g.tomlHeader()
+	gtoml.Nodes = nodes
 	if g.PublicKey != nil {
 		gtoml.PublicKey = g.PublicKey.TOML().(*DistPublicTOML)
 	}
+	return gtoml
+}
 
+// tomlHeader returns the TOML-encodable version of the group without its nodes
+// and distributed public key.
+func (g *Group) tomlHeader() *GroupTOML {
+	gtoml := &GroupTOML{
+		Threshold: g.Threshold,
+	}
 	gtoml.ID = g.ID
 	gtoml.SchemeID = g.Scheme.ID
 	gtoml.Period = g.Period.String()
@@ -282,6 +384,12 @@ func (g *Group) TOML() interface{} {
 		gtoml.TransitionTime = g.TransitionTime
 	}
 	gtoml.GenesisSeed = hex.EncodeToString(g.GetGenesisSeed())
+	gtoml.SetupSeed = g.TransitionTime == 0 && g.Len() > 0 && bytes.Equal(g.GenesisSeed, g.ComputeGenesisSeed())
+	gtoml.Verifier = g.verifier
+	gtoml.Metadata = copyMetadata(g.metadata)
+	if g.PublicKey == nil && g.distPublicHash != nil {
+		gtoml.DistPublicHash = hex.EncodeToString(g.distPublicHash)
+	}
 	return gtoml
 }
 
@@ -295,6 +403,44 @@ func (g *Group) GetGenesisSeed() []byte {
 	return g.GenesisSeed
 }
 
+// setDistPublic replaces the distributed public key embedded in the group. A
+// seed not set yet is first computed from the previous key, as it would have
+// been by the nodes that already loaded the group.
+func (g *Group) setDistPublic(d *DistPublic) {
+	g.GetGenesisSeed()
+	g.PublicKey = d
+}
+
+// ComputeGenesisSeed deterministically derives the genesis seed from the
+// canonical form of the group as it is before running the DKG, which is when
+// the seed is fixed during a setup: the distributed public key and the
+// transition time are not taken into account.
+func (g *Group) ComputeGenesisSeed() []byte {
+	setup := *g
+	setup.Nodes = make([]*Node, len(g.Nodes))
+	copy(setup.Nodes, g.Nodes)
+	setup.PublicKey = nil
+	setup.distPublicHash = nil
+	setup.TransitionTime = 0
+	return setup.Hash()
+}
+
+// verifyGenesisSeed checks the genesis seed of the group is the one derived
+// from the group at its setup. It is only called for the seeds written as
+// setup seeds: a seed computed lazily by GetGenesisSeed covers the distributed
+// key embedded at that time, which may have changed since, and a group
+// resulting from a resharing inherits the seed of the first group of the
+// network, so neither can be verified.
+func (g *Group) verifyGenesisSeed() error {
+	if g.GenesisSeed == nil || g.TransitionTime != 0 || g.Len() == 0 {
+		return nil
+	}
+	if exp := g.ComputeGenesisSeed(); !bytes.Equal(g.GenesisSeed, exp) {
+		return fmt.Errorf("group: genesis seed %x does not match the group (expected %x)", g.GenesisSeed, exp)
+	}
+	return nil
+}
+
 // TOMLValue returns an empty TOML-compatible value of the group
 func (g *Group) TOMLValue() interface{} {
 	return &GroupTOML{}
@@ -337,19 +483,51 @@ func LoadGroup(list []*Node, genesis int64, public *DistPublic, period time.Dura
 }
 
 func copyAndSort(list []*Identity) []*Node {
-	nl := make([]*Identity, len(list))
-	copy(nl, list)
-	sort.Sort(ByKey(nl))
-	nodes := make([]*Node, len(list))
-	for i := 0; i < len(list); i++ {
+	assigned := AssignIndices(list)
+	nodes := make([]*Node, len(assigned))
+	for i, a := range assigned {
 		nodes[i] = &Node{
-			Identity: nl[i],
-			Index:    Index(i),
+			Identity: a.Identity,
+			Index:    a.Index,
 		}
 	}
 	return nodes
 }
 
+// IndexedIdentity is an identity along the index of its share in a DKG.
+type IndexedIdentity struct {
+	Index    Index
+	Identity *Identity
+}
+
+// AssignIndices returns the canonical assignment of the share indexes to the
+// given identities, the one NewGroup uses: the identities are sorted by their
+// serialized public key, then by address for identical keys, and get the
+// indexes 0 to n-1 in that order. The indexes start at 0, not 1, as the DKG
+// uses the index of a node as the index of its share: a 1-based assignment
+// would not match the shares and groups of the running networks. The
+// assignment only depends on the set of identities, not on their order in the
+// list, so that all the participants agree on it. The list is not modified.
+func AssignIndices(list []*Identity) []IndexedIdentity {
+	sorted := make([]*Identity, len(list))
+	copy(sorted, list)
+	keys := make(map[*Identity][]byte, len(list))
+	for _, id := range sorted {
+		keys[id], _ = id.Key.MarshalBinary()
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if c := bytes.Compare(keys[sorted[i]], keys[sorted[j]]); c != 0 {
+			return c < 0
+		}
+		return sorted[i].Addr < sorted[j].Addr
+	})
+	assigned := make([]IndexedIdentity, len(sorted))
+	for i, id := range sorted {
+		assigned[i] = IndexedIdentity{Index: Index(i), Identity: id}
+	}
+	return assigned
+}
+
 // MinimumT calculates the threshold needed for the group to produce sufficient shares to decode
 func MinimumT(n int) int {
 	return (n >> 1) + 1
@@ -0,0 +1,92 @@
+package key
+
+import (
+	"net"
+	"strings"
+)
+
+// addressIndex maps the normalized addresses of the nodes of a group to their
+// position in the group. It is only valid for the list of nodes it was built
+// from, and never modified once built, so that copies of a group can share it.
+type addressIndex struct {
+	nodes     []*Node
+	positions map[string]int
+}
+
+func newAddressIndex(nodes []*Node) *addressIndex {
+	idx := &addressIndex{nodes: nodes, positions: make(map[string]int, len(nodes))}
+	for i, n := range nodes {
+		if n != nil && n.Identity != nil {
+			idx.positions[NormalizeAddress(n.Addr)] = i
+		}
+	}
+	return idx
+}
+
+// builtFrom returns true if the index was built from this list of nodes.
+func (a *addressIndex) builtFrom(nodes []*Node) bool {
+	if a == nil || len(a.nodes) != len(nodes) {
+		return false
+	}
+	return len(nodes) == 0 || &a.nodes[0] == &nodes[0]
+}
+
+// lookup returns the identity of the node at the given normalized address. It
+// returns stale if the node the index points to is no longer at that address,
+// e.g. as the nodes were sorted in place since the index was built.
+func (a *addressIndex) lookup(nodes []*Node, addr string) (id *Identity, ok, stale bool) {
+	i, ok := a.positions[addr]
+	if !ok {
+		return nil, false, false
+	}
+	n := nodes[i]
+	if n == nil || n.Identity == nil || NormalizeAddress(n.Addr) != addr {
+		return nil, false, true
+	}
+	return n.Identity, true, false
+}
+
+// NodeByAddress returns the identity of the member of the group reachable at
+// the given address. Addresses are compared once normalized with
+// NormalizeAddress, so that formatting differences don't cause a miss. Lookups
+// use an index of the addresses of the group built on the first call and
+// rebuilt when the list of nodes is replaced, as the edits of the group do.
+func (g *Group) NodeByAddress(addr string) (*Identity, bool) {
+	addr = NormalizeAddress(addr)
+	idx, _ := g.addrIndex.Load().(*addressIndex)
+	if !idx.builtFrom(g.Nodes) {
+		idx = newAddressIndex(g.Nodes)
+		g.addrIndex.Store(idx)
+	}
+	id, ok, stale := idx.lookup(g.Nodes, addr)
+	if stale {
+		idx = newAddressIndex(g.Nodes)
+		g.addrIndex.Store(idx)
+		id, ok, _ = idx.lookup(g.Nodes, addr)
+	}
+	return id, ok
+}
+
+// NormalizeAddress returns the canonical form of a "host:port" address:
+//   - the host is lowercased and its trailing dot, if any, is removed,
+//   - IP addresses are written in their canonical form, e.g. IPv6 addresses
+//     are compressed and IPv4-mapped IPv6 addresses are written as IPv4,
+//   - IPv6 addresses are bracketed when followed by a port, and not otherwise.
+//
+// Addresses without a port are normalized as a host alone.
+func NormalizeAddress(addr string) string {
+	addr = strings.TrimSpace(addr)
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, ""
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if ip := net.ParseIP(host); ip != nil {
+		host = ip.String()
+	}
+	if port == "" {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
@@ -0,0 +1,74 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	for _, c := range []struct{ in, out string }{
+		{"127.0.0.1:8080", "127.0.0.1:8080"},
+		{"Drand.Example.COM:443", "drand.example.com:443"},
+		{"drand.example.com.:443", "drand.example.com:443"},
+		{"DRAND.example.com.", "drand.example.com"},
+		{"[2001:DB8:0:0::1]:443", "[2001:db8::1]:443"},
+		{"[2001:db8:0000:0000:0000:0000:0000:0001]:443", "[2001:db8::1]:443"},
+		{"2001:db8::1", "2001:db8::1"},
+		{"[2001:db8::1]", "2001:db8::1"},
+		{"[::ffff:127.0.0.1]:80", "127.0.0.1:80"},
+		{" 127.0.0.1:80 ", "127.0.0.1:80"},
+	} {
+		require.Equal(t, c.out, NormalizeAddress(c.in), c.in)
+	}
+}
+
+func TestGroupNodeByAddress(t *testing.T) {
+	_, group := BatchIdentities(3)
+	group.Nodes[0].Addr = "Node0.Example.com:443"
+	group.Nodes[1].Addr = "[2001:db8::1]:443"
+
+	for addr, want := range map[string]*Identity{
+		"node0.example.com.:443":                     group.Nodes[0].Identity,
+		"NODE0.EXAMPLE.COM:443":                      group.Nodes[0].Identity,
+		"[2001:0db8:0000:0000:0000:0000:0000:1]:443": group.Nodes[1].Identity,
+		"[2001:DB8::1]:443":                          group.Nodes[1].Identity,
+		group.Nodes[2].Addr:                          group.Nodes[2].Identity,
+	} {
+		id, ok := group.NodeByAddress(addr)
+		require.True(t, ok, addr)
+		require.Equal(t, want, id, addr)
+	}
+	_, ok := group.NodeByAddress("node0.example.com:444")
+	require.False(t, ok)
+
+	// edits of the nodes are picked up, without affecting the original
+	moved := group.Nodes[2].copy()
+	moved.Addr = "node2.example.com:443"
+	updated, err := group.UpdateNode(moved)
+	require.NoError(t, err)
+	id, ok := updated.NodeByAddress("Node2.example.com:443")
+	require.True(t, ok)
+	require.True(t, id.Equal(moved.Identity))
+	_, ok = group.NodeByAddress("node2.example.com:443")
+	require.False(t, ok)
+	c, err := updated.WithNodes(updated.Nodes[:2])
+	require.NoError(t, err)
+	_, ok = c.NodeByAddress("node2.example.com:443")
+	require.False(t, ok)
+
+	// the copies get their own index
+	copied := group.Copy()
+	copied.Nodes[2].Addr = "node2.example.com:443"
+	copied.Nodes = append([]*Node{}, copied.Nodes...)
+	_, ok = copied.NodeByAddress("node2.example.com:443")
+	require.True(t, ok)
+	_, ok = group.NodeByAddress(group.Nodes[2].Addr)
+	require.True(t, ok)
+
+	// nodes sorted in place are still found
+	group.Nodes[0], group.Nodes[2] = group.Nodes[2], group.Nodes[0]
+	id, ok = group.NodeByAddress("node0.example.com:443")
+	require.True(t, ok)
+	require.Equal(t, group.Nodes[2].Identity, id)
+}
@@ -0,0 +1,42 @@
+package key
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+const nodesTableHeader = "[[Nodes]]"
+
+// nodeComments returns, for each node table of a group file in order, the
+// comment lines written right above its header, without their leading "#".
+// Operators use them to annotate nodes, e.g. with the organization running
+// them.
+func nodeComments(data []byte) []string {
+	var comments []string
+	var current []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "#"):
+			current = append(current, strings.TrimPrefix(strings.TrimPrefix(line, "#"), " "))
+		case line == "":
+		case line == nodesTableHeader:
+			comments = append(comments, strings.Join(current, "\n"))
+			current = nil
+		default:
+			current = nil
+		}
+	}
+	return comments
+}
+
+func (gt *GroupTOML) setNodeComments(data []byte) {
+	comments := nodeComments(data)
+	for i, n := range gt.Nodes {
+		if i < len(comments) {
+			n.Comment = comments[i]
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package key
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupCommentsRoundTrip(t *testing.T) {
+	_, group := BatchIdentities(3)
+	path := filepath.Join(t.TempDir(), "group.toml")
+	require.NoError(t, Save(path, group, false))
+
+	// annotate the file by hand
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	edited := strings.Replace(string(data), nodesTableHeader,
+		"# run by org A\n# contact: ops@a.org\n"+nodesTableHeader, 1)
+	parts := strings.SplitN(edited, nodesTableHeader, 3)
+	edited = parts[0] + nodesTableHeader + parts[1] + "\n# run by org B\n" + nodesTableHeader + parts[2]
+	require.NoError(t, os.WriteFile(path, []byte(edited), 0600))
+
+	loaded := new(Group)
+	require.NoError(t, Load(path, loaded))
+	require.Equal(t, "run by org A\ncontact: ops@a.org", loaded.Nodes[0].Comment)
+	require.Equal(t, "run by org B", loaded.Nodes[1].Comment)
+	require.Empty(t, loaded.Nodes[2].Comment)
+	// comments are not part of the content of the group
+	require.True(t, loaded.Equal(group))
+
+	modified, err := loaded.WithThreshold(3)
+	require.NoError(t, err)
+	modified.Nodes[2].Comment = "run by org C"
+	require.NoError(t, Save(path, modified, false))
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "# run by org A\n# contact: ops@a.org\n"+nodesTableHeader)
+	require.Contains(t, string(data), "# run by org B\n"+nodesTableHeader)
+
+	reloaded := new(Group)
+	require.NoError(t, Load(path, reloaded))
+	require.Equal(t, 3, reloaded.Threshold)
+	require.Equal(t, loaded.Nodes[0].Comment, reloaded.Nodes[0].Comment)
+	require.Equal(t, loaded.Nodes[1].Comment, reloaded.Nodes[1].Comment)
+	require.Equal(t, "run by org C", reloaded.Nodes[2].Comment)
+}
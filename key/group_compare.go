@@ -0,0 +1,167 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GroupDiff holds the meaningful differences between two groups A and B, as
+// returned by CompareGroups. The fields describing a parameter of the groups
+// hold the values of both groups, which are equal when the groups agree on
+// that parameter.
+type GroupDiff struct {
+	// OnlyInA are the nodes of A whose public key is not in B
+	OnlyInA []*Node
+	// OnlyInB are the nodes of B whose public key is not in A
+	OnlyInB []*Node
+	// Reindexed are the nodes present in both groups at different indexes
+	Reindexed []IndexChange
+
+	ThresholdA, ThresholdB int
+	SchemeA, SchemeB       string
+	PeriodA, PeriodB       time.Duration
+	GenesisA, GenesisB     int64
+	// DistPublicDiffers is true if the groups hold different distributed
+	// public keys, or if only one of them holds one.
+	DistPublicDiffers bool
+}
+
+// IndexChange describes a node present in both groups at different indexes.
+type IndexChange struct {
+	Addr           string
+	IndexA, IndexB Index
+}
+
+// CompareGroups returns the differences between the two groups. Nodes are
+// matched by public key, so their order in the groups does not matter, and
+// fields having no effect on the randomness, such as the node addresses, are
+// ignored.
+func CompareGroups(a, b *Group) (GroupDiff, error) {
+	if a == nil || b == nil {
+		return GroupDiff{}, errors.New("group: can't compare a nil group")
+	}
+	diff := GroupDiff{
+		ThresholdA: a.Threshold, ThresholdB: b.Threshold,
+		SchemeA: a.Scheme.ID, SchemeB: b.Scheme.ID,
+		PeriodA: a.Period, PeriodB: b.Period,
+		GenesisA: a.GenesisTime, GenesisB: b.GenesisTime,
+	}
+	switch {
+	case a.PublicKey == nil && b.PublicKey == nil:
+	case a.PublicKey == nil || b.PublicKey == nil:
+		diff.DistPublicDiffers = true
+	default:
+		diff.DistPublicDiffers = !a.PublicKey.Equal(b.PublicKey)
+	}
+
+	inB := make(map[string]*Node, b.Len())
+	for _, n := range b.Nodes {
+		inB[n.Key.String()] = n
+	}
+	for _, n := range a.Nodes {
+		k := n.Key.String()
+		other, ok := inB[k]
+		if !ok {
+			diff.OnlyInA = append(diff.OnlyInA, n)
+			continue
+		}
+		if other.Index != n.Index {
+			diff.Reindexed = append(diff.Reindexed, IndexChange{Addr: n.Addr, IndexA: n.Index, IndexB: other.Index})
+		}
+		delete(inB, k)
+	}
+	for _, n := range b.Nodes {
+		if _, ok := inB[n.Key.String()]; ok {
+			diff.OnlyInB = append(diff.OnlyInB, n)
+		}
+	}
+	byIndex := func(nodes []*Node) {
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].Index < nodes[j].Index })
+	}
+	byIndex(diff.OnlyInA)
+	byIndex(diff.OnlyInB)
+	sort.Slice(diff.Reindexed, func(i, j int) bool { return diff.Reindexed[i].IndexA < diff.Reindexed[j].IndexA })
+	return diff, nil
+}
+
+// Equivalent returns true if the groups don't differ.
+func (d GroupDiff) Equivalent() bool {
+	return len(d.OnlyInA) == 0 && len(d.OnlyInB) == 0 && len(d.Reindexed) == 0 &&
+		d.ThresholdA == d.ThresholdB && d.SchemeA == d.SchemeB && d.PeriodA == d.PeriodB &&
+		d.GenesisA == d.GenesisB && !d.DistPublicDiffers
+}
+
+// String returns a description of the differences, one per line.
+func (d GroupDiff) String() string {
+	if d.Equivalent() {
+		return "groups are equivalent"
+	}
+	var lines []string
+	add := func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	for _, n := range d.OnlyInA {
+		add("node %d %s (key %s) is only in A", n.Index, n.Addr, n.Key)
+	}
+	for _, n := range d.OnlyInB {
+		add("node %d %s (key %s) is only in B", n.Index, n.Addr, n.Key)
+	}
+	for _, c := range d.Reindexed {
+		add("node %s has index %d in A but %d in B", c.Addr, c.IndexA, c.IndexB)
+	}
+	if d.ThresholdA != d.ThresholdB {
+		add("threshold is %d in A but %d in B", d.ThresholdA, d.ThresholdB)
+	}
+	if d.SchemeA != d.SchemeB {
+		add("scheme is %s in A but %s in B", d.SchemeA, d.SchemeB)
+	}
+	if d.PeriodA != d.PeriodB {
+		add("period is %s in A but %s in B", d.PeriodA, d.PeriodB)
+	}
+	if d.GenesisA != d.GenesisB {
+		add("genesis time is %d in A but %d in B", d.GenesisA, d.GenesisB)
+	}
+	if d.DistPublicDiffers {
+		add("distributed public keys differ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// IsSupersetOf returns true if every node of the other group, matched by
+// public key, is also a node of g, whatever their order and addresses. A group
+// is a superset of itself; a strict superset also has more nodes than the
+// other group.
+func (g *Group) IsSupersetOf(other *Group) bool {
+	keys := g.nodeKeys()
+	for _, n := range other.Nodes {
+		if _, ok := keys[n.Key.String()]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Intersection returns the identities of the nodes of g whose public key is
+// also held by a node of the other group, in the order of g.
+func (g *Group) Intersection(other *Group) []*Identity {
+	keys := other.nodeKeys()
+	var common []*Identity
+	for _, n := range g.Nodes {
+		if _, ok := keys[n.Key.String()]; ok {
+			common = append(common, n.Identity)
+		}
+	}
+	return common
+}
+
+// nodeKeys returns the set of the public keys of the nodes of the group.
+func (g *Group) nodeKeys() map[string]struct{} {
+	keys := make(map[string]struct{}, g.Len())
+	for _, n := range g.Nodes {
+		keys[n.Key.String()] = struct{}{}
+	}
+	return keys
+}
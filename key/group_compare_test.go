@@ -0,0 +1,90 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareGroups(t *testing.T) {
+	_, a := BatchIdentities(4)
+	b := a.Copy()
+	// ordering and addresses are not meaningful
+	b.Nodes[0], b.Nodes[3] = b.Nodes[3], b.Nodes[0]
+	b.Nodes[1].Addr = "127.0.0.1:9999"
+	diff, err := CompareGroups(a, b)
+	require.NoError(t, err)
+	require.True(t, diff.Equivalent())
+	require.Equal(t, "groups are equivalent", diff.String())
+
+	_, other := BatchIdentities(1)
+	b.Nodes[0] = other.Nodes[0]
+	b.Nodes[2].Index = 7
+	b.Threshold = 4
+	_, dist := dealShares(4, 3)
+	b.PublicKey = dist
+	diff, err = CompareGroups(a, b)
+	require.NoError(t, err)
+	require.False(t, diff.Equivalent())
+	require.Len(t, diff.OnlyInA, 1)
+	require.True(t, diff.OnlyInA[0].Identity.Equal(a.Nodes[3].Identity))
+	require.Len(t, diff.OnlyInB, 1)
+	require.True(t, diff.OnlyInB[0].Identity.Equal(other.Nodes[0].Identity))
+	require.Equal(t, []IndexChange{{Addr: a.Nodes[2].Addr, IndexA: 2, IndexB: 7}}, diff.Reindexed)
+	require.Equal(t, 3, diff.ThresholdA)
+	require.Equal(t, 4, diff.ThresholdB)
+	require.True(t, diff.DistPublicDiffers)
+
+	s := diff.String()
+	require.Contains(t, s, "only in A")
+	require.Contains(t, s, "only in B")
+	require.Contains(t, s, "index 2 in A but 7 in B")
+	require.Contains(t, s, "threshold is 3 in A but 4 in B")
+	require.Contains(t, s, "distributed public keys differ")
+
+	_, err = CompareGroups(a, nil)
+	require.Error(t, err)
+}
+
+func TestGroupSetOperations(t *testing.T) {
+	_, old := BatchIdentities(4)
+	_, others := BatchIdentities(2)
+	keys := func(ids []*Identity) []string {
+		var k []string
+		for _, id := range ids {
+			k = append(k, id.Key.String())
+		}
+		return k
+	}
+	nodeKeys := func(nodes []*Node) []string {
+		var k []string
+		for _, n := range nodes {
+			k = append(k, n.Key.String())
+		}
+		return k
+	}
+
+	// superset: nodes added, reordered and moved to other addresses
+	grown := old.Copy()
+	grown.Nodes = append(grown.Nodes, others.Nodes[0])
+	grown.Nodes[0], grown.Nodes[2] = grown.Nodes[2], grown.Nodes[0]
+	grown.Nodes[1].Addr = "127.0.0.1:9999"
+	require.True(t, grown.IsSupersetOf(old))
+	require.False(t, old.IsSupersetOf(grown))
+	require.True(t, old.IsSupersetOf(old))
+	require.ElementsMatch(t, nodeKeys(old.Nodes), keys(grown.Intersection(old)))
+	require.ElementsMatch(t, nodeKeys(old.Nodes), keys(old.Intersection(grown)))
+
+	// disjoint
+	require.False(t, old.IsSupersetOf(others))
+	require.False(t, others.IsSupersetOf(old))
+	require.Empty(t, old.Intersection(others))
+
+	// overlapping: a node replaced by another one
+	replaced := old.Copy()
+	replaced.Nodes[3] = others.Nodes[1]
+	require.False(t, replaced.IsSupersetOf(old))
+	require.False(t, old.IsSupersetOf(replaced))
+	common := old.Intersection(replaced)
+	require.Equal(t, nodeKeys(old.Nodes[:3]), keys(common))
+}
@@ -0,0 +1,140 @@
+package key
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrDistPublicHash is returned when a distributed public key doesn't match
+// the hash declared by the group referencing it.
+var ErrDistPublicHash = errors.New("group: distributed public key does not match the hash of the group")
+
+// WithDistPublicReference returns a copy of the group referencing its
+// distributed public key by hash instead of holding it, for networks where the
+// key is distributed separately. The hash of the group is unchanged. The
+// returned group is the same as g if g holds no distributed public key.
+func (g *Group) WithDistPublicReference() *Group {
+	if g.PublicKey == nil {
+		return g
+	}
+	ref := *g
+	ref.distPublicHash = g.PublicKey.Hash()
+	ref.PublicKey = nil
+	return &ref
+}
+
+// DistPublicHash returns the hash of the distributed public key the group
+// references, or nil if the group holds its key or did not run a DKG yet.
+func (g *Group) DistPublicHash() []byte {
+	if g.PublicKey != nil {
+		return nil
+	}
+	return g.distPublicHash
+}
+
+// ResolveDistPublic sets the distributed public key referenced by the group,
+// after checking it has the hash declared by the group. It returns an error
+// wrapping ErrDistPublicHash if it does not.
+func (g *Group) ResolveDistPublic(d *DistPublic) error {
+	if g.distPublicHash == nil {
+		return errors.New("group: no distributed public key referenced")
+	}
+	if !bytes.Equal(d.Hash(), g.distPublicHash) {
+		return fmt.Errorf("%w: got %x, expected %x", ErrDistPublicHash, d.Hash(), g.distPublicHash)
+	}
+	g.PublicKey = d
+	return nil
+}
+
+// setDistPublicHash decodes the hash declared by a group file, which must be
+// the one of the distributed public key if the file holds it too.
+func (g *Group) setDistPublicHash(h string) error {
+	hash, err := hex.DecodeString(h)
+	if err != nil {
+		return fmt.Errorf("group: decoding distributed public key hash: %v", err)
+	}
+	g.distPublicHash = hash
+	if g.PublicKey != nil && !bytes.Equal(g.PublicKey.Hash(), hash) {
+		return fmt.Errorf("%w: the group holds another key", ErrDistPublicHash)
+	}
+	return nil
+}
+
+// storedGroup returns the group as it is written to the group file, its nodes
+// listed by index.
+func (f *fileStore) storedGroup(g *Group) *Group {
+	g = sortedByIndex(g)
+	if f.distPublicReference {
+		return g.WithDistPublicReference()
+	}
+	return g
+}
+
+// groupFileObject returns the object written to the group file for the
+// group. With WithMinimalGroupDiffs, it is encoded against the current content
+// of the file.
+func (f *fileStore) groupFileObject(g *Group) Tomler {
+	stored := f.storedGroup(g)
+	if !f.minimalGroupDiffs {
+		return stored
+	}
+	previous, err := f.readData(f.groupFile)
+	if err != nil {
+		previous = nil
+	}
+	return &minimalDiffGroup{Group: stored, previous: previous}
+}
+
+// writeGroup writes the group file. When the group references its distributed
+// public key, the key is written along with it so both files can't disagree,
+// and so is the signature of the group if any.
+func (f *fileStore) writeGroup(g *Group, sig *groupSignature) error {
+	reference := f.distPublicReference && g.PublicKey != nil
+	if !reference && sig == nil {
+		if err := f.closed.check(); err != nil {
+			return err
+		}
+		obj := f.groupFileObject(g)
+		if f.contentAddressedGroups {
+			// writing through the link would overwrite the archived group
+			if err := os.Remove(f.groupFile); err != nil && !os.IsNotExist(err) {
+				return wrapFileError(f.groupFile, err)
+			}
+		}
+		if err := f.save(f.groupFile, obj, false); err != nil {
+			return err
+		}
+		return f.archiveGroup()
+	}
+	w := &atomicWrite{closed: f.closed, wal: f.wal, codecs: f.codecs}
+	if reference {
+		if err := w.add(f.distKeyFile, g.PublicKey, false); err != nil {
+			return err
+		}
+	}
+	if err := w.add(f.groupFile, f.groupFileObject(g), false); err != nil {
+		return err
+	}
+	if sig != nil {
+		if err := w.add(f.groupSigFile, sig, false); err != nil {
+			return err
+		}
+	}
+	if err := w.commit(); err != nil {
+		return err
+	}
+	return f.archiveGroup()
+}
+
+// resolveDistPublic loads the distributed public key file referenced by the
+// group.
+func (f *fileStore) resolveDistPublic(g *Group) error {
+	d := new(DistPublic)
+	if err := f.load(f.distKeyFile, d); err != nil {
+		return fmt.Errorf("group: loading the referenced distributed public key: %v", err)
+	}
+	return g.ResolveDistPublic(d)
+}
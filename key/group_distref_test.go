@@ -0,0 +1,77 @@
+package key
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupDistPublicReference(t *testing.T) {
+	_, group := BatchIdentities(3)
+	_, dist := dealShares(3, group.Threshold)
+	group.PublicKey = dist
+
+	ref := group.WithDistPublicReference()
+	require.Nil(t, ref.PublicKey)
+	require.Equal(t, dist.Hash(), ref.DistPublicHash())
+	require.Equal(t, group.Hash(), ref.Hash())
+	require.NotNil(t, group.PublicKey)
+	require.Nil(t, group.DistPublicHash())
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, ref))
+	require.Contains(t, buf.String(), "dist_public_hash")
+	require.NotContains(t, buf.String(), publicKeyTableHeader)
+	decoded := new(Group)
+	require.NoError(t, Decode(&buf, decoded))
+	require.Equal(t, dist.Hash(), decoded.DistPublicHash())
+	require.True(t, decoded.Equal(ref))
+	require.False(t, decoded.Equal(group))
+
+	_, other := dealShares(3, group.Threshold)
+	require.ErrorIs(t, decoded.ResolveDistPublic(other), ErrDistPublicHash)
+	require.Nil(t, decoded.PublicKey)
+	require.NoError(t, decoded.ResolveDistPublic(dist))
+	require.True(t, decoded.Equal(group))
+}
+
+func TestStoreDistPublicReference(t *testing.T) {
+	_, group := BatchIdentities(3)
+	_, dist := dealShares(3, group.Threshold)
+	group.PublicKey = dist
+
+	base := t.TempDir()
+	store := NewFileStore(base, "", WithDistPublicReference(), WithVerifyAfterWrite(true))
+	require.NoError(t, store.SaveGroup(group))
+	f := store.(*fileStore)
+	data, err := os.ReadFile(f.groupFile)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "dist_public_hash")
+	require.NotContains(t, string(data), publicKeyTableHeader)
+
+	// matching hash
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(group))
+	require.True(t, loaded.PublicKey.Equal(dist))
+
+	// a store without the mode does not resolve the reference
+	loaded, err = NewFileStore(base, "").LoadGroup()
+	require.NoError(t, err)
+	require.Nil(t, loaded.PublicKey)
+	require.Equal(t, dist.Hash(), loaded.DistPublicHash())
+
+	// mismatched hash
+	_, other := dealShares(3, group.Threshold)
+	require.NoError(t, save(f.distKeyFile, other, false, nil))
+	_, err = store.LoadGroup()
+	require.ErrorIs(t, err, ErrDistPublicHash)
+
+	// rotating the key through the store updates the reference
+	require.NoError(t, store.SaveDistPublic(other))
+	loaded, err = store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.PublicKey.Equal(other))
+}
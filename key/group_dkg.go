@@ -0,0 +1,159 @@
+package key
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	kyber "github.com/drand/kyber"
+	dkg "github.com/drand/kyber/share/dkg"
+)
+
+// Warnings returns the settings of the group that are valid but worth the
+// attention of the operators. A group mixing nodes served over TLS and nodes
+// served without it, as during a migration to TLS, gets a *MixedTLSWarning
+// listing them.
+func (g *Group) Warnings() []error {
+	var warning MixedTLSWarning
+	for _, n := range g.Nodes {
+		if n.TLS {
+			warning.TLS = append(warning.TLS, n.Addr)
+		} else {
+			warning.Plain = append(warning.Plain, n.Addr)
+		}
+	}
+	if len(warning.TLS) > 0 && len(warning.Plain) > 0 {
+		return []error{&warning}
+	}
+	return nil
+}
+
+// MixedTLSWarning is returned by Warnings for a group in which some nodes are
+// served over TLS and others are not.
+type MixedTLSWarning struct {
+	// TLS and Plain are the addresses of the nodes served with and without
+	// TLS
+	TLS, Plain []string
+}
+
+func (w *MixedTLSWarning) Error() string {
+	return fmt.Sprintf("group: %d nodes served over TLS but %d without: %s",
+		len(w.TLS), len(w.Plain), strings.Join(w.Plain, ", "))
+}
+
+// Valid checks the group is consistent: it has at least one node, a threshold
+// between MinimumT and the number of nodes, and no two nodes share the same
+// index, address or public key. Valid settings that deserve a warning are
+// reported by Warnings instead.
+func (g *Group) Valid() error {
+	if g.Len() == 0 {
+		return errors.New("group: no nodes")
+	}
+	if err := validThreshold(g.Threshold, g.Len()); err != nil {
+		return err
+	}
+	indexes := make(map[Index]bool, g.Len())
+	addrs := make(map[string]bool, g.Len())
+	keys := make(map[string]bool, g.Len())
+	for _, n := range g.Nodes {
+		if n == nil || n.Identity == nil || n.Key == nil {
+			return errors.New("group: node without identity")
+		}
+		if indexes[n.Index] {
+			return fmt.Errorf("group: duplicate node index %d", n.Index)
+		}
+		if addrs[n.Addr] {
+			return fmt.Errorf("group: duplicate node address %s", n.Addr)
+		}
+		k := n.Key.String()
+		if keys[k] {
+			return fmt.Errorf("group: duplicate node public key for %s", n.Addr)
+		}
+		indexes[n.Index], addrs[n.Addr], keys[k] = true, true, true
+	}
+	return nil
+}
+
+// DKGConfig holds the parameters of a group consumed by the DKG library.
+type DKGConfig struct {
+	// Suite is the group used by the DKG
+	Suite dkg.Suite
+	// Nodes are the participants of the DKG, sorted by index
+	Nodes []dkg.Node
+	// Threshold of the distributed key
+	Threshold int
+	// Nonce identifying this DKG run, derived from the group
+	Nonce []byte
+}
+
+// ToDKGConfig validates the group and returns the parameters needed to run a
+// DKG or a resharing with it. This is the single place where drand nodes are
+// mapped to DKG nodes.
+func (g *Group) ToDKGConfig() (*DKGConfig, error) {
+	if err := g.Valid(); err != nil {
+		return nil, err
+	}
+	nodes := g.DKGNodes()
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Index < nodes[j].Index
+	})
+	return &DKGConfig{
+		Suite:     KeyGroup.(dkg.Suite),
+		Nodes:     nodes,
+		Threshold: g.Threshold,
+		Nonce:     g.dkgNonce(),
+	}, nil
+}
+
+// Config returns the configuration of a DKG where these parameters describe
+// the new nodes, run by the node holding the given long term private key.
+func (c *DKGConfig) Config(longterm kyber.Scalar) *dkg.Config {
+	return &dkg.Config{
+		Suite:     c.Suite,
+		NewNodes:  c.Nodes,
+		Longterm:  longterm,
+		FastSync:  true,
+		Threshold: c.Threshold,
+		Nonce:     c.Nonce,
+		Auth:      DKGAuthScheme,
+	}
+}
+
+// dkgNonce derives the nonce of a DKG from the time at which the group
+// becomes active.
+func (g *Group) dkgNonce() []byte {
+	h := sha256.New()
+	if g.TransitionTime != 0 {
+		_ = binary.Write(h, binary.BigEndian, g.TransitionTime)
+	} else {
+		_ = binary.Write(h, binary.BigEndian, g.GenesisTime)
+	}
+	return h.Sum(nil)
+}
+
+// Leader returns the identity of the node coordinating the operations of the
+// group which need one, such as starting a DKG: the first node in the
+// canonical order of AssignIndices, so that it only depends on the set of
+// nodes and not on their order in the group. The leader is an orchestration
+// convenience only, not a trust assumption: the protocols it coordinates stay
+// secure if it misbehaves. It returns nil for a group without nodes.
+func (g *Group) Leader() *Identity {
+	ids := make([]*Identity, 0, g.Len())
+	for _, n := range g.Nodes {
+		ids = append(ids, n.Identity)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return AssignIndices(ids)[0].Identity
+}
+
+// IsLeader returns true if the node with the given public key is the leader of
+// the group, see Leader.
+func (g *Group) IsLeader(pub kyber.Point) bool {
+	leader := g.Leader()
+	return leader != nil && pub != nil && leader.Key.Equal(pub)
+}
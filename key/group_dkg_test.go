@@ -0,0 +1,73 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupToDKGConfig(t *testing.T) {
+	ps, group := BatchIdentities(5)
+	group.GenesisTime = 10
+	// the configuration does not depend on the order of the nodes
+	group.Nodes[0], group.Nodes[4] = group.Nodes[4], group.Nodes[0]
+
+	conf, err := group.ToDKGConfig()
+	require.NoError(t, err)
+	require.Equal(t, group.Threshold, conf.Threshold)
+	require.Len(t, conf.Nodes, 5)
+	for i, n := range conf.Nodes {
+		require.Equal(t, Index(i), n.Index)
+		require.True(t, n.Public.Equal(ps[i].Public.Key))
+	}
+
+	dkgConf := conf.Config(ps[0].Key)
+	require.Equal(t, conf.Nodes, dkgConf.NewNodes)
+	require.Equal(t, conf.Nonce, dkgConf.Nonce)
+
+	// a later transition time gives a different nonce
+	group.TransitionTime = 20
+	conf2, err := group.ToDKGConfig()
+	require.NoError(t, err)
+	require.NotEqual(t, conf.Nonce, conf2.Nonce)
+
+	group.Nodes[1].Index = group.Nodes[2].Index
+	_, err = group.ToDKGConfig()
+	require.Error(t, err)
+	group.Threshold = 1
+	_, err = group.ToDKGConfig()
+	require.Error(t, err)
+}
+
+func TestGroupLeader(t *testing.T) {
+	pairs, group := BatchIdentities(5)
+	leader := group.Leader()
+	require.NotNil(t, leader)
+	require.True(t, leader.Equal(AssignIndices([]*Identity{
+		pairs[3].Public, pairs[0].Public, pairs[4].Public, pairs[2].Public, pairs[1].Public,
+	})[0].Identity))
+	require.True(t, group.IsLeader(leader.Key))
+	leaders := 0
+	for _, p := range pairs {
+		if group.IsLeader(p.Public.Key) {
+			leaders++
+		}
+	}
+	require.Equal(t, 1, leaders)
+
+	// reordering the nodes or renumbering them does not change the leader
+	for i := range group.Nodes {
+		group.Nodes[i].Index = Index(len(group.Nodes) - i)
+	}
+	group.Nodes[0], group.Nodes[4], group.Nodes[1] = group.Nodes[4], group.Nodes[1], group.Nodes[0]
+	require.True(t, group.Leader().Equal(leader))
+	reversed := make([]*Identity, len(pairs))
+	for i, p := range pairs {
+		reversed[len(pairs)-1-i] = p.Public
+	}
+	require.True(t, NewGroup(reversed, 3, 1, 0, 0, group.Scheme, "").Leader().Equal(leader))
+
+	require.Nil(t, new(Group).Leader())
+	require.False(t, new(Group).IsLeader(leader.Key))
+	require.False(t, group.IsLeader(nil))
+}
@@ -0,0 +1,159 @@
+package key
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	kyber "github.com/drand/kyber"
+)
+
+// Copy returns a deep copy of the group: the nodes, their identities and the
+// distributed key can be modified in the copy without affecting the receiver.
+func (g *Group) Copy() *Group {
+	c := *g
+	c.addrIndex = atomic.Value{}
+	c.Nodes = make([]*Node, len(g.Nodes))
+	for i, n := range g.Nodes {
+		c.Nodes[i] = n.copy()
+	}
+	if g.GenesisSeed != nil {
+		c.GenesisSeed = append([]byte{}, g.GenesisSeed...)
+	}
+	if g.PublicKey != nil {
+		c.PublicKey = &DistPublic{Coefficients: append([]kyber.Point{}, g.PublicKey.Coefficients...)}
+	}
+	c.metadata = copyMetadata(g.metadata)
+	return &c
+}
+
+func (n *Node) copy() *Node {
+	id := *n.Identity
+	if n.Signature != nil {
+		id.Signature = append([]byte{}, n.Signature...)
+	}
+	return &Node{Identity: &id, Index: n.Index, Comment: n.Comment}
+}
+
+// validThreshold returns an error if t is not a valid threshold for a group of
+// n nodes, i.e. if it is not between MinimumT(n) and n, both included.
+func validThreshold(t, n int) error {
+	if t < MinimumT(n) {
+		return fmt.Errorf("group: threshold %d below minimum %d for %d nodes", t, MinimumT(n), n)
+	}
+	if t > n {
+		return fmt.Errorf("group: threshold %d greater than the number of nodes %d", t, n)
+	}
+	return nil
+}
+
+// WithThreshold returns a copy of the group using the given threshold. The
+// threshold must be between MinimumT and the number of nodes of the group. The
+// receiver is never modified.
+func (g *Group) WithThreshold(t int) (*Group, error) {
+	if err := validThreshold(t, g.Len()); err != nil {
+		return nil, err
+	}
+	c := g.Copy()
+	c.Threshold = t
+	return c, nil
+}
+
+// WithNodes returns a copy of the group using the given list of nodes. The
+// threshold of the group must still be valid for the new list, and all nodes
+// must have a distinct index. The receiver is never modified.
+func (g *Group) WithNodes(nodes []*Node) (*Group, error) {
+	if err := validThreshold(g.Threshold, len(nodes)); err != nil {
+		return nil, err
+	}
+	indexes := make(map[Index]bool, len(nodes))
+	for _, n := range nodes {
+		if indexes[n.Index] {
+			return nil, fmt.Errorf("group: duplicate node index %d", n.Index)
+		}
+		indexes[n.Index] = true
+	}
+	c := g.Copy()
+	c.Nodes = make([]*Node, len(nodes))
+	for i, n := range nodes {
+		c.Nodes[i] = n.copy()
+	}
+	return c, nil
+}
+
+// ThresholdWarning tells, along with the new group, that editing a group had
+// to lower its threshold to keep it valid.
+type ThresholdWarning struct {
+	Old, New int
+}
+
+func (w *ThresholdWarning) Error() string {
+	return fmt.Sprintf("group: threshold lowered from %d to %d", w.Old, w.New)
+}
+
+// RemoveNode returns a copy of the group without the node reachable at the
+// given address, e.g. to prepare a resharing without a departed node. The
+// distributed public key, or the reference to it, is cleared, as it is not the
+// one of the new group. If the threshold exceeds the number of remaining nodes,
+// it is lowered to it and a non-nil *ThresholdWarning is returned with the new
+// group. The remaining nodes keep their index. The receiver is never modified.
+func (g *Group) RemoveNode(addr string) (*Group, *ThresholdWarning, error) {
+	removed, ok := g.NodeByAddress(addr)
+	if !ok {
+		return nil, nil, fmt.Errorf("group: no node at address %s", addr)
+	}
+	nodes := make([]*Node, 0, g.Len())
+	for _, n := range g.Nodes {
+		if !n.Identity.Key.Equal(removed.Key) {
+			nodes = append(nodes, n)
+		}
+	}
+	var warning *ThresholdWarning
+	threshold := g.Threshold
+	if threshold > len(nodes) {
+		threshold = len(nodes)
+		warning = &ThresholdWarning{Old: g.Threshold, New: threshold}
+	}
+	edited := *g
+	edited.Threshold = threshold
+	edited.PublicKey = nil
+	edited.distPublicHash = nil
+	c, err := edited.WithNodes(nodes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, warning, nil
+}
+
+// UpdateNode returns a copy of the group in which the node holding the public
+// key of n is replaced by n, e.g. to change its address, its TLS setting or its
+// comment. The node keeps its index, and the distributed public key is kept, as
+// it does not depend on these fields. The receiver is never modified.
+func (g *Group) UpdateNode(n *Node) (*Group, error) {
+	for i, current := range g.Nodes {
+		if !current.Identity.Key.Equal(n.Identity.Key) {
+			continue
+		}
+		c := g.Copy()
+		c.Nodes[i] = n.copy()
+		c.Nodes[i].Index = current.Index
+		return c, nil
+	}
+	return nil, fmt.Errorf("group: no node with key %s", n.Identity.Key)
+}
+
+// SetNodeTLS returns a copy of the group in which the node reachable at the
+// given address is served over TLS or not, e.g. to migrate a network to TLS one
+// node at a time. The signatures of the nodes and the hash of the group don't
+// cover the TLS flag, so they stay valid. It returns an error if no node has
+// that address. The receiver is never modified.
+func (g *Group) SetNodeTLS(addr string, tls bool) (*Group, error) {
+	for i, n := range g.Nodes {
+		if n.Addr != addr {
+			continue
+		}
+		c := g.Copy()
+		c.Nodes[i].TLS = tls
+		return c, nil
+	}
+	return nil, fmt.Errorf("group: no node at address %s", addr)
+}
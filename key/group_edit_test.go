@@ -0,0 +1,149 @@
+package key
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupWithThreshold(t *testing.T) {
+	_, group := BatchIdentities(5)
+	thr := group.Threshold
+
+	for _, invalid := range []int{-1, 0, MinimumT(5) - 1, 6} {
+		c, err := group.WithThreshold(invalid)
+		require.Error(t, err, "threshold %d", invalid)
+		require.Nil(t, c)
+		require.Equal(t, thr, group.Threshold)
+	}
+
+	c, err := group.WithThreshold(5)
+	require.NoError(t, err)
+	require.Equal(t, 5, c.Threshold)
+	require.Equal(t, thr, group.Threshold)
+	require.True(t, c.Nodes[0].Equal(group.Nodes[0]))
+
+	// the copy doesn't share its nodes with the receiver
+	c.Nodes[0].Addr = "127.0.0.1:1"
+	require.NotEqual(t, c.Nodes[0].Addr, group.Nodes[0].Addr)
+}
+
+func TestGroupWithNodes(t *testing.T) {
+	_, group := BatchIdentities(5)
+	nodes := group.Nodes
+
+	// threshold 3 can't be used with 2 nodes
+	_, err := group.WithNodes(nodes[:2])
+	require.Error(t, err)
+
+	// duplicate index
+	_, err = group.WithNodes([]*Node{nodes[0], nodes[1], {Identity: nodes[2].Identity, Index: nodes[0].Index}})
+	require.Error(t, err)
+
+	c, err := group.WithNodes(nodes[:4])
+	require.NoError(t, err)
+	require.Equal(t, 4, c.Len())
+	require.Equal(t, 5, group.Len())
+}
+
+func TestGroupRemoveNode(t *testing.T) {
+	_, group := BatchIdentities(5)
+	_, dist := dealShares(5, 3)
+	group.PublicKey = dist
+	removed := group.Nodes[1]
+
+	_, _, err := group.RemoveNode("127.0.0.1:1")
+	require.Error(t, err)
+
+	c, warning, err := group.RemoveNode(removed.Addr)
+	require.NoError(t, err)
+	require.Nil(t, warning)
+	require.Equal(t, 4, c.Len())
+	require.Nil(t, c.Find(removed.Identity))
+	require.Nil(t, c.PublicKey)
+	require.Equal(t, group.Threshold, c.Threshold)
+	// the other nodes keep their index and the receiver is untouched
+	require.Equal(t, group.Nodes[2].Index, c.Find(group.Nodes[2].Identity).Index)
+	require.Equal(t, 5, group.Len())
+	require.NotNil(t, group.PublicKey)
+
+	cases := []struct {
+		n, thr, expected int
+		lowered          bool
+	}{
+		{n: 4, thr: 3, expected: 3},
+		{n: 3, thr: 3, expected: 2, lowered: true},
+		{n: 2, thr: 2, expected: 1, lowered: true},
+		{n: 5, thr: 5, expected: 4, lowered: true},
+	}
+	for _, tc := range cases {
+		_, g := BatchIdentities(tc.n)
+		g.Threshold = tc.thr
+		c, warning, err := g.RemoveNode(g.Nodes[0].Addr)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, c.Threshold, "n=%d thr=%d", tc.n, tc.thr)
+		require.Equal(t, tc.thr, g.Threshold)
+		if tc.lowered {
+			require.Equal(t, &ThresholdWarning{Old: tc.thr, New: tc.expected}, warning)
+		} else {
+			require.Nil(t, warning)
+		}
+	}
+
+	// a reference to the distributed key is cleared as well
+	ref := group.WithDistPublicReference()
+	c, _, err = ref.RemoveNode(removed.Addr)
+	require.NoError(t, err)
+	require.Nil(t, c.DistPublicHash())
+
+	// the last node can't be removed
+	_, single := BatchIdentities(1)
+	single.Threshold = 1
+	_, _, err = single.RemoveNode(single.Nodes[0].Addr)
+	require.Error(t, err)
+}
+
+func TestGroupSetNodeTLS(t *testing.T) {
+	_, group := BatchIdentities(3)
+	hash := group.Hash()
+	require.NoError(t, group.Valid())
+	require.Empty(t, group.Warnings())
+	_, err := group.SetNodeTLS("127.0.0.1:1", true)
+	require.Error(t, err)
+
+	// a mixed group is valid but flagged
+	addr := group.Nodes[1].Addr
+	plain, err := group.SetNodeTLS(addr, false)
+	require.NoError(t, err)
+	require.True(t, group.Nodes[1].TLS)
+	require.Empty(t, group.Warnings())
+	group = plain
+	require.False(t, group.Nodes[1].TLS)
+	require.NoError(t, group.Nodes[1].ValidSignature())
+	require.Equal(t, hash, group.Hash())
+	require.NoError(t, group.Valid())
+	warnings := group.Warnings()
+	require.Len(t, warnings, 1)
+	var warning *MixedTLSWarning
+	require.ErrorAs(t, warnings[0], &warning)
+	require.Equal(t, []string{addr}, warning.Plain)
+	require.Len(t, warning.TLS, 2)
+	_, err = group.ToDKGConfig()
+	require.NoError(t, err)
+
+	// the flag round trips through the group file
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, group))
+	decoded := new(Group)
+	require.NoError(t, Decode(&buf, decoded))
+	require.False(t, decoded.Nodes[1].TLS)
+	require.True(t, decoded.Nodes[0].TLS)
+
+	for _, n := range group.Nodes {
+		group, err = group.SetNodeTLS(n.Addr, false)
+		require.NoError(t, err)
+	}
+	require.NoError(t, group.Valid())
+	require.Empty(t, group.Warnings())
+}
@@ -0,0 +1,297 @@
+package key
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// MaxGroupIncludeDepth is the maximum nesting level of include directives
+// allowed when decoding a group file.
+const MaxGroupIncludeDepth = 8
+
+// includeFetchTimeout bounds the time spent fetching a remote group fragment.
+const includeFetchTimeout = 30 * time.Second
+
+// includePinPrefix introduces the hash pinning the content of an include.
+const includePinPrefix = "#sha256="
+
+// GroupFragmentTOML is the TOML representation of a group fragment: a file
+// holding only a list of nodes, referenced by a group file through its
+// include directive. A fragment can itself include other fragments.
+type GroupFragmentTOML struct {
+	Include []string `toml:",omitempty"`
+	Nodes   []*NodeTOML
+}
+
+// resolveGroupIncludes merges into gt the nodes of all the fragments
+// referenced, directly or transitively, by its include directive. Relative
+// references are resolved against the location of the file including them.
+// Including the same node twice is accepted but two different nodes sharing
+// an address or a key is an error, and so is sharing an index other than 0.
+// As fragments usually leave the indexes unset, the nodes merged with index 0
+// once a node already holds it get the lowest free indexes. The fragments
+// included by URL are only fetched if remote is true.
+func resolveGroupIncludes(location string, gt *GroupTOML, remote bool) error {
+	r := &includeResolver{
+		seen:   make(map[string]*NodeTOML),
+		active: map[string]bool{canonicalLocation(location): true},
+		remote: remote,
+	}
+	var nodes []*NodeTOML
+	for _, n := range gt.Nodes {
+		ok, err := r.add(location, n)
+		if err != nil {
+			return err
+		}
+		if ok {
+			nodes = append(nodes, n)
+		}
+	}
+	included, err := r.resolve(location, gt.Include, 1)
+	if err != nil {
+		return err
+	}
+	gt.Nodes = append(nodes, included...)
+	gt.Include = nil
+	assignFreeIndexes(gt.Nodes)
+	return nil
+}
+
+// assignFreeIndexes gives the lowest free indexes to the nodes with index 0
+// but the first one.
+func assignFreeIndexes(nodes []*NodeTOML) {
+	used := make(map[Index]bool, len(nodes))
+	for _, n := range nodes {
+		used[n.Index] = true
+	}
+	next, first := Index(1), true
+	for _, n := range nodes {
+		if n.Index != 0 {
+			continue
+		}
+		if first {
+			first = false
+			continue
+		}
+		for used[next] {
+			next++
+		}
+		n.Index = next
+		used[next] = true
+	}
+}
+
+type includeResolver struct {
+	// seen indexes every node already merged by address, key and index
+	seen map[string]*NodeTOML
+	// active holds the locations currently being decoded, to detect cycles
+	active map[string]bool
+	// remote allows fetching the fragments included by URL
+	remote bool
+}
+
+func (r *includeResolver) resolve(parent string, includes []string, depth int) ([]*NodeTOML, error) {
+	if len(includes) == 0 {
+		return nil, nil
+	}
+	if depth > MaxGroupIncludeDepth {
+		return nil, fmt.Errorf("group: include depth exceeds %d in %s", MaxGroupIncludeDepth, parent)
+	}
+	var nodes []*NodeTOML
+	for _, inc := range includes {
+		ref, pin := splitIncludePin(inc)
+		location, err := resolveLocation(parent, ref)
+		if err != nil {
+			return nil, fmt.Errorf("group: include %q from %s: %v", inc, parent, err)
+		}
+		if isURL(location) && !r.remote {
+			return nil, fmt.Errorf("group: include %s: remote fragments are only fetched by LoadWithRemoteIncludes", location)
+		}
+		if strings.HasPrefix(location, "http://") && pin == "" {
+			return nil, fmt.Errorf("group: include %s: plain HTTP fragments must pin their hash with %s<hex>", location, includePinPrefix)
+		}
+		canonical := canonicalLocation(location)
+		if r.active[canonical] {
+			return nil, fmt.Errorf("group: include cycle detected on %s", location)
+		}
+
+		frag := new(GroupFragmentTOML)
+		if err := decodeLocation(location, pin, frag); err != nil {
+			return nil, fmt.Errorf("group: include %s: %v", location, err)
+		}
+		for _, n := range frag.Nodes {
+			ok, err := r.add(location, n)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				nodes = append(nodes, n)
+			}
+		}
+
+		r.active[canonical] = true
+		sub, err := r.resolve(location, frag.Include, depth+1)
+		delete(r.active, canonical)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, sub...)
+	}
+	return nodes, nil
+}
+
+// add registers the node and returns false if the very same node was already
+// registered. It returns an error if the node conflicts with another one.
+func (r *includeResolver) add(location string, n *NodeTOML) (bool, error) {
+	if n == nil || n.PublicTOML == nil {
+		return false, fmt.Errorf("group: empty node entry in %s", location)
+	}
+	keys := []string{
+		"addr:" + n.Address,
+		"key:" + n.Key,
+	}
+	if n.Index != 0 {
+		keys = append(keys, fmt.Sprintf("index:%d", n.Index))
+	}
+	var duplicate bool
+	for _, k := range keys {
+		prev, exists := r.seen[k]
+		if !exists {
+			continue
+		}
+		if !sameNodeTOML(prev, n) {
+			return false, fmt.Errorf("group: node %s in %s conflicts with node %s (%s)",
+				n.Address, location, prev.Address, strings.SplitN(k, ":", 2)[0])
+		}
+		duplicate = true
+	}
+	if duplicate {
+		return false, nil
+	}
+	for _, k := range keys {
+		r.seen[k] = n
+	}
+	return true, nil
+}
+
+func sameNodeTOML(a, b *NodeTOML) bool {
+	return a.Index == b.Index &&
+		a.Address == b.Address &&
+		a.Key == b.Key &&
+		a.TLS == b.TLS &&
+		a.Signature == b.Signature
+}
+
+func isURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// resolveLocation returns the location of ref relative to the parent location.
+func resolveLocation(parent, ref string) (string, error) {
+	if isURL(ref) {
+		return ref, nil
+	}
+	if isURL(parent) {
+		base, err := url.Parse(parent)
+		if err != nil {
+			return "", err
+		}
+		rel, err := url.Parse(ref)
+		if err != nil {
+			return "", err
+		}
+		return base.ResolveReference(rel).String(), nil
+	}
+	if filepath.IsAbs(ref) {
+		return ref, nil
+	}
+	return filepath.Join(filepath.Dir(parent), ref), nil
+}
+
+func canonicalLocation(location string) string {
+	if isURL(location) {
+		return location
+	}
+	if abs, err := filepath.Abs(location); err == nil {
+		return abs
+	}
+	return filepath.Clean(location)
+}
+
+// splitIncludePin splits the reference of an include from the hex encoded hash
+// pinning its content, if any.
+func splitIncludePin(inc string) (ref, pin string) {
+	if i := strings.LastIndex(inc, includePinPrefix); i >= 0 {
+		return inc[:i], inc[i+len(includePinPrefix):]
+	}
+	return inc, ""
+}
+
+// decodeLocation decodes the TOML found at the given file path or URL into v,
+// after checking its SHA-256 matches the pinned one, if any.
+func decodeLocation(location, pin string, v interface{}) error {
+	buff, err := readLocation(location)
+	if err != nil {
+		return err
+	}
+	if pin != "" {
+		expected, err := hex.DecodeString(pin)
+		if err != nil {
+			return fmt.Errorf("invalid pinned hash: %v", err)
+		}
+		if h := sha256.Sum256(buff); !bytes.Equal(h[:], expected) {
+			return fmt.Errorf("hash %x does not match the pinned one", h)
+		}
+	}
+	_, err = toml.Decode(string(buff), v)
+	return err
+}
+
+// readLocation reads the given file path or URL. Fragments larger than
+// DefaultMaxFileSize are rejected.
+func readLocation(location string) ([]byte, error) {
+	if !isURL(location) {
+		fd, err := openLimited(location, DefaultMaxFileSize)
+		if err != nil {
+			return nil, err
+		}
+		defer fd.Close()
+		return readLimited(fd, DefaultMaxFileSize)
+	}
+	client := &http.Client{Timeout: includeFetchTimeout, CheckRedirect: sameOriginRedirect}
+	resp, err := client.Get(location) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return readLimited(resp.Body, DefaultMaxFileSize)
+}
+
+// maxIncludeRedirects is the number of redirects followed when fetching a
+// remote group fragment.
+const maxIncludeRedirects = 10
+
+// sameOriginRedirect only follows the redirects keeping the scheme and the
+// host of the original request, so that a redirect can't downgrade an HTTPS
+// fragment to plain HTTP, bypassing the pinning it requires.
+func sameOriginRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxIncludeRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxIncludeRedirects)
+	}
+	if orig := via[0].URL; req.URL.Scheme != orig.Scheme || req.URL.Host != orig.Host {
+		return fmt.Errorf("redirect from %s://%s to %s://%s refused", orig.Scheme, orig.Host, req.URL.Scheme, req.URL.Host)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package key
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/drand/drand/common/scheme"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTOML(t *testing.T, path string, v interface{}) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0740))
+	fd, err := os.Create(path)
+	require.NoError(t, err)
+	defer fd.Close()
+	require.NoError(t, toml.NewEncoder(fd).Encode(v))
+}
+
+func fragment(nodes []*Node, includes ...string) *GroupFragmentTOML {
+	frag := &GroupFragmentTOML{Include: includes}
+	for _, n := range nodes {
+		frag.Nodes = append(frag.Nodes, n.TOML().(*NodeTOML))
+	}
+	return frag
+}
+
+func TestGroupIncludes(t *testing.T) {
+	dir := t.TempDir()
+	ids := newIds(4)
+	for i, id := range ids {
+		id.Addr = fmt.Sprintf("127.0.0.1:%d", 3000+i)
+	}
+	group := NewGroup(nil, 3, 1, 30*time.Second, 0, scheme.GetSchemeFromEnv(), "test_beacon")
+	group.Nodes = ids[:1]
+
+	groupPath := filepath.Join(dir, "group.toml")
+	gt := group.TOML().(*GroupTOML)
+	gt.Include = []string{"org-a.toml", "org-b.toml"}
+	// the seed depends on the merged list of nodes
+	gt.GenesisSeed = ""
+	writeTOML(t, groupPath, gt)
+	writeTOML(t, filepath.Join(dir, "org-a.toml"), fragment(ids[1:2], "sub/org-c.toml"))
+	// org-b repeats a node of org-a, which is not a conflict
+	writeTOML(t, filepath.Join(dir, "org-b.toml"), fragment(ids[1:3]))
+	writeTOML(t, filepath.Join(dir, "sub", "org-c.toml"), fragment(ids[3:]))
+
+	loaded := new(Group)
+	require.NoError(t, Load(groupPath, loaded))
+	require.Equal(t, 4, loaded.Len())
+	for _, id := range ids {
+		require.NotNil(t, loaded.Find(id.Identity))
+	}
+
+	// conflicting node: same address, different key
+	conflict := newIds(1)
+	conflict[0].Addr = ids[1].Addr
+	conflict[0].Index = 10
+	writeTOML(t, filepath.Join(dir, "org-b.toml"), fragment(conflict))
+	require.Error(t, Load(groupPath, new(Group)))
+
+	// cycle between fragments
+	writeTOML(t, filepath.Join(dir, "org-b.toml"), fragment(nil, "org-a.toml"))
+	writeTOML(t, filepath.Join(dir, "sub", "org-c.toml"), fragment(ids[3:], "../org-a.toml"))
+	err := Load(groupPath, new(Group))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cycle")
+}
+
+func TestGroupIncludesDepth(t *testing.T) {
+	dir := t.TempDir()
+	ids := newIds(1)
+	group := NewGroup(nil, 1, 1, 30*time.Second, 0, scheme.GetSchemeFromEnv(), "test_beacon")
+	group.Nodes = ids
+
+	groupPath := filepath.Join(dir, "group.toml")
+	gt := group.TOML().(*GroupTOML)
+	gt.Include = []string{"frag0.toml"}
+	writeTOML(t, groupPath, gt)
+	for i := 0; i <= MaxGroupIncludeDepth; i++ {
+		next := fmt.Sprintf("frag%d.toml", i+1)
+		writeTOML(t, filepath.Join(dir, fmt.Sprintf("frag%d.toml", i)), fragment(nil, next))
+	}
+	writeTOML(t, filepath.Join(dir, fmt.Sprintf("frag%d.toml", MaxGroupIncludeDepth+1)), fragment(nil))
+
+	err := Load(groupPath, new(Group))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "depth")
+}
+
+func TestGroupIncludesWithoutIndexes(t *testing.T) {
+	dir := t.TempDir()
+	ids := newIds(4)
+	for i, id := range ids {
+		id.Addr = fmt.Sprintf("127.0.0.1:%d", 3000+i)
+		id.Index = 0
+	}
+	group := NewGroup(nil, 3, 1, 30*time.Second, 0, scheme.GetSchemeFromEnv(), "test_beacon")
+	group.Nodes = ids[:1]
+
+	groupPath := filepath.Join(dir, "group.toml")
+	gt := group.TOML().(*GroupTOML)
+	gt.Include = []string{"org-a.toml", "org-b.toml"}
+	gt.GenesisSeed = ""
+	writeTOML(t, groupPath, gt)
+	writeTOML(t, filepath.Join(dir, "org-a.toml"), fragment(ids[1:3]))
+	writeTOML(t, filepath.Join(dir, "org-b.toml"), fragment(ids[3:]))
+
+	loaded := new(Group)
+	require.NoError(t, Load(groupPath, loaded))
+	require.Equal(t, 4, loaded.Len())
+	require.NoError(t, loaded.Valid())
+	for i, n := range loaded.Nodes {
+		require.Equal(t, Index(i), n.Index)
+	}
+
+	// explicit indexes still conflict
+	ids[3].Index = 7
+	ids[2].Index = 7
+	writeTOML(t, filepath.Join(dir, "org-a.toml"), fragment(ids[2:3]))
+	writeTOML(t, filepath.Join(dir, "org-b.toml"), fragment(ids[3:]))
+	require.Error(t, Load(groupPath, new(Group)))
+}
+
+func TestGroupIncludesPinned(t *testing.T) {
+	ids := newIds(2)
+	ids[1].Addr = "127.0.0.1:3001"
+	var buf bytes.Buffer
+	require.NoError(t, toml.NewEncoder(&buf).Encode(fragment(ids[1:])))
+	content := buf.Bytes()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+	hash := sha256.Sum256(content)
+
+	dir := t.TempDir()
+	group := NewGroup(nil, 2, 1, 30*time.Second, 0, scheme.GetSchemeFromEnv(), "test_beacon")
+	group.Nodes = ids[:1]
+	groupPath := filepath.Join(dir, "group.toml")
+	load := func(include string) error {
+		gt := group.TOML().(*GroupTOML)
+		gt.GenesisSeed = ""
+		gt.Include = []string{include}
+		writeTOML(t, groupPath, gt)
+		return LoadWithRemoteIncludes(groupPath, new(Group))
+	}
+
+	// plain HTTP requires a pinned hash
+	err := load(server.URL + "/org.toml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "pin")
+	require.NoError(t, load(server.URL+"/org.toml#sha256="+hex.EncodeToString(hash[:])))
+	other := sha256.Sum256([]byte("another fragment"))
+	err = load(server.URL + "/org.toml#sha256=" + hex.EncodeToString(other[:]))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "pinned")
+
+	// local fragments can be pinned too
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "org.toml"), content, 0o600))
+	require.NoError(t, load("org.toml#sha256="+hex.EncodeToString(hash[:])))
+	require.Error(t, load("org.toml#sha256="+hex.EncodeToString(other[:])))
+
+	// redirects to another origin are refused
+	redirect := httptest.NewServer(http.RedirectHandler(server.URL+"/org.toml", http.StatusFound))
+	defer redirect.Close()
+	err = load(redirect.URL + "/org.toml#sha256=" + hex.EncodeToString(hash[:]))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "redirect")
+
+	// the loads of a store never reach the network
+	require.NoError(t, load(server.URL+"/org.toml#sha256="+hex.EncodeToString(hash[:])))
+	err = Load(groupPath, new(Group))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "LoadWithRemoteIncludes")
+	store := NewFileStore(dir, "").(*fileStore)
+	require.NoError(t, os.MkdirAll(filepath.Dir(store.groupFile), 0o700))
+	require.NoError(t, os.Rename(groupPath, store.groupFile))
+	_, err = store.LoadGroup()
+	require.Error(t, err)
+}
@@ -0,0 +1,218 @@
+package key
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	kyber "github.com/drand/kyber"
+)
+
+// Conflict is an entry of a group edited differently by the two branches of
+// a merge, see ThreeWayMergeGroup.
+type Conflict struct {
+	// Field is the name of the conflicting field of the group, or "node" for
+	// a node edited differently
+	Field string
+	// Key is the public key of the node of a node conflict
+	Key string
+	// Base, A and B describe the entry in the base and in each branch, an
+	// empty description meaning the entry is absent
+	Base, A, B string
+}
+
+func (c Conflict) String() string {
+	describe := func(s string) string {
+		if s == "" {
+			return "absent"
+		}
+		return s
+	}
+	field := c.Field
+	if c.Key != "" {
+		field += " " + c.Key
+	}
+	return fmt.Sprintf("%s: %s in base, %s in A, %s in B", field, describe(c.Base), describe(c.A), describe(c.B))
+}
+
+// groupField is a field of a group merged by ThreeWayMergeGroup.
+type groupField struct {
+	name string
+	// value describes the field of the group, so that equal descriptions mean
+	// equal values
+	value func(g *Group) string
+	// copy sets the field of dst to the one of src
+	copy func(dst, src *Group)
+}
+
+var mergedGroupFields = []groupField{
+	{"threshold", func(g *Group) string { return fmt.Sprint(g.Threshold) },
+		func(dst, src *Group) { dst.Threshold = src.Threshold }},
+	{"period", func(g *Group) string { return g.Period.String() },
+		func(dst, src *Group) { dst.Period = src.Period }},
+	{"catchup period", func(g *Group) string { return g.CatchupPeriod.String() },
+		func(dst, src *Group) { dst.CatchupPeriod = src.CatchupPeriod }},
+	{"genesis time", func(g *Group) string { return fmt.Sprint(g.GenesisTime) },
+		func(dst, src *Group) { dst.GenesisTime = src.GenesisTime }},
+	{"transition time", func(g *Group) string { return fmt.Sprint(g.TransitionTime) },
+		func(dst, src *Group) { dst.TransitionTime = src.TransitionTime }},
+	{"genesis seed", func(g *Group) string { return hex.EncodeToString(g.GenesisSeed) },
+		func(dst, src *Group) { dst.GenesisSeed = append([]byte(nil), src.GenesisSeed...) }},
+	{"scheme", func(g *Group) string { return g.Scheme.ID },
+		func(dst, src *Group) { dst.Scheme = src.Scheme }},
+	{"beacon id", func(g *Group) string { return g.ID },
+		func(dst, src *Group) { dst.ID = src.ID }},
+	{"distributed public key", distPublicDescription, copyDistPublic},
+	{"metadata", metadataDescription,
+		func(dst, src *Group) { dst.metadata = copyMetadata(src.metadata) }},
+}
+
+func distPublicDescription(g *Group) string {
+	switch {
+	case g.PublicKey != nil:
+		return hex.EncodeToString(g.PublicKey.Hash())
+	case g.distPublicHash != nil:
+		return hex.EncodeToString(g.distPublicHash)
+	}
+	return ""
+}
+
+func copyDistPublic(dst, src *Group) {
+	dst.PublicKey, dst.distPublicHash = nil, src.distPublicHash
+	if src.PublicKey != nil {
+		dst.PublicKey = &DistPublic{Coefficients: append([]kyber.Point{}, src.PublicKey.Coefficients...)}
+	}
+}
+
+func metadataDescription(g *Group) string {
+	entries := make([]string, 0, len(g.metadata))
+	for k, v := range g.metadata {
+		entries = append(entries, fmt.Sprintf("%q=%q", k, v))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, " ")
+}
+
+// nodeDescription describes all the fields of a node, empty for a nil node.
+func nodeDescription(n *Node) string {
+	if n == nil {
+		return ""
+	}
+	desc := fmt.Sprintf("%s (index %d, tls %t)", n.Addr, n.Index, n.TLS)
+	if n.Comment != "" {
+		desc += fmt.Sprintf(" %q", n.Comment)
+	}
+	if n.Signature != nil {
+		desc += fmt.Sprintf(" signed %x", n.Signature)
+	}
+	return desc
+}
+
+// merge3 returns which version of an entry the merge keeps given the
+// descriptions of the entry in the base and in each branch: the one of the
+// branch that changed it, or "" if both branches changed it differently.
+func merge3(base, a, b string) string {
+	switch {
+	case a == b || b == base:
+		return "a"
+	case a == base:
+		return "b"
+	}
+	return ""
+}
+
+// ThreeWayMergeGroup merges the edits made to the base group by two branches,
+// a and b, as in a merge of version control. Nodes are matched by public key:
+// a node added, removed or edited by one branch only is merged, as is a field
+// of the group changed by one branch only or by both in the same way. Entries
+// changed differently by both branches, e.g. the same node edited differently
+// or removed by one branch and edited by the other, or two different
+// thresholds, are returned as conflicts and keep their base version in the
+// merged group, to be resolved by hand. Nodes added by b whose index is taken
+// by another node get the lowest free index. Without conflicts, the merged
+// group must pass Valid. None of the groups is modified.
+func ThreeWayMergeGroup(base, a, b *Group) (*Group, []Conflict, error) {
+	if base == nil || a == nil || b == nil {
+		return nil, nil, errors.New("group: can't merge nil groups")
+	}
+	merged := base.Copy()
+	var conflicts []Conflict
+	for _, f := range mergedGroupFields {
+		v0, va, vb := f.value(base), f.value(a), f.value(b)
+		switch merge3(v0, va, vb) {
+		case "a":
+			f.copy(merged, a)
+		case "b":
+			f.copy(merged, b)
+		default:
+			conflicts = append(conflicts, Conflict{Field: f.name, Base: v0, A: va, B: vb})
+		}
+	}
+
+	byKey := func(g *Group) map[string]*Node {
+		nodes := make(map[string]*Node, g.Len())
+		for _, n := range g.Nodes {
+			nodes[n.Key.String()] = n
+		}
+		return nodes
+	}
+	inBase, inA, inB := byKey(base), byKey(a), byKey(b)
+	var keys []string
+	seen := make(map[string]bool)
+	for _, g := range []*Group{base, a, b} {
+		for _, n := range g.Nodes {
+			if k := n.Key.String(); !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	var nodes, added []*Node
+	used := make(map[Index]bool)
+	for _, k := range keys {
+		n0, na, nb := inBase[k], inA[k], inB[k]
+		d0, da, db := nodeDescription(n0), nodeDescription(na), nodeDescription(nb)
+		var chosen *Node
+		switch merge3(d0, da, db) {
+		case "a":
+			chosen = na
+		case "b":
+			chosen = nb
+			if n0 == nil && na == nil {
+				// renumbered below if its index is taken
+				added = append(added, nb.copy())
+				continue
+			}
+		default:
+			conflicts = append(conflicts, Conflict{Field: "node", Key: k, Base: d0, A: da, B: db})
+			chosen = n0
+		}
+		if chosen != nil {
+			nodes = append(nodes, chosen.copy())
+			used[chosen.Index] = true
+		}
+	}
+	next := Index(0)
+	for _, n := range added {
+		if used[n.Index] {
+			for used[next] {
+				next++
+			}
+			n.Index = next
+		}
+		used[n.Index] = true
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Index < nodes[j].Index })
+	merged.Nodes = nodes
+
+	if len(conflicts) > 0 {
+		return merged, conflicts, nil
+	}
+	if err := merged.Valid(); err != nil {
+		return nil, nil, fmt.Errorf("group: merged group is invalid: %w", err)
+	}
+	return merged, nil, nil
+}
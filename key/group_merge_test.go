@@ -0,0 +1,68 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestThreeWayMergeGroup(t *testing.T) {
+	pairs, base := BatchIdentities(5)
+	base.Threshold = 3
+	base.Nodes = base.Nodes[:4]
+	joining := NewTLSKeyPair("127.0.0.1:9000")
+
+	// A removes a node and adds one, B adds another one at the same index and
+	// changes the comment of a node
+	a := base.Copy()
+	a.Nodes = append(a.Nodes[1:], &Node{Identity: pairs[4].Public, Index: 4})
+	b := base.Copy()
+	b.Nodes = append(b.Nodes, &Node{Identity: joining.Public, Index: 4})
+	b.Find(pairs[2].Public).Comment = "run by org B"
+
+	merged, conflicts, err := ThreeWayMergeGroup(base, a, b)
+	require.NoError(t, err)
+	require.Empty(t, conflicts)
+	require.Len(t, merged.Nodes, 5)
+	require.Nil(t, merged.Find(pairs[0].Public))
+	require.Equal(t, Index(4), merged.Find(pairs[4].Public).Index)
+	require.Equal(t, Index(0), merged.Find(joining.Public).Index)
+	require.Equal(t, "run by org B", merged.Find(pairs[2].Public).Comment)
+	require.Equal(t, 3, merged.Threshold)
+	require.Len(t, base.Nodes, 4)
+	require.Empty(t, base.Find(pairs[2].Public).Comment)
+
+	// the same change on both sides is no conflict
+	a.Threshold, b.Threshold = 4, 4
+	merged, conflicts, err = ThreeWayMergeGroup(base, a, b)
+	require.NoError(t, err)
+	require.Empty(t, conflicts)
+	require.Equal(t, 4, merged.Threshold)
+
+	// different thresholds, and a node edited by A and removed by B
+	b.Threshold = 2
+	a.Find(pairs[3].Public).Addr = "127.0.0.1:9001"
+	b.Nodes = b.Nodes[:len(b.Nodes)-2]
+	b.Nodes = append(b.Nodes, &Node{Identity: joining.Public, Index: 4})
+	merged, conflicts, err = ThreeWayMergeGroup(base, a, b)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 2)
+	require.Equal(t, "threshold", conflicts[0].Field)
+	require.Equal(t, "3", conflicts[0].Base)
+	require.Equal(t, "4", conflicts[0].A)
+	require.Equal(t, "2", conflicts[0].B)
+	require.Equal(t, "node", conflicts[1].Field)
+	require.Equal(t, pairs[3].Public.Key.String(), conflicts[1].Key)
+	require.Empty(t, conflicts[1].B)
+	require.Contains(t, conflicts[1].String(), "absent in B")
+	// conflicting entries keep their base version
+	require.Equal(t, 3, merged.Threshold)
+	require.Equal(t, base.Find(pairs[3].Public).Addr, merged.Find(pairs[3].Public).Addr)
+
+	// a merge giving an invalid group
+	a, b = base.Copy(), base.Copy()
+	a.Nodes = a.Nodes[:3]
+	b.Nodes = append(b.Nodes[:2], b.Nodes[3])
+	_, _, err = ThreeWayMergeGroup(base, a, b)
+	require.Error(t, err)
+}
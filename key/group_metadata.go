@@ -0,0 +1,32 @@
+package key
+
+// SetMetadata sets the annotation of the group under the given key, e.g. the
+// name of the network or a contact. An empty value removes the annotation.
+// Annotations are kept in the group file but are not part of the hash of the
+// group, so they can be changed without changing its identity.
+func (g *Group) SetMetadata(key, value string) {
+	if value == "" {
+		delete(g.metadata, key)
+		return
+	}
+	if g.metadata == nil {
+		g.metadata = make(map[string]string)
+	}
+	g.metadata[key] = value
+}
+
+// Metadata returns a copy of the annotations of the group.
+func (g *Group) Metadata() map[string]string {
+	return copyMetadata(g.metadata)
+}
+
+func copyMetadata(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
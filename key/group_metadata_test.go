@@ -0,0 +1,42 @@
+package key
+
+import (
+	"bytes"
+	"path"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupMetadata(t *testing.T) {
+	_, group := BatchIdentities(3)
+	hash := group.Hash()
+	require.Nil(t, group.Metadata())
+
+	group.SetMetadata("network", "testnet")
+	group.SetMetadata("contact", "ops@example.com")
+	group.SetMetadata("chain id", "42")
+	require.Equal(t, hash, group.Hash())
+	group.Metadata()["network"] = "mainnet"
+	require.Equal(t, "testnet", group.Metadata()["network"])
+
+	filePath := path.Join(t.TempDir(), "group.toml")
+	require.NoError(t, Save(filePath, group, false))
+	loaded := new(Group)
+	require.NoError(t, Load(filePath, loaded))
+	require.Equal(t, group.Metadata(), loaded.Metadata())
+	require.Equal(t, hash, loaded.Hash())
+
+	// the standard encoding, which writes the table after the nodes, decodes
+	// as well
+	var buf bytes.Buffer
+	require.NoError(t, toml.NewEncoder(&buf).Encode(group.TOML()))
+	decoded, err := DecodeGroup(&buf)
+	require.NoError(t, err)
+	require.Equal(t, group.Metadata(), decoded.Metadata())
+
+	require.Equal(t, group.Metadata(), group.Copy().Metadata())
+	group.SetMetadata("contact", "")
+	require.NotContains(t, group.Metadata(), "contact")
+}
@@ -0,0 +1,180 @@
+package key
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// minimalDiffGroup is a group encoded so that its file differs from the
+// previous content of the file only by the entries that changed.
+type minimalDiffGroup struct {
+	*Group
+	previous []byte
+}
+
+// groupSections are the parts of a group file: the fields of the group, the
+// [[Nodes]] tables along with the comments above them, in order, and the
+// [PublicKey] table.
+type groupSections struct {
+	header  []byte
+	nodes   [][]byte
+	trailer []byte
+}
+
+func (s groupSections) bytes() []byte {
+	out := append([]byte{}, s.header...)
+	for _, n := range s.nodes {
+		out = append(out, n...)
+	}
+	return append(out, s.trailer...)
+}
+
+// encodeSections returns the sections of the group as written by EncodeGroup.
+func encodeSections(g *Group) (groupSections, error) {
+	var s groupSections
+	var out, buf bytes.Buffer
+	if err := toml.NewEncoder(&out).Encode(g.tomlHeader()); err != nil {
+		return s, err
+	}
+	s.header = append([]byte{}, out.Bytes()...)
+	enc := toml.NewEncoder(&buf)
+	section := func(write func(bw *bufio.Writer) error) ([]byte, error) {
+		out.Reset()
+		bw := bufio.NewWriter(&out)
+		if err := write(bw); err != nil {
+			return nil, err
+		}
+		err := bw.Flush()
+		return append([]byte{}, out.Bytes()...), err
+	}
+	for _, n := range g.Nodes {
+		node, err := section(func(bw *bufio.Writer) error { return writeNodeTable(bw, &buf, enc, n) })
+		if err != nil {
+			return s, err
+		}
+		s.nodes = append(s.nodes, node)
+	}
+	var err error
+	s.trailer, err = section(func(bw *bufio.Writer) error { return writePublicKeyTable(bw, &buf, enc, g) })
+	return s, err
+}
+
+// splitGroupFile splits the content of a group file into its sections, as
+// written, the comments and blank lines above a table belonging to it. It
+// returns false if the file is not laid out as EncodeGroup does.
+func splitGroupFile(data []byte) (groupSections, bool) {
+	lines := strings.SplitAfter(string(data), "\n")
+	var starts []int
+	trailer := len(lines)
+	floor := 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "[") {
+			continue
+		}
+		if trimmed != nodesTableHeader && len(starts) == 0 {
+			// tables of the header, such as [Metadata]
+			floor = i + 1
+			continue
+		}
+		start := i
+		for start > floor && isCommentOrBlank(lines[start-1]) {
+			start--
+		}
+		floor = i + 1
+		switch {
+		case trimmed == nodesTableHeader && trailer < len(lines):
+			return groupSections{}, false
+		case trimmed == nodesTableHeader:
+			starts = append(starts, start)
+		case trailer == len(lines):
+			trailer = start
+		}
+	}
+	if len(starts) == 0 {
+		return groupSections{}, false
+	}
+	join := func(from, to int) []byte {
+		return []byte(strings.Join(lines[from:to], ""))
+	}
+	s := groupSections{header: join(0, starts[0]), trailer: join(trailer, len(lines))}
+	for i, start := range starts {
+		end := trailer
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		s.nodes = append(s.nodes, join(start, end))
+	}
+	return s, true
+}
+
+func isCommentOrBlank(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || strings.HasPrefix(trimmed, "#")
+}
+
+// encodeGroupMinimalDiff writes the group to w reusing, byte for byte, the
+// sections of the previous content of its file which hold the same entries:
+// the fields of the group, each node matched by public key, and the
+// distributed public key. The other sections are written as EncodeGroup does.
+// The group is written as EncodeGroup does if the previous content can't be
+// decoded or reusing it would not yield the same group.
+func encodeGroupMinimalDiff(w io.Writer, previous []byte, g *Group) error {
+	fresh, err := encodeSections(g)
+	if err != nil {
+		return err
+	}
+	if out, ok := reuseSections(previous, g, fresh); ok {
+		_, err = w.Write(out)
+		return err
+	}
+	_, err = w.Write(fresh.bytes())
+	return err
+}
+
+func reuseSections(previous []byte, g *Group, fresh groupSections) ([]byte, bool) {
+	old := new(Group)
+	if previous == nil || Decode(bytes.NewReader(previous), old) != nil {
+		return nil, false
+	}
+	raw, ok := splitGroupFile(previous)
+	if !ok || len(raw.nodes) != old.Len() {
+		return nil, false
+	}
+	oldFresh, err := encodeSections(old)
+	if err != nil {
+		return nil, false
+	}
+	out := groupSections{header: fresh.header, nodes: append([][]byte{}, fresh.nodes...), trailer: fresh.trailer}
+	if bytes.Equal(oldFresh.header, fresh.header) {
+		out.header = raw.header
+	}
+	if bytes.Equal(oldFresh.trailer, fresh.trailer) {
+		out.trailer = raw.trailer
+	}
+	oldNodes := make(map[string]int, old.Len())
+	for i, n := range old.Nodes {
+		oldNodes[n.Key.String()] = i
+	}
+	for j, n := range g.Nodes {
+		if i, ok := oldNodes[n.Key.String()]; ok && bytes.Equal(oldFresh.nodes[i], fresh.nodes[j]) {
+			out.nodes[j] = raw.nodes[i]
+		}
+	}
+
+	// the reused sections must decode to the same group, comments included
+	data := out.bytes()
+	decoded := new(Group)
+	if Decode(bytes.NewReader(data), decoded) != nil {
+		return nil, false
+	}
+	check, err := encodeSections(decoded)
+	if err != nil || !bytes.Equal(check.bytes(), fresh.bytes()) {
+		return nil, false
+	}
+	return data, true
+}
@@ -0,0 +1,98 @@
+package key
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/drand/drand/common/scheme"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreMinimalGroupDiffs(t *testing.T) {
+	_, group := BatchIdentities(4)
+	_, dist := dealShares(4, 3)
+	group.PublicKey = dist
+	group.Scheme = scheme.GetSchemeFromEnv()
+	group.Nodes[0].Comment = "run by org A"
+	store := NewFileStore(t.TempDir(), "", WithMinimalGroupDiffs())
+	groupFile := store.(*fileStore).groupFile
+	require.NoError(t, store.SaveGroup(group))
+
+	// hand edits of the operators are kept
+	data, err := os.ReadFile(groupFile)
+	require.NoError(t, err)
+	edited := "# managed by the ops team\n" + strings.Replace(string(data), "# run by org A", "#run by org A", 1)
+	require.NoError(t, os.WriteFile(groupFile, []byte(edited), 0o600))
+	before, ok := splitGroupFile([]byte(edited))
+	require.True(t, ok)
+
+	target := group.Nodes[2]
+	moved := &Node{Identity: &Identity{Key: target.Key, Addr: "127.0.0.1:9999", TLS: target.TLS, Signature: target.Signature}}
+	updated, err := group.UpdateNode(moved)
+	require.NoError(t, err)
+	require.Equal(t, target.Index, updated.Nodes[2].Index)
+	require.NoError(t, store.SaveGroup(updated))
+
+	data, err = os.ReadFile(groupFile)
+	require.NoError(t, err)
+	after, ok := splitGroupFile(data)
+	require.True(t, ok)
+	require.Equal(t, string(before.header), string(after.header))
+	require.Equal(t, string(before.trailer), string(after.trailer))
+	require.Len(t, after.nodes, len(before.nodes))
+	for i := range before.nodes {
+		if i == 2 {
+			require.NotEqual(t, string(before.nodes[i]), string(after.nodes[i]))
+			require.Contains(t, string(after.nodes[i]), "127.0.0.1:9999")
+			continue
+		}
+		require.Equal(t, string(before.nodes[i]), string(after.nodes[i]), "node %d", i)
+	}
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(updated))
+	require.Equal(t, "run by org A", loaded.Nodes[0].Comment)
+
+	// sections holding other values are rewritten
+	reshared := updated.Copy()
+	reshared.Nodes[0].Comment = "run by org B"
+	_, reshared.PublicKey = dealShares(4, 3)
+	require.NoError(t, store.SaveGroup(reshared))
+	data, err = os.ReadFile(groupFile)
+	require.NoError(t, err)
+	last, ok := splitGroupFile(data)
+	require.True(t, ok)
+	require.Equal(t, string(after.header), string(last.header))
+	require.NotEqual(t, string(after.trailer), string(last.trailer))
+	require.Contains(t, string(last.nodes[0]), "# run by org B")
+	require.Equal(t, string(after.nodes[1]), string(last.nodes[1]))
+	loaded, err = store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(reshared))
+
+	// without the option, the whole file is written again
+	plain := NewFileStore(t.TempDir(), "")
+	require.NoError(t, os.WriteFile(plain.(*fileStore).groupFile, []byte(edited), 0o600))
+	require.NoError(t, plain.SaveGroup(updated))
+	data, err = os.ReadFile(plain.(*fileStore).groupFile)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "managed by the ops team")
+}
+
+func TestGroupUpdateNode(t *testing.T) {
+	_, group := BatchIdentities(3)
+	_, other := BatchIdentities(1)
+	_, err := group.UpdateNode(other.Nodes[0])
+	require.Error(t, err)
+
+	n := group.Nodes[1].copy()
+	n.Index = 42
+	n.Comment = "new comment"
+	updated, err := group.UpdateNode(n)
+	require.NoError(t, err)
+	require.Equal(t, group.Nodes[1].Index, updated.Nodes[1].Index)
+	require.Equal(t, "new comment", updated.Nodes[1].Comment)
+	require.Empty(t, group.Nodes[1].Comment)
+	require.Equal(t, group.Hash(), updated.Hash())
+}
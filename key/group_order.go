@@ -0,0 +1,103 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrNodeOrder is returned when the nodes of a group are not listed in their
+// canonical order, by increasing index.
+var ErrNodeOrder = errors.New("group: nodes not in index order")
+
+// CheckNodeOrder checks the nodes of the group are listed by increasing index,
+// the order in which drand writes them. The index of a node is the one of its
+// share, written along the node, so a hand edit reordering the nodes of a
+// group file leaves the positions and the indexes disagreeing, which breaks
+// the code relying on the position of the nodes. It returns an error wrapping
+// ErrNodeOrder telling the first misplaced node.
+func (g *Group) CheckNodeOrder() error {
+	indexes := make([]Index, len(g.Nodes))
+	for i, n := range g.Nodes {
+		indexes[i] = n.Index
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	for i, n := range g.Nodes {
+		if n.Index != indexes[i] {
+			return fmt.Errorf("%w: position %d holds the node of index %d (%s), the node of index %d belongs there",
+				ErrNodeOrder, i, n.Index, n.Addr, indexes[i])
+		}
+	}
+	return nil
+}
+
+// CheckCanonicalIndices checks the indexes of the nodes follow the canonical
+// assignment of AssignIndices, which orders the nodes by public key: listed by
+// increasing index, the nodes must come in that order. This detects a hand
+// edit changing the index of a node, which then no longer matches its share.
+// The indexes need not be contiguous, so that a group edited by RemoveNode
+// passes. It returns an error wrapping ErrNodeOrder telling the first node off
+// its canonical rank.
+func (g *Group) CheckCanonicalIndices() error {
+	byIndex := make([]*Node, len(g.Nodes))
+	copy(byIndex, g.Nodes)
+	sort.SliceStable(byIndex, func(i, j int) bool { return byIndex[i].Index < byIndex[j].Index })
+	ids := make([]*Identity, len(byIndex))
+	for i, n := range byIndex {
+		ids[i] = n.Identity
+	}
+	for i, a := range AssignIndices(ids) {
+		if a.Identity != byIndex[i].Identity {
+			return fmt.Errorf("%w: the node of index %d (%s) is not the %d-th node in the canonical order, %s is",
+				ErrNodeOrder, byIndex[i].Index, byIndex[i].Addr, i, a.Identity.Addr)
+		}
+	}
+	return nil
+}
+
+// sortedByIndex returns the group with its nodes listed by increasing index,
+// sorting a copy if they are not.
+func sortedByIndex(g *Group) *Group {
+	if g.CheckNodeOrder() == nil {
+		return g
+	}
+	c := g.Copy()
+	sort.SliceStable(c.Nodes, func(i, j int) bool { return c.Nodes[i].Index < c.Nodes[j].Index })
+	return c
+}
+
+// WithStrictNodeOrder makes LoadGroup fail with an error wrapping ErrNodeOrder
+// when the nodes of the group file are not listed by increasing index, see
+// CheckNodeOrder, or their indexes do not follow the canonical assignment, see
+// CheckCanonicalIndices, instead of only logging a warning. Either way, the
+// next save of the group writes the nodes back in index order; indexes off the
+// canonical assignment are kept, as they are the ones of the shares.
+func WithStrictNodeOrder() StoreOption {
+	return func(f *fileStore) {
+		f.strictNodeOrder = true
+	}
+}
+
+// loadGroupFile loads the group held by the file and checks the order and the
+// indexes of its nodes in the file. The nodes are checked as decoded, before
+// anything hashes the group, as hashing sorts them.
+func (f *fileStore) loadGroupFile(filePath string, g *Group) error {
+	if err := f.load(filePath, g); err != nil {
+		return err
+	}
+	msg := "group nodes not in index order, the next save sorts them"
+	err := g.CheckNodeOrder()
+	if err == nil {
+		msg = "group node indexes differ from the canonical assignment"
+		err = g.CheckCanonicalIndices()
+	}
+	switch {
+	case err == nil:
+		return nil
+	case f.strictNodeOrder:
+		return wrapFileError(filePath, err)
+	default:
+		f.log.Warnw("", "store", msg, "file", filePath, "err", err)
+		return nil
+	}
+}
@@ -0,0 +1,89 @@
+package key
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/drand/drand/log"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestGroupCheckNodeOrder(t *testing.T) {
+	_, group := BatchIdentities(4)
+	require.NoError(t, group.CheckNodeOrder())
+	group.Nodes[1], group.Nodes[3] = group.Nodes[3], group.Nodes[1]
+	err := group.CheckNodeOrder()
+	require.ErrorIs(t, err, ErrNodeOrder)
+	require.Contains(t, err.Error(), "position 1 holds the node of index 3")
+
+	sorted := sortedByIndex(group)
+	require.NoError(t, sorted.CheckNodeOrder())
+	require.Error(t, group.CheckNodeOrder())
+}
+
+func TestGroupCheckCanonicalIndices(t *testing.T) {
+	_, group := BatchIdentities(4)
+	require.NoError(t, group.CheckCanonicalIndices())
+	// the position does not matter, only the indexes
+	group.Nodes[1], group.Nodes[3] = group.Nodes[3], group.Nodes[1]
+	require.NoError(t, group.CheckCanonicalIndices())
+	removed, _, err := group.RemoveNode(group.Nodes[2].Addr)
+	require.NoError(t, err)
+	require.NoError(t, removed.CheckCanonicalIndices())
+
+	// an operator swaps the indexes of two nodes
+	edited := sortedByIndex(group).Copy()
+	edited.Nodes[0].Index, edited.Nodes[2].Index = edited.Nodes[2].Index, edited.Nodes[0].Index
+	edited.Nodes[0], edited.Nodes[2] = edited.Nodes[2], edited.Nodes[0]
+	require.NoError(t, edited.CheckNodeOrder())
+	err = edited.CheckCanonicalIndices()
+	require.ErrorIs(t, err, ErrNodeOrder)
+	require.Contains(t, err.Error(), "the node of index 0")
+}
+
+func TestStoreNodeOrder(t *testing.T) {
+	_, group := BatchIdentities(4)
+	group.GenesisSeed = group.ComputeGenesisSeed()
+	var logs bytes.Buffer
+	logger := log.NewLogger(zapcore.AddSync(&logs), log.LogWarn)
+	folder := t.TempDir()
+	store := NewFileStore(folder, "", WithLogger(logger)).(*fileStore)
+
+	// an operator swaps two nodes by hand
+	misordered := group.Copy()
+	misordered.Nodes[0], misordered.Nodes[2] = misordered.Nodes[2], misordered.Nodes[0]
+	require.NoError(t, Save(store.groupFile, misordered, false))
+
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.Equal(t, group.Hash(), loaded.Hash())
+	require.Contains(t, logs.String(), "group nodes not in index order")
+
+	strict := NewFileStore(folder, "", WithStrictNodeOrder())
+	_, err = strict.LoadGroup()
+	require.ErrorIs(t, err, ErrNodeOrder)
+
+	// the next save writes the nodes back in order
+	require.NoError(t, store.SaveGroup(misordered))
+	raw := new(Group)
+	require.NoError(t, Load(store.groupFile, raw))
+	require.NoError(t, raw.CheckNodeOrder())
+	for i, n := range raw.Nodes {
+		require.Equal(t, Index(i), n.Index)
+	}
+	_, err = strict.LoadGroup()
+	require.NoError(t, err)
+
+	// indexes changed against the key order are reported too
+	reindexed := sortedByIndex(group).Copy()
+	reindexed.Nodes[0].Index, reindexed.Nodes[1].Index = 1, 0
+	reindexed.Nodes[0], reindexed.Nodes[1] = reindexed.Nodes[1], reindexed.Nodes[0]
+	require.NoError(t, Save(store.groupFile, reindexed, false))
+	logs.Reset()
+	_, err = store.LoadGroup()
+	require.NoError(t, err)
+	require.Contains(t, logs.String(), "group node indexes differ from the canonical assignment")
+	_, err = strict.LoadGroup()
+	require.ErrorIs(t, err, ErrNodeOrder)
+}
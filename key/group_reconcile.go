@@ -0,0 +1,76 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ReconcileGroup returns a copy of the current group whose nodes are the given
+// authoritative identities, e.g. as listed by an external registry, using the
+// given threshold, along with the differences from the current group. Nodes
+// already in the current group, matched by public key, keep their index and
+// take the address and TLS setting of their authoritative identity; new nodes
+// get the lowest free indexes. If the set of nodes or the threshold changes,
+// the distributed public key is cleared, as the new group needs a resharing.
+// It performs no I/O and never modifies current.
+func ReconcileGroup(current *Group, authoritative []*Identity, threshold int) (*Group, GroupDiff, error) {
+	if current == nil {
+		return nil, GroupDiff{}, errors.New("group: can't reconcile a nil group")
+	}
+	if err := validThreshold(threshold, len(authoritative)); err != nil {
+		return nil, GroupDiff{}, err
+	}
+	byKey := make(map[string]*Node, current.Len())
+	used := make(map[Index]bool, current.Len())
+	for _, n := range current.Nodes {
+		byKey[n.Key.String()] = n
+	}
+
+	nodes := make([]*Node, 0, len(authoritative))
+	var added []*Node
+	seen := make(map[string]bool, len(authoritative))
+	for _, id := range authoritative {
+		if id == nil || id.Key == nil {
+			return nil, GroupDiff{}, errors.New("group: authoritative identity without key")
+		}
+		k := id.Key.String()
+		if seen[k] {
+			return nil, GroupDiff{}, fmt.Errorf("group: duplicate authoritative key for %s", id.Addr)
+		}
+		seen[k] = true
+		n := &Node{Identity: id}
+		if existing, ok := byKey[k]; ok {
+			n.Index, n.Comment = existing.Index, existing.Comment
+			used[n.Index] = true
+		} else {
+			added = append(added, n)
+		}
+		nodes = append(nodes, n)
+	}
+	next := Index(0)
+	for _, n := range added {
+		for used[next] {
+			next++
+		}
+		n.Index = next
+		used[next] = true
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Index < nodes[j].Index })
+
+	edited := *current
+	edited.Threshold = threshold
+	if len(added) > 0 || len(nodes) != current.Len() || threshold != current.Threshold {
+		edited.PublicKey = nil
+		edited.distPublicHash = nil
+	}
+	reconciled, err := edited.WithNodes(nodes)
+	if err != nil {
+		return nil, GroupDiff{}, err
+	}
+	if err := reconciled.Valid(); err != nil {
+		return nil, GroupDiff{}, err
+	}
+	diff, err := CompareGroups(current, reconciled)
+	return reconciled, diff, err
+}
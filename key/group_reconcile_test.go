@@ -0,0 +1,54 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileGroup(t *testing.T) {
+	pairs, current := BatchIdentities(4)
+	_, dist := dealShares(4, current.Threshold)
+	current.PublicKey = dist
+	ids := func(ps ...*Pair) []*Identity {
+		list := make([]*Identity, len(ps))
+		for i, p := range ps {
+			list[i] = p.Public
+		}
+		return list
+	}
+
+	// same set: nothing changes
+	same, diff, err := ReconcileGroup(current, ids(pairs[3], pairs[1], pairs[0], pairs[2]), current.Threshold)
+	require.NoError(t, err)
+	require.True(t, diff.Equivalent(), diff.String())
+	require.True(t, same.PublicKey.Equal(dist))
+
+	// one node leaves, one joins
+	newcomer := NewTLSKeyPair("127.0.0.1:9000")
+	leaving := current.Find(pairs[2].Public)
+	reconciled, diff, err := ReconcileGroup(current, ids(pairs[0], pairs[1], pairs[3], newcomer), 3)
+	require.NoError(t, err)
+	require.Len(t, diff.OnlyInA, 1)
+	require.True(t, diff.OnlyInA[0].Key.Equal(pairs[2].Public.Key))
+	require.Len(t, diff.OnlyInB, 1)
+	require.True(t, diff.OnlyInB[0].Key.Equal(newcomer.Public.Key))
+	require.Empty(t, diff.Reindexed)
+	require.Equal(t, 3, reconciled.Threshold)
+	require.Nil(t, reconciled.PublicKey)
+	require.Equal(t, leaving.Index, reconciled.Find(newcomer.Public).Index)
+	for _, p := range []*Pair{pairs[0], pairs[1], pairs[3]} {
+		require.Equal(t, current.Find(p.Public).Index, reconciled.Find(p.Public).Index)
+	}
+	require.NoError(t, reconciled.Valid())
+	require.True(t, current.PublicKey.Equal(dist))
+	require.Equal(t, 4, current.Len())
+
+	// invalid inputs
+	_, _, err = ReconcileGroup(current, ids(pairs[0], pairs[1]), 3)
+	require.Error(t, err)
+	_, _, err = ReconcileGroup(current, ids(pairs[0], pairs[1], pairs[0]), 2)
+	require.Error(t, err)
+	_, _, err = ReconcileGroup(nil, ids(pairs...), 3)
+	require.Error(t, err)
+}
@@ -0,0 +1,91 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/drand/drand/common/scheme"
+	kyber "github.com/drand/kyber"
+)
+
+// ErrSchemeMismatch is returned when loading a group holding nodes whose public
+// key is not a valid key under the scheme of the group, e.g. a key of a node
+// set up for another scheme.
+var ErrSchemeMismatch = errors.New("group: node keys not valid under the group scheme")
+
+// schemeKeyGroup returns the group of the public keys of the nodes under the
+// given scheme. All the schemes supported so far use the same key group.
+func schemeKeyGroup(string) kyber.Group {
+	return KeyGroup
+}
+
+// schemeKeyChecker collects the nodes of a group whose public key is not a
+// valid point of the key group of its scheme.
+type schemeKeyChecker struct {
+	schemeID string
+	group    kyber.Group
+	invalid  []string
+}
+
+func newSchemeKeyChecker(schemeID string) *schemeKeyChecker {
+	return &schemeKeyChecker{schemeID: schemeID, group: schemeKeyGroup(schemeID)}
+}
+
+// check records the node at position i if its key is not valid. It returns
+// false in that case.
+func (c *schemeKeyChecker) check(i int, n *NodeTOML) bool {
+	if n == nil || n.PublicTOML == nil {
+		return true
+	}
+	if _, err := StringToPoint(c.group, n.Key); err != nil {
+		c.invalid = append(c.invalid, fmt.Sprintf("node[%d] %s (%v)", i, n.Address, err))
+		return false
+	}
+	return true
+}
+
+// checkBinary records the node at position i if its binary encoded key is not
+// valid. It returns false in that case.
+func (c *schemeKeyChecker) checkBinary(i int, addr string, key []byte) bool {
+	if err := c.group.Point().UnmarshalBinary(key); err != nil {
+		c.invalid = append(c.invalid, fmt.Sprintf("node[%d] %s (%v)", i, addr, err))
+		return false
+	}
+	return true
+}
+
+func (c *schemeKeyChecker) err() error {
+	if len(c.invalid) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w %s: %s", ErrSchemeMismatch, c.schemeID, strings.Join(c.invalid, ", "))
+}
+
+// schemeID returns the ID of the scheme of the group, the default one if it
+// is not set.
+func (gt *GroupTOML) schemeID() string {
+	if gt.SchemeID == "" {
+		return scheme.DefaultSchemeID
+	}
+	return gt.SchemeID
+}
+
+// NodesByScheme partitions the identities of the nodes of the group by the ID
+// of their scheme. Identities don't carry a scheme identifier, and all the
+// supported schemes share the same key group so a key does not tell its
+// scheme either: every node is taken to run the scheme of the group, the
+// default one if it is not set, and the result holds a single cohort for a
+// non empty group. A migration controller can still rely on it once
+// identities declare their scheme.
+func (g *Group) NodesByScheme() map[string][]*Identity {
+	cohorts := make(map[string][]*Identity)
+	id := g.Scheme.ID
+	if id == "" {
+		id = scheme.DefaultSchemeID
+	}
+	for _, n := range g.Nodes {
+		cohorts[id] = append(cohorts[id], n.Identity)
+	}
+	return cohorts
+}
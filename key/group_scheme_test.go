@@ -0,0 +1,51 @@
+package key
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/drand/drand/common/scheme"
+	"github.com/drand/kyber/util/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupSchemeMismatch(t *testing.T) {
+	_, group := BatchIdentities(4)
+	gt := group.TOML().(*GroupTOML)
+	// a key of the signature group, as used by schemes with keys on G2
+	gt.Nodes[2].Key = PointToString(SigGroup.Point().Pick(random.New()))
+
+	var buf bytes.Buffer
+	require.NoError(t, toml.NewEncoder(&buf).Encode(gt))
+	filePath := path.Join(t.TempDir(), "group.toml")
+	require.NoError(t, os.WriteFile(filePath, buf.Bytes(), 0o600))
+
+	err := Load(filePath, new(Group))
+	require.ErrorIs(t, err, ErrSchemeMismatch)
+	require.Contains(t, err.Error(), "node[2] "+group.Nodes[2].Addr)
+	require.Contains(t, err.Error(), group.Scheme.ID)
+	for _, i := range []int{0, 1, 3} {
+		require.NotContains(t, err.Error(), group.Nodes[i].Addr)
+	}
+
+	_, err = DecodeGroup(&buf)
+	require.ErrorIs(t, err, ErrSchemeMismatch)
+	require.Contains(t, err.Error(), "node[2] "+group.Nodes[2].Addr)
+}
+
+func TestGroupNodesByScheme(t *testing.T) {
+	_, group := BatchIdentities(4)
+	cohorts := group.NodesByScheme()
+	require.Len(t, cohorts, 1)
+	require.Len(t, cohorts[scheme.DefaultSchemeID], 4)
+	for i, id := range cohorts[scheme.DefaultSchemeID] {
+		require.True(t, id.Equal(group.Nodes[i].Identity))
+	}
+
+	group.Scheme = scheme.GetSchemeFromEnv()
+	require.Len(t, group.NodesByScheme()[group.Scheme.ID], 4)
+	require.Empty(t, (&Group{}).NodesByScheme())
+}
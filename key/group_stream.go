@@ -0,0 +1,214 @@
+package key
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const publicKeyTableHeader = "[PublicKey]"
+const metadataTableHeader = "[Metadata]"
+
+// maxGroupLine is the maximum length of a line of a group file read by
+// DecodeGroup. The longest line is the one holding the coefficients of the
+// distributed public key.
+const maxGroupLine = 16 << 20
+
+// EncodeGroup writes the TOML representation of the group to w one node at a
+// time, so that the memory used does not depend on the number of nodes. It is
+// the encoding used by Encode and the stores for groups.
+func EncodeGroup(w io.Writer, g *Group) error {
+	bw := bufio.NewWriter(w)
+	if err := toml.NewEncoder(bw).Encode(g.tomlHeader()); err != nil {
+		return err
+	}
+	// a single buffer and encoder are reused for all the nodes
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	for _, n := range g.Nodes {
+		if err := writeNodeTable(bw, &buf, enc, n); err != nil {
+			return err
+		}
+	}
+	if err := writePublicKeyTable(bw, &buf, enc, g); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// writeNodeTable writes the [[Nodes]] table of the node, preceded by its
+// comment, using enc to encode it into buf.
+func writeNodeTable(bw *bufio.Writer, buf *bytes.Buffer, enc *toml.Encoder, n *Node) error {
+	buf.Reset()
+	if err := enc.Encode(n.TOML()); err != nil {
+		return err
+	}
+	bw.WriteString("\n")
+	if n.Comment != "" {
+		for _, c := range strings.Split(n.Comment, "\n") {
+			bw.WriteString(strings.TrimRight("# "+c, " ") + "\n")
+		}
+	}
+	bw.WriteString(nodesTableHeader + "\n")
+	writeIndented(bw, buf.Bytes())
+	return nil
+}
+
+// writePublicKeyTable writes the [PublicKey] table of the group, if it holds
+// a distributed public key, using enc to encode it into buf.
+func writePublicKeyTable(bw *bufio.Writer, buf *bytes.Buffer, enc *toml.Encoder, g *Group) error {
+	if g.PublicKey == nil {
+		return nil
+	}
+	buf.Reset()
+	if err := enc.Encode(g.PublicKey.TOML()); err != nil {
+		return err
+	}
+	bw.WriteString("\n" + publicKeyTableHeader + "\n")
+	writeIndented(bw, buf.Bytes())
+	return nil
+}
+
+// writeIndented writes the lines of the encoded table, indented as the TOML
+// encoder does for sub tables.
+func writeIndented(w *bufio.Writer, table []byte) {
+	for _, line := range strings.SplitAfter(string(table), "\n") {
+		if line != "" && line != "\n" {
+			w.WriteString("  ")
+		}
+		w.WriteString(line)
+	}
+}
+
+// DecodeGroup reads a group written by Encode or EncodeGroup from r, decoding
+// the nodes one at a time instead of decoding the whole file at once. It
+// expects the layout written by these functions: the fields of the group and
+// its [Metadata] table, then one [[Nodes]] table per node and the [PublicKey]
+// table. Include directives are rejected as with Decode.
+func DecodeGroup(r io.Reader) (*Group, error) {
+	d := &groupDecoder{group: new(Group), header: new(GroupTOML)}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxGroupLine)
+	for scanner.Scan() {
+		if err := d.line(scanner.Text()); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := d.flush(); err != nil {
+		return nil, err
+	}
+	if d.checker != nil {
+		if err := d.checker.err(); err != nil {
+			return nil, err
+		}
+	}
+	if len(d.header.Include) > 0 {
+		return nil, errors.New("group: include directives can only be resolved from a file")
+	}
+	if err := d.group.fromTOMLHeader(d.header); err != nil {
+		return nil, err
+	}
+	return d.group, nil
+}
+
+type groupSection int
+
+const (
+	headerSection groupSection = iota
+	nodeSection
+	publicKeySection
+	metadataSection
+)
+
+// groupDecoder accumulates the lines of the current section of a group file
+// and decodes it when the next one starts.
+type groupDecoder struct {
+	group    *Group
+	header   *GroupTOML
+	section  groupSection
+	lines    strings.Builder
+	comments []string
+	// comment of the node being read
+	comment string
+	// nodes is the number of nodes read so far
+	nodes int
+	// checker collects the nodes whose key is invalid under the scheme read
+	// from the header
+	checker *schemeKeyChecker
+}
+
+func (d *groupDecoder) line(line string) error {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case trimmed == nodesTableHeader || trimmed == publicKeyTableHeader || trimmed == metadataTableHeader:
+		if err := d.flush(); err != nil {
+			return err
+		}
+		switch trimmed {
+		case nodesTableHeader:
+			d.section = nodeSection
+			d.comment = strings.Join(d.comments, "\n")
+		case publicKeyTableHeader:
+			d.section = publicKeySection
+		default:
+			d.section = metadataSection
+		}
+		d.comments = nil
+		return nil
+	case strings.HasPrefix(trimmed, "["):
+		return fmt.Errorf("group: unexpected table %s", trimmed)
+	case strings.HasPrefix(trimmed, "#"):
+		d.comments = append(d.comments, strings.TrimPrefix(strings.TrimPrefix(trimmed, "#"), " "))
+	case trimmed != "":
+		d.comments = nil
+	}
+	d.lines.WriteString(line + "\n")
+	return nil
+}
+
+func (d *groupDecoder) flush() error {
+	data := d.lines.String()
+	d.lines.Reset()
+	switch d.section {
+	case headerSection:
+		_, err := toml.Decode(data, d.header)
+		return err
+	case nodeSection:
+		ntoml := new(NodeTOML)
+		if _, err := toml.Decode(data, ntoml); err != nil {
+			return err
+		}
+		ntoml.Comment = d.comment
+		if d.checker == nil {
+			d.checker = newSchemeKeyChecker(d.header.schemeID())
+		}
+		i := d.nodes
+		d.nodes++
+		if !d.checker.check(i, ntoml) {
+			return nil
+		}
+		n := new(Node)
+		if err := n.FromTOML(ntoml); err != nil {
+			return fmt.Errorf("group: unwrapping node[%d]: %v", i, err)
+		}
+		d.group.Nodes = append(d.group.Nodes, n)
+	case publicKeySection:
+		d.header.PublicKey = new(DistPublicTOML)
+		if _, err := toml.Decode(data, d.header.PublicKey); err != nil {
+			return err
+		}
+	case metadataSection:
+		if _, err := toml.Decode(data, &d.header.Metadata); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package key
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/drand/drand/common/scheme"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupStreamEncoding(t *testing.T) {
+	_, group := BatchIdentities(5)
+	group.Period = 30 * time.Second
+	group.GenesisTime = 1
+	group.Scheme = scheme.GetSchemeFromEnv()
+	group.ID = "test_beacon"
+
+	var standard, streamed bytes.Buffer
+	require.NoError(t, toml.NewEncoder(&standard).Encode(group.TOML()))
+	require.NoError(t, EncodeGroup(&streamed, group))
+	require.Equal(t, standard.String(), streamed.String())
+
+	group.Nodes[1].Comment = "run by org B\ncontact: ops@b.org"
+	streamed.Reset()
+	require.NoError(t, EncodeGroup(&streamed, group))
+	require.Contains(t, streamed.String(), "# run by org B\n# contact: ops@b.org\n"+nodesTableHeader)
+	decoded, err := DecodeGroup(&streamed)
+	require.NoError(t, err)
+	require.True(t, decoded.Equal(group))
+	require.Equal(t, group.Nodes[1].Comment, decoded.Nodes[1].Comment)
+
+	// files written by the store can be decoded
+	store := NewFileStore(t.TempDir(), "").(*fileStore)
+	require.NoError(t, store.SaveGroup(group))
+	data, err := os.ReadFile(store.groupFile)
+	require.NoError(t, err)
+	decoded, err = DecodeGroup(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.True(t, decoded.Equal(group))
+
+	_, err = DecodeGroup(strings.NewReader(standard.String() + "\n[Other]\n  a = 1\n"))
+	require.Error(t, err)
+}
+
+func largeGroup(n int) *Group {
+	ids := make([]*Identity, n)
+	for i := range ids {
+		ids[i] = &Identity{
+			Key:  KeyGroup.Point().Mul(KeyGroup.Scalar().SetInt64(int64(i+1)), nil),
+			Addr: fmt.Sprintf("node%d.example.com:443", i),
+			TLS:  true,
+		}
+	}
+	return NewGroup(ids, MinimumT(n), 1, 30*time.Second, 0, scheme.GetSchemeFromEnv(), "test_beacon")
+}
+
+// heapWriter discards what is written to it and records the largest heap size
+// seen while writing.
+type heapWriter struct {
+	peak uint64
+}
+
+func (h *heapWriter) Write(p []byte) (int, error) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	if m.HeapAlloc > h.peak {
+		h.peak = m.HeapAlloc
+	}
+	return len(p), nil
+}
+
+// BenchmarkGroupEncode compares the memory used to encode a large group: the
+// allocations and the peak heap used above the one before encoding.
+func BenchmarkGroupEncode(b *testing.B) {
+	group := largeGroup(5000)
+	for name, encode := range map[string]func(io.Writer) error{
+		"standard":  func(w io.Writer) error { return toml.NewEncoder(w).Encode(group.TOML()) },
+		"streaming": func(w io.Writer) error { return EncodeGroup(w, group) },
+	} {
+		encode := encode
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			var peak uint64
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+				var m runtime.MemStats
+				runtime.ReadMemStats(&m)
+				w := &heapWriter{peak: m.HeapAlloc}
+				if err := encode(w); err != nil {
+					b.Fatal(err)
+				}
+				if w.peak-m.HeapAlloc > peak {
+					peak = w.peak - m.HeapAlloc
+				}
+			}
+			b.ReportMetric(float64(peak), "peak-heap-B")
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package key
 
 import (
+	"encoding/hex"
+	"fmt"
 	"os"
+	"path"
 	"testing"
 	"time"
 
@@ -9,6 +12,7 @@ import (
 	"github.com/drand/drand/common/scheme"
 	"github.com/drand/drand/protobuf/drand"
 	"github.com/drand/kyber"
+	"github.com/drand/kyber/share"
 	"github.com/drand/kyber/util/random"
 	"github.com/stretchr/testify/require"
 )
@@ -180,3 +184,145 @@ func TestConvertGroup(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, received.Equal(group))
 }
+
+func TestGroupGenesisSeed(t *testing.T) {
+	_, group := BatchIdentities(5)
+	group.GenesisTime = time.Now().Unix()
+	group.Period = 3 * time.Second
+
+	// the seed does not depend on the distributed key
+	seed := group.ComputeGenesisSeed()
+	dpub := group.PublicKey
+	group.PublicKey = nil
+	require.Equal(t, seed, group.ComputeGenesisSeed())
+	require.Equal(t, seed, group.GetGenesisSeed())
+	group.PublicKey = dpub
+
+	groupPath := path.Join(t.TempDir(), "group.toml")
+	require.NoError(t, Save(groupPath, group, false))
+	loaded := new(Group)
+	require.NoError(t, Load(groupPath, loaded))
+	require.Equal(t, seed, loaded.GenesisSeed)
+
+	// a tampered seed is detected at load time
+	gtoml := group.TOML().(*GroupTOML)
+	gtoml.GenesisSeed = hex.EncodeToString([]byte("not the seed"))
+	require.Error(t, new(Group).FromTOML(gtoml))
+
+	// a reshared group keeps the seed of the original group
+	gtoml.TransitionTime = group.GenesisTime + 10
+	require.NoError(t, new(Group).FromTOML(gtoml))
+
+	// a seed computed lazily covers the distributed key embedded at the time,
+	// so it can't be checked once the key changed
+	group.GenesisSeed = nil
+	lazy := group.GetGenesisSeed()
+	group.PublicKey = &DistPublic{[]kyber.Point{KeyGroup.Point().Pick(random.New())}}
+	gtoml = group.TOML().(*GroupTOML)
+	require.False(t, gtoml.SetupSeed)
+	loaded = new(Group)
+	require.NoError(t, loaded.FromTOML(gtoml))
+	require.Equal(t, lazy, loaded.GenesisSeed)
+}
+
+func TestGroupNodeIndex(t *testing.T) {
+	// deterministic keys so the expected indexes can be pinned: the index of a
+	// member is the one of its share and must never change
+	ids := make([]*Identity, 5)
+	for i := range ids {
+		ids[i] = &Identity{
+			Key:  KeyGroup.Point().Mul(KeyGroup.Scalar().SetInt64(int64(i+1)), nil),
+			Addr: fmt.Sprintf("127.0.0.1:%d", 8000+i),
+		}
+	}
+	group := NewGroup(ids, 3, 1, 30*time.Second, 0, scheme.GetSchemeFromEnv(), "test_beacon")
+
+	golden := []int{1, 2, 0, 3, 4}
+	for i, id := range ids {
+		idx, err := group.NodeIndex(id.Key)
+		require.NoError(t, err)
+		require.Equal(t, golden[i], idx, "index of key %d", i+1)
+	}
+
+	// the DKG deals the shares using the same indexes
+	for _, n := range group.DKGNodes() {
+		idx, err := group.NodeIndex(n.Public)
+		require.NoError(t, err)
+		require.Equal(t, int(n.Index), idx)
+	}
+
+	_, err := group.NodeIndex(KeyGroup.Point().Mul(KeyGroup.Scalar().SetInt64(6), nil))
+	require.Error(t, err)
+}
+
+func TestGroupVerificationThreshold(t *testing.T) {
+	for _, c := range []struct{ n, thr int }{{3, 2}, {5, 3}, {7, 4}, {10, 6}, {4, 4}} {
+		_, group := BatchIdentities(c.n)
+		group.Threshold = c.thr
+		group.PublicKey = nil
+		require.Equal(t, c.thr, group.VerificationThreshold())
+
+		allowed := group.AllowedIndices()
+		require.Len(t, allowed, c.n)
+		for i, idx := range allowed {
+			require.Equal(t, i, idx)
+		}
+
+		// the threshold of the distributed key holds, even once the group is
+		// set to reshare with another threshold
+		secret := KeyGroup.Scalar().Pick(random.New())
+		shares, dist := dealSecret(c.n, c.thr, secret)
+		group.PublicKey = dist
+		group.Threshold = c.n
+		thr := group.VerificationThreshold()
+		require.Equal(t, c.thr, thr)
+
+		pri := make([]*share.PriShare, thr)
+		for i := range pri {
+			pri[i] = shares[allowed[c.n-1-i]].PrivateShare()
+		}
+		recovered, err := share.RecoverSecret(KeyGroup, pri, thr, c.n)
+		require.NoError(t, err)
+		require.True(t, recovered.Equal(secret))
+		_, err = share.RecoverSecret(KeyGroup, pri[:thr-1], thr, c.n)
+		require.Error(t, err, "n=%d thr=%d", c.n, c.thr)
+	}
+}
+
+func TestAssignIndices(t *testing.T) {
+	ids := make([]*Identity, 5)
+	for i := range ids {
+		ids[i] = &Identity{
+			Key:  KeyGroup.Point().Mul(KeyGroup.Scalar().SetInt64(int64(i+1)), nil),
+			Addr: fmt.Sprintf("node%d:443", i+1),
+		}
+	}
+	// golden order of the keys i·G, indexes starting at 0 as the DKG shares
+	golden := []string{"node3:443", "node1:443", "node2:443", "node4:443", "node5:443"}
+	check := func(list []*Identity) {
+		assigned := AssignIndices(list)
+		require.Len(t, assigned, len(golden))
+		for i, a := range assigned {
+			require.Equal(t, Index(i), a.Index)
+			require.Equal(t, golden[i], a.Identity.Addr)
+		}
+	}
+	check(ids)
+	reversed := []*Identity{ids[4], ids[3], ids[2], ids[1], ids[0]}
+	check(reversed)
+	require.Equal(t, "node5:443", reversed[0].Addr)
+	check([]*Identity{ids[2], ids[4], ids[0], ids[3], ids[1]})
+
+	// NewGroup uses the same assignment
+	group := NewGroup(reversed, 3, 1, time.Second, 0, scheme.GetSchemeFromEnv(), "")
+	for i, a := range AssignIndices(ids) {
+		require.Equal(t, a.Index, group.Nodes[i].Index)
+		require.True(t, a.Identity.Equal(group.Nodes[i].Identity))
+	}
+
+	// identical keys are ordered by address
+	twin := &Identity{Key: ids[0].Key, Addr: "node0:443"}
+	assigned := AssignIndices([]*Identity{ids[0], twin})
+	require.Equal(t, "node0:443", assigned[0].Identity.Addr)
+	require.Empty(t, AssignIndices(nil))
+}
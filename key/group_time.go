@@ -0,0 +1,176 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+)
+
+// maxRoundTime is the latest time TimeOfRound returns, for rounds too far in
+// the future to be represented. It is the latest time whose Unix seconds fit
+// the internal representation of a time.Time.
+var maxRoundTime = time.Unix(math.MaxInt64-62135596800, 999999999)
+
+// MinPeriod is the shortest period accepted by SetPeriod.
+const MinPeriod = time.Second
+
+// MaxPastGenesisRounds is the number of rounds a genesis time given to
+// SetGenesis can be in the past: a new beacon starting further behind would
+// have to catch up on as many rounds at once.
+const MaxPastGenesisRounds = 100
+
+// SetPeriod sets the period of the group after checking it is at least
+// MinPeriod and a whole number of seconds, since the period is exchanged and
+// hashed in seconds.
+func (g *Group) SetPeriod(d time.Duration) error {
+	switch {
+	case d < MinPeriod:
+		return fmt.Errorf("group: period %s shorter than the minimum %s", d, MinPeriod)
+	case d%time.Second != 0:
+		return fmt.Errorf("group: period %s is not a whole number of seconds", d)
+	case d/time.Second > math.MaxUint32:
+		return fmt.Errorf("group: period %s too long", d)
+	}
+	g.Period = d
+	return nil
+}
+
+// SetGenesis sets the genesis time of the group, truncated to the second. The
+// period must be set first: genesis times more than MaxPastGenesisRounds
+// periods before now, the current time, are rejected, as the beacon would
+// start that many rounds behind.
+func (g *Group) SetGenesis(t, now time.Time) error {
+	if g.Period <= 0 {
+		return errors.New("group: the period must be set before the genesis time")
+	}
+	t = t.Truncate(time.Second)
+	if earliest := now.Add(-MaxPastGenesisRounds * g.Period); t.Before(earliest) {
+		return fmt.Errorf("group: genesis time %s more than %d rounds in the past", t.UTC(), MaxPastGenesisRounds)
+	}
+	g.GenesisTime = t.Unix()
+	return nil
+}
+
+// CurrentRound returns the round the beacon chain of this group is at, at the
+// given time. It follows the chain convention: round 0 is the fixed genesis
+// block, round 1 is emitted at the genesis time and a new round is emitted
+// every period. Before the genesis time, it returns 0.
+func (g *Group) CurrentRound(now time.Time) (uint64, error) {
+	if g.Period <= 0 {
+		return 0, errors.New("group: period must be positive")
+	}
+	return g.RoundAt(now), nil
+}
+
+// RoundAt returns the round of the beacon chain of this group at the given
+// time, following the same convention as CurrentRound: round 1 starts at the
+// genesis time and a time within a period belongs to the round emitted at the
+// start of that period. It returns 0 before the genesis time or if the period
+// of the group is not positive. The arithmetic is exact, so that far times do
+// not overflow, saturating at math.MaxUint64.
+func (g *Group) RoundAt(t time.Time) uint64 {
+	if g.Period <= 0 || t.Before(time.Unix(g.GenesisTime, 0)) {
+		return 0
+	}
+	// nanoseconds since the genesis, on 128 bits
+	hi, lo := bits.Mul64(uint64(t.Unix()-g.GenesisTime), uint64(time.Second))
+	lo, carry := bits.Add64(lo, uint64(t.Nanosecond()), 0)
+	hi += carry
+	if hi >= uint64(g.Period) {
+		return math.MaxUint64
+	}
+	elapsed, _ := bits.Div64(hi, lo, uint64(g.Period))
+	if elapsed == math.MaxUint64 {
+		return elapsed
+	}
+	return elapsed + 1
+}
+
+// TimeOfRound returns the time at which the given round of the beacon chain of
+// this group is emitted. Round 0, the fixed genesis block, and round 1 both map
+// to the genesis time, and every round is one period after the previous one.
+// If the period of the group is not positive, it returns the genesis time.
+// Rounds too far in the future to be represented saturate at the latest time
+// a time.Time can hold.
+func (g *Group) TimeOfRound(round uint64) time.Time {
+	genesis := time.Unix(g.GenesisTime, 0)
+	if round <= 1 || g.Period <= 0 {
+		return genesis
+	}
+	hi, lo := bits.Mul64(round-1, uint64(g.Period))
+	if hi >= uint64(time.Second) {
+		return maxRoundTime
+	}
+	secs, nanos := bits.Div64(hi, lo, uint64(time.Second))
+	if secs > uint64(maxRoundTime.Unix()-g.GenesisTime) {
+		return maxRoundTime
+	}
+	return time.Unix(g.GenesisTime+int64(secs), int64(nanos))
+}
+
+// ClockSkew estimates by how much the given time is off, given that the chain
+// is known to be at the expected round. It returns zero if now falls within
+// the window of the expected round, a positive duration if now is past the
+// window, i.e. the local clock is ahead, and a negative one if it is behind.
+func (g *Group) ClockSkew(now time.Time, expectedRound uint64) (time.Duration, error) {
+	if g.Period <= 0 {
+		return 0, errors.New("group: period must be positive")
+	}
+	if expectedRound == 0 {
+		return 0, errors.New("group: round 0 has no emission time")
+	}
+	start := g.TimeOfRound(expectedRound)
+	end := start.Add(g.Period)
+	switch {
+	case now.Before(start):
+		return now.Sub(start), nil
+	case now.Before(end):
+		return 0, nil
+	default:
+		return now.Sub(end), nil
+	}
+}
+
+// RoundTimingError is returned by ValidateRoundTiming for a beacon received
+// outside of the tolerated window around the emission time of its round.
+type RoundTimingError struct {
+	Round      uint64
+	Expected   time.Time
+	ReceivedAt time.Time
+	// Offset is how far off the beacon was received: negative if it was
+	// received too early, positive if too late
+	Offset time.Duration
+}
+
+func (e *RoundTimingError) Error() string {
+	offset, when := e.Offset, "late"
+	if offset < 0 {
+		offset, when = -offset, "early"
+	}
+	return fmt.Sprintf("group: round %d received %s too %s, expected at %s",
+		e.Round, offset, when, e.Expected.UTC().Format(time.RFC3339))
+}
+
+// ValidateRoundTiming checks the beacon of the given round was received within
+// tolerance of its emission time according to the schedule of the group, see
+// TimeOfRound. It returns a *RoundTimingError telling how far off it was
+// otherwise, which points to a node emitting off-schedule or to a skewed
+// clock.
+func (g *Group) ValidateRoundTiming(round uint64, receivedAt time.Time, tolerance time.Duration) error {
+	switch {
+	case g.Period <= 0:
+		return errors.New("group: period must be positive")
+	case round == 0:
+		return errors.New("group: round 0 has no emission time")
+	case tolerance < 0:
+		return errors.New("group: tolerance must not be negative")
+	}
+	expected := g.TimeOfRound(round)
+	offset := receivedAt.Sub(expected)
+	if offset < -tolerance || offset > tolerance {
+		return &RoundTimingError{Round: round, Expected: expected, ReceivedAt: receivedAt, Offset: offset}
+	}
+	return nil
+}
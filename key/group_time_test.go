@@ -0,0 +1,150 @@
+package key
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupCurrentRound(t *testing.T) {
+	genesis := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := &Group{GenesisTime: genesis.Unix(), Period: 3 * time.Second}
+
+	for _, tv := range []struct {
+		now   time.Time
+		round uint64
+	}{
+		{genesis.Add(-time.Second), 0},
+		{genesis, 1},
+		{genesis.Add(2999 * time.Millisecond), 1},
+		{genesis.Add(3 * time.Second), 2},
+		{genesis.Add(30 * time.Second), 11},
+	} {
+		round, err := g.CurrentRound(tv.now)
+		require.NoError(t, err)
+		require.Equal(t, tv.round, round, tv.now.Sub(genesis))
+	}
+
+	g.Period = 0
+	_, err := g.CurrentRound(genesis)
+	require.Error(t, err)
+}
+
+func TestGroupClockSkew(t *testing.T) {
+	genesis := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := &Group{GenesisTime: genesis.Unix(), Period: 3 * time.Second}
+
+	// round 2 is between genesis+3s and genesis+6s
+	skew, err := g.ClockSkew(genesis.Add(4*time.Second), 2)
+	require.NoError(t, err)
+	require.Zero(t, skew)
+	skew, err = g.ClockSkew(genesis.Add(10*time.Second), 2)
+	require.NoError(t, err)
+	require.Equal(t, 4*time.Second, skew)
+	skew, err = g.ClockSkew(genesis.Add(time.Second), 2)
+	require.NoError(t, err)
+	require.Equal(t, -2*time.Second, skew)
+
+	_, err = g.ClockSkew(genesis, 0)
+	require.Error(t, err)
+}
+
+func TestGroupRoundAtTimeOfRound(t *testing.T) {
+	genesis := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := &Group{GenesisTime: genesis.Unix(), Period: 30 * time.Second}
+
+	for _, tv := range []struct {
+		at    time.Time
+		round uint64
+	}{
+		{genesis.Add(-time.Nanosecond), 0},
+		{genesis, 1},
+		{genesis.Add(30*time.Second - time.Nanosecond), 1},
+		{genesis.Add(30 * time.Second), 2},
+		{genesis.Add(45 * time.Second), 2},
+		{genesis.Add(24 * time.Hour), 2881},
+		// further than a time.Duration can hold
+		{genesis.AddDate(1000, 0, 0), 365242*2880 + 1},
+	} {
+		require.Equal(t, tv.round, g.RoundAt(tv.at), tv.at)
+	}
+
+	require.True(t, genesis.Equal(g.TimeOfRound(0)))
+	require.True(t, genesis.Equal(g.TimeOfRound(1)))
+	require.True(t, genesis.Add(30*time.Second).Equal(g.TimeOfRound(2)))
+	require.True(t, genesis.Add(24*time.Hour).Equal(g.TimeOfRound(2881)))
+	large := uint64(1) << 40
+	require.Equal(t, g.GenesisTime+int64(large-1)*30, g.TimeOfRound(large).Unix())
+	require.Equal(t, large, g.RoundAt(g.TimeOfRound(large)))
+	require.Equal(t, large-1, g.RoundAt(g.TimeOfRound(large).Add(-time.Nanosecond)))
+	require.True(t, maxRoundTime.Equal(g.TimeOfRound(math.MaxUint64)))
+	require.Equal(t, uint64(maxRoundTime.Unix()-g.GenesisTime)/30+1, g.RoundAt(maxRoundTime))
+
+	// sub-second periods
+	g.Period = 250 * time.Millisecond
+	require.Equal(t, uint64(5), g.RoundAt(genesis.Add(time.Second)))
+	require.True(t, genesis.Add(1250*time.Millisecond).Equal(g.TimeOfRound(6)))
+	g.Period = time.Nanosecond
+	require.Equal(t, uint64(math.MaxUint64), g.RoundAt(maxRoundTime))
+
+	g.Period = 0
+	require.Zero(t, g.RoundAt(genesis.Add(time.Hour)))
+	require.True(t, genesis.Equal(g.TimeOfRound(10)))
+}
+
+func TestGroupSetPeriodGenesis(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	g := new(Group)
+	require.Error(t, g.SetGenesis(now, now))
+	for _, d := range []time.Duration{0, -time.Second, time.Millisecond, 999 * time.Millisecond, 1500 * time.Millisecond, (math.MaxUint32 + 1) * time.Second} {
+		require.Error(t, g.SetPeriod(d), d)
+		require.Zero(t, g.Period)
+	}
+	require.NoError(t, g.SetPeriod(MinPeriod))
+	require.NoError(t, g.SetPeriod(30*time.Second))
+	require.Equal(t, 30*time.Second, g.Period)
+
+	future := now.Add(time.Hour)
+	require.NoError(t, g.SetGenesis(future, now))
+	require.Equal(t, future.Unix(), g.GenesisTime)
+	// a genesis time slightly in the past is fine
+	require.NoError(t, g.SetGenesis(now.Add(-10*g.Period), now))
+	require.Equal(t, uint64(11), g.RoundAt(now))
+
+	// a beacon created this far behind would start millions of rounds late
+	require.Error(t, g.SetGenesis(now.Add(-(MaxPastGenesisRounds+1)*g.Period), now))
+	require.Error(t, g.SetGenesis(time.Unix(0, 0), now))
+	require.Equal(t, now.Add(-10*g.Period).Unix(), g.GenesisTime)
+}
+
+func TestGroupValidateRoundTiming(t *testing.T) {
+	genesis := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := &Group{GenesisTime: genesis.Unix(), Period: 30 * time.Second}
+	emitted := genesis.Add(9 * 30 * time.Second)
+
+	// on time, at the edges of the tolerance
+	for _, at := range []time.Time{emitted, emitted.Add(2 * time.Second), emitted.Add(-2 * time.Second)} {
+		require.NoError(t, g.ValidateRoundTiming(10, at, 2*time.Second))
+	}
+
+	err := g.ValidateRoundTiming(10, emitted.Add(-5*time.Second), 2*time.Second)
+	var timing *RoundTimingError
+	require.ErrorAs(t, err, &timing)
+	require.Equal(t, -5*time.Second, timing.Offset)
+	require.True(t, timing.Expected.Equal(emitted))
+	require.Equal(t, "group: round 10 received 5s too early, expected at 2020-01-01T00:04:30Z", err.Error())
+
+	err = g.ValidateRoundTiming(10, emitted.Add(90*time.Second), 2*time.Second)
+	require.ErrorAs(t, err, &timing)
+	require.Equal(t, 90*time.Second, timing.Offset)
+	require.Contains(t, err.Error(), "1m30s too late")
+	// a beacon of another round
+	require.Error(t, g.ValidateRoundTiming(11, emitted, 2*time.Second))
+
+	require.Error(t, g.ValidateRoundTiming(0, genesis, time.Second))
+	require.Error(t, g.ValidateRoundTiming(1, genesis, -time.Second))
+	g.Period = 0
+	require.Error(t, g.ValidateRoundTiming(1, genesis, time.Second))
+}
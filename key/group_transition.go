@@ -0,0 +1,118 @@
+package key
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidTransition is returned when a group can't follow another one
+// through the given kind of transition.
+var ErrInvalidTransition = errors.New("group: invalid transition")
+
+// ErrReshareQuorum is returned when too few nodes of a group are available to
+// reshare it.
+var ErrReshareQuorum = errors.New("group: reshare quorum not met")
+
+// TransitionKind tells the kind of resharing moving a network from a group to
+// the next one.
+type TransitionKind int
+
+const (
+	// TransitionRefresh renews the shares of the same nodes, e.g. to make the
+	// shares leaked before the refresh useless.
+	TransitionRefresh TransitionKind = iota
+	// TransitionReshare hands the shares to another set of nodes, e.g. to add
+	// or remove nodes.
+	TransitionReshare
+)
+
+func (k TransitionKind) String() string {
+	switch k {
+	case TransitionRefresh:
+		return "refresh"
+	case TransitionReshare:
+		return "reshare"
+	default:
+		return "unknown"
+	}
+}
+
+// ValidateTransition checks the next group can follow the old one through the
+// given kind of transition. Both kinds keep the chain: the next group has the
+// genesis seed and the distributed public key of the old one, since the
+// beacons of the chain, before and after the transition, are all verified
+// with that key. Only the commitments of the other coefficients change. A
+// refresh must keep the nodes, matched by public key, and the threshold. A
+// reshare must change the nodes while keeping at least a threshold of the old
+// nodes, which are needed to deal the new shares. The errors wrap
+// ErrInvalidTransition.
+func ValidateTransition(old, next *Group, kind TransitionKind) error {
+	if err := next.Valid(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTransition, err)
+	}
+	if old.PublicKey == nil || next.PublicKey == nil {
+		return fmt.Errorf("%w: both groups must hold a distributed public key", ErrInvalidTransition)
+	}
+	if !bytes.Equal(old.GetGenesisSeed(), next.GetGenesisSeed()) {
+		return fmt.Errorf("%w: genesis seed changed from %x to %x",
+			ErrInvalidTransition, old.GetGenesisSeed(), next.GetGenesisSeed())
+	}
+	if !old.PublicKey.Key().Equal(next.PublicKey.Key()) {
+		return fmt.Errorf("%w: distributed public key changed from %s to %s",
+			ErrInvalidTransition, old.PublicKey.Key(), next.PublicKey.Key())
+	}
+
+	common := len(old.Intersection(next))
+	sameNodes := common == old.Len() && common == next.Len()
+	switch kind {
+	case TransitionRefresh:
+		if !sameNodes {
+			return fmt.Errorf("%w: a refresh keeps the %d nodes, %d of them are in the new group of %d nodes",
+				ErrInvalidTransition, old.Len(), common, next.Len())
+		}
+		if old.Threshold != next.Threshold {
+			return fmt.Errorf("%w: a refresh keeps the threshold, changed from %d to %d",
+				ErrInvalidTransition, old.Threshold, next.Threshold)
+		}
+	case TransitionReshare:
+		if sameNodes {
+			return fmt.Errorf("%w: a reshare changes the nodes, use a refresh to keep them", ErrInvalidTransition)
+		}
+		if common < old.ReshareQuorum() {
+			return fmt.Errorf("%w: only %d of the old nodes are in the new group, the old threshold is %d",
+				ErrInvalidTransition, common, old.Threshold)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %d", ErrInvalidTransition, kind)
+	}
+	return nil
+}
+
+// ReshareQuorum returns the minimum number of nodes of the group that must take
+// part in a reshare, or a refresh, as dealers of the new shares: the threshold
+// of the group, as fewer shares can't reconstruct the secret being reshared.
+func (g *Group) ReshareQuorum() int {
+	return g.Threshold
+}
+
+// CanReshareWith checks enough nodes of the group, see ReshareQuorum, are among
+// the available identities to start a reshare. Identities are matched to the
+// nodes by public key, whatever their address; those of other nodes and the
+// duplicates don't count. It returns an error wrapping ErrReshareQuorum telling
+// how many nodes are missing otherwise.
+func (g *Group) CanReshareWith(available []*Identity) error {
+	keys := g.nodeKeys()
+	present := make(map[string]bool, len(available))
+	for _, id := range available {
+		key := id.Key.String()
+		if _, ok := keys[key]; ok {
+			present[key] = true
+		}
+	}
+	if quorum := g.ReshareQuorum(); len(present) < quorum {
+		return fmt.Errorf("%w: %d of the %d nodes available, %d needed, %d missing",
+			ErrReshareQuorum, len(present), g.Len(), quorum, quorum-len(present))
+	}
+	return nil
+}
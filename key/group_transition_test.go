@@ -0,0 +1,86 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/drand/kyber/util/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupValidateTransition(t *testing.T) {
+	secret := KeyGroup.Scalar().Pick(random.New())
+	_, old := BatchIdentities(5)
+	old.Threshold = 3
+	_, old.PublicKey = dealSecret(5, 3, secret)
+	old.GenesisSeed = old.ComputeGenesisSeed()
+	withNodes := func(nodes []*Node, threshold int) *Group {
+		g := &Group{Threshold: threshold, GenesisSeed: old.GenesisSeed}
+		for i, n := range nodes {
+			c := n.copy()
+			c.Index = Index(i)
+			g.Nodes = append(g.Nodes, c)
+		}
+		return g
+	}
+	fresh := func(addr string) *Node {
+		return &Node{Identity: NewTLSKeyPair(addr).Public}
+	}
+
+	// a refresh keeps the nodes and the key, with new commitments
+	refreshed := withNodes(old.Nodes, 3)
+	_, refreshed.PublicKey = dealSecret(5, 3, secret)
+	require.False(t, refreshed.PublicKey.Equal(old.PublicKey))
+	require.NoError(t, ValidateTransition(old, refreshed, TransitionRefresh))
+	err := ValidateTransition(old, refreshed, TransitionReshare)
+	require.ErrorIs(t, err, ErrInvalidTransition)
+
+	_, refreshed.PublicKey = dealSecret(5, 3, KeyGroup.Scalar().Pick(random.New()))
+	err = ValidateTransition(old, refreshed, TransitionRefresh)
+	require.ErrorIs(t, err, ErrInvalidTransition)
+	require.Contains(t, err.Error(), "distributed public key changed")
+
+	// a reshare changes the nodes, keeping a quorum of the old ones
+	nodes := append([]*Node{}, old.Nodes[:3]...)
+	nodes = append(nodes, fresh("127.0.0.1:9000"), fresh("127.0.0.1:9001"), fresh("127.0.0.1:9002"))
+	reshared := withNodes(nodes, 4)
+	_, reshared.PublicKey = dealSecret(6, 4, secret)
+	require.NoError(t, ValidateTransition(old, reshared, TransitionReshare))
+	err = ValidateTransition(old, reshared, TransitionRefresh)
+	require.ErrorIs(t, err, ErrInvalidTransition)
+	require.Contains(t, err.Error(), "3 of them")
+
+	reshared.GenesisSeed = []byte("another chain")
+	require.ErrorIs(t, ValidateTransition(old, reshared, TransitionReshare), ErrInvalidTransition)
+
+	// without a quorum of old nodes, the new shares can't be dealt
+	nodes = append([]*Node{}, old.Nodes[:2]...)
+	nodes = append(nodes, fresh("127.0.0.1:9000"), fresh("127.0.0.1:9001"))
+	lost := withNodes(nodes, 3)
+	_, lost.PublicKey = dealSecret(4, 3, secret)
+	err = ValidateTransition(old, lost, TransitionReshare)
+	require.ErrorIs(t, err, ErrInvalidTransition)
+	require.Contains(t, err.Error(), "only 2 of the old nodes")
+}
+
+func TestGroupCanReshareWith(t *testing.T) {
+	_, group := BatchIdentities(5)
+	group.Threshold = 3
+	require.Equal(t, 3, group.ReshareQuorum())
+	ids := make([]*Identity, group.Len())
+	for i, n := range group.Nodes {
+		ids[i] = n.Identity
+	}
+	outsiders, _ := BatchIdentities(2)
+
+	// exactly enough, whatever the addresses
+	moved := *ids[2]
+	moved.Addr = "10.0.0.1:4444"
+	require.NoError(t, group.CanReshareWith([]*Identity{ids[0], ids[4], &moved}))
+	require.NoError(t, group.CanReshareWith(ids))
+
+	// one short, outsiders and duplicates not counted
+	err := group.CanReshareWith([]*Identity{ids[0], ids[4], ids[4], outsiders[0].Public, outsiders[1].Public})
+	require.ErrorIs(t, err, ErrReshareQuorum)
+	require.Contains(t, err.Error(), "1 missing")
+	require.ErrorIs(t, group.CanReshareWith(nil), ErrReshareQuorum)
+}
@@ -0,0 +1,29 @@
+package key
+
+import "fmt"
+
+// placeholderAddress is the address given to the nodes of a public view. The
+// .invalid domain is reserved, so the placeholders can never be dialed.
+const placeholderAddress = "node%d.invalid:0"
+
+// MinimalPublicView returns a copy of the group holding only what verifiers
+// need, for publishing it where the network topology must not leak. The copy
+// keeps the threshold, period, catchup period, scheme, beacon ID, genesis
+// time, genesis seed, transition time, the distributed public key, or its
+// hash for a group referencing it, and the public key and index of every
+// node. It strips the address of the nodes, replaced by a placeholder such as
+// "node1.invalid:0", their TLS setting, their signature, which covers the
+// address, their comment and the metadata of the group. The hash of the group
+// only covers retained fields, so the view has the hash of the group. The
+// receiver is never modified.
+func (g *Group) MinimalPublicView() *Group {
+	view := g.Copy()
+	for _, n := range view.Nodes {
+		n.Addr = fmt.Sprintf(placeholderAddress, n.Index)
+		n.TLS = false
+		n.Signature = nil
+		n.Comment = ""
+	}
+	view.metadata = nil
+	return view
+}
@@ -0,0 +1,46 @@
+package key
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupMinimalPublicView(t *testing.T) {
+	_, group := BatchIdentities(3)
+	_, group.PublicKey = dealShares(3, 2)
+	group.Nodes[0].Comment = "operated by us"
+	group.SetMetadata("owner", "ops")
+	addresses := make([]string, 0, group.Len())
+	for _, n := range group.Nodes {
+		addresses = append(addresses, n.Addr)
+	}
+
+	view := group.MinimalPublicView()
+	require.NoError(t, view.Valid())
+	require.Equal(t, group.Hash(), view.Hash())
+	require.True(t, view.PublicKey.Equal(group.PublicKey))
+	require.Equal(t, group.GetGenesisSeed(), view.GetGenesisSeed())
+	require.Equal(t, group.Threshold, view.Threshold)
+	require.Equal(t, group.Period, view.Period)
+	require.Empty(t, view.Metadata())
+	for i, n := range view.Nodes {
+		require.True(t, n.Key.Equal(group.Nodes[i].Key))
+		require.Equal(t, group.Nodes[i].Index, n.Index)
+		require.True(t, strings.HasSuffix(n.Addr, ".invalid:0"))
+		require.False(t, n.TLS)
+		require.Nil(t, n.Signature)
+		require.Empty(t, n.Comment)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, view))
+	for _, addr := range addresses {
+		require.NotContains(t, buf.String(), addr)
+	}
+	// the group is left untouched
+	require.Equal(t, addresses[0], group.Nodes[0].Addr)
+	require.Equal(t, "operated by us", group.Nodes[0].Comment)
+}
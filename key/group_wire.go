@@ -0,0 +1,275 @@
+package key
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/drand/drand/common/scheme"
+	kyber "github.com/drand/kyber"
+)
+
+// groupWireVersion is the version byte starting the wire encoding of a group.
+// Decoders refuse the versions they don't know, so that the encoding can
+// evolve without being misread.
+const groupWireVersion = 1
+
+// ErrGroupWire is returned when decoding a group from a malformed wire
+// encoding, or one of an unknown version.
+var ErrGroupWire = errors.New("group: invalid wire encoding")
+
+// MarshalWire returns the compact binary encoding of the group used to send it
+// from node to node, much smaller than the TOML one for large groups. After a
+// version byte, it holds the threshold and timing parameters, the genesis
+// seed, the scheme and beacon IDs, the nodes and the distributed public key,
+// integers as varints and byte strings prefixed with their length. The
+// comments and metadata of the group, local to each node, are not encoded.
+func (g *Group) MarshalWire() ([]byte, error) {
+	if g.PublicKey == nil && g.distPublicHash != nil {
+		return nil, errors.New("group: can't encode a group referencing its distributed public key")
+	}
+	w := &wireWriter{buf: []byte{groupWireVersion}}
+	w.uvarint(uint64(g.Threshold))
+	w.varint(int64(g.Period))
+	w.varint(int64(g.CatchupPeriod))
+	w.varint(g.GenesisTime)
+	w.varint(g.TransitionTime)
+	w.bytes(g.GenesisSeed)
+	w.bytes([]byte(g.Scheme.ID))
+	w.bytes([]byte(g.ID))
+	w.uvarint(uint64(len(g.Nodes)))
+	for _, n := range g.Nodes {
+		key, err := n.Key.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("group: encoding the key of %s: %v", n.Addr, err)
+		}
+		w.uvarint(uint64(n.Index))
+		w.bytes(key)
+		w.bytes([]byte(n.Addr))
+		if n.TLS {
+			w.buf = append(w.buf, 1)
+		} else {
+			w.buf = append(w.buf, 0)
+		}
+		w.bytes(n.Signature)
+	}
+	var coefficients []kyber.Point
+	if g.PublicKey != nil {
+		coefficients = g.PublicKey.Coefficients
+	}
+	w.uvarint(uint64(len(coefficients)))
+	for i, c := range coefficients {
+		b, err := c.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("group: encoding coefficient %d: %v", i, err)
+		}
+		w.bytes(b)
+	}
+	return w.buf, nil
+}
+
+// UnmarshalGroupWire decodes a group encoded by MarshalWire. It checks the
+// encoding is well formed, the keys of the nodes valid for the scheme of the
+// group and the threshold valid, and returns an error wrapping ErrGroupWire
+// otherwise. Unlike the decoding of a group file, the genesis seed is not
+// checked, as the encoding does not tell whether it was derived from the
+// group at its setup.
+func UnmarshalGroupWire(data []byte) (*Group, error) {
+	g, err := unmarshalGroupWire(&wireReader{buf: data})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGroupWire, err)
+	}
+	return g, nil
+}
+
+func unmarshalGroupWire(r *wireReader) (*Group, error) {
+	version, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	if version != groupWireVersion {
+		return nil, fmt.Errorf("unknown version %d", version)
+	}
+	g := new(Group)
+	threshold := r.uvarint()
+	g.Period = time.Duration(r.varint())
+	g.CatchupPeriod = time.Duration(r.varint())
+	g.GenesisTime = r.varint()
+	g.TransitionTime = r.varint()
+	if seed := r.bytes(); len(seed) > 0 {
+		g.GenesisSeed = append([]byte(nil), seed...)
+	}
+	schemeID := string(r.bytes())
+	g.ID = string(r.bytes())
+	if r.err != nil {
+		return nil, r.err
+	}
+	if g.Scheme, err = scheme.GetSchemeByIDWithDefault(schemeID); err != nil {
+		return nil, err
+	}
+
+	checker := newSchemeKeyChecker(g.Scheme.ID)
+	count := r.count()
+	g.Nodes = make([]*Node, 0, count)
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		index := r.uvarint()
+		key := r.bytes()
+		addr := string(r.bytes())
+		tls, _ := r.byte()
+		signature := r.bytes()
+		if r.err != nil {
+			break
+		}
+		if index > uint64(^Index(0)) {
+			return nil, fmt.Errorf("node %d: index %d out of range", i, index)
+		}
+		if tls > 1 {
+			return nil, fmt.Errorf("node %d: invalid TLS flag %d", i, tls)
+		}
+		if !checker.checkBinary(int(i), addr, key) {
+			continue
+		}
+		point := KeyGroup.Point()
+		if err := point.UnmarshalBinary(key); err != nil {
+			return nil, fmt.Errorf("node %d: invalid key: %v", i, err)
+		}
+		id := &Identity{Key: point, Addr: addr, TLS: tls == 1}
+		if len(signature) > 0 {
+			id.Signature = append([]byte(nil), signature...)
+		}
+		g.Nodes = append(g.Nodes, &Node{Identity: id, Index: Index(index)})
+	}
+
+	count = r.count()
+	coefficients := make([]kyber.Point, 0, count)
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		c := KeyGroup.Point()
+		if err := c.UnmarshalBinary(r.bytes()); r.err == nil && err != nil {
+			return nil, fmt.Errorf("%w: coefficient %d: %v", ErrInvalidPoint, i, err)
+		}
+		if r.err == nil && c.Equal(KeyGroup.Point().Null()) {
+			return nil, fmt.Errorf("%w: coefficient %d is the identity", ErrInvalidPoint, i)
+		}
+		coefficients = append(coefficients, c)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if err := checker.err(); err != nil {
+		return nil, err
+	}
+	if len(r.buf) > 0 {
+		return nil, fmt.Errorf("%d trailing bytes", len(r.buf))
+	}
+	if len(coefficients) > 0 {
+		g.PublicKey = &DistPublic{Coefficients: coefficients}
+	}
+
+	if threshold > uint64(len(g.Nodes)) {
+		return nil, errors.New("threshold greater than number of participants")
+	}
+	g.Threshold = int(threshold)
+	if g.Threshold < MinimumT(g.Len()) {
+		return nil, fmt.Errorf("threshold %d below minimum %d", g.Threshold, MinimumT(g.Len()))
+	}
+	return g, nil
+}
+
+// wireWriter appends the fields of a wire encoding to its buffer.
+type wireWriter struct {
+	buf []byte
+}
+
+func (w *wireWriter) uvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	w.buf = append(w.buf, b[:binary.PutUvarint(b[:], v)]...)
+}
+
+func (w *wireWriter) varint(v int64) {
+	var b [binary.MaxVarintLen64]byte
+	w.buf = append(w.buf, b[:binary.PutVarint(b[:], v)]...)
+}
+
+func (w *wireWriter) bytes(b []byte) {
+	w.uvarint(uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+// wireReader consumes the fields of a wire encoding. Once a read fails, err is
+// set and the following reads return zero values, so that callers only check
+// err after a series of reads.
+type wireReader struct {
+	buf []byte
+	err error
+}
+
+func (r *wireReader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf(format, args...)
+	}
+	r.buf = nil
+}
+
+func (r *wireReader) byte() (byte, error) {
+	if r.err == nil && len(r.buf) == 0 {
+		r.fail("unexpected end of data")
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	b := r.buf[0]
+	r.buf = r.buf[1:]
+	return b, nil
+}
+
+func (r *wireReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.fail("invalid varint")
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *wireReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.buf)
+	if n <= 0 {
+		r.fail("invalid varint")
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *wireReader) bytes() []byte {
+	n := r.uvarint()
+	if r.err != nil {
+		return nil
+	}
+	if n > uint64(len(r.buf)) {
+		r.fail("length %d exceeds the %d remaining bytes", n, len(r.buf))
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+// count reads a number of entries, each taking at least one byte, so that a
+// corrupt count can't make the decoder allocate more than the data holds.
+func (r *wireReader) count() uint64 {
+	n := r.uvarint()
+	if r.err == nil && n > uint64(len(r.buf)) {
+		r.fail("count %d exceeds the %d remaining bytes", n, len(r.buf))
+		return 0
+	}
+	return n
+}
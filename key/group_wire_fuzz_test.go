@@ -0,0 +1,39 @@
+//go:build go1.18
+
+package key
+
+import (
+	"errors"
+	"testing"
+)
+
+func FuzzUnmarshalGroupWire(f *testing.F) {
+	data, err := wireTestGroup().MarshalWire()
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Add([]byte{groupWireVersion})
+	f.Add([]byte{})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		g, err := UnmarshalGroupWire(data)
+		if err != nil {
+			if !errors.Is(err, ErrGroupWire) {
+				t.Fatalf("error not wrapping ErrGroupWire: %v", err)
+			}
+			return
+		}
+		// a decoded group encodes back to a group equal to it
+		again, err := g.MarshalWire()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := UnmarshalGroupWire(again)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.Equal(g) {
+			t.Fatal("group differs once encoded again")
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package key
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/drand/drand/common/scheme"
+	"github.com/stretchr/testify/require"
+)
+
+func wireTestGroup() *Group {
+	_, group := BatchIdentities(5)
+	_, dist := dealShares(5, group.Threshold)
+	group.PublicKey = dist
+	group.Period = 3e9
+	group.CatchupPeriod = 1e9
+	group.GenesisTime = 1600000000
+	group.Scheme = scheme.GetSchemeFromEnv()
+	group.ID = "wire"
+	group.Nodes[1].TLS = false
+	group.GenesisSeed = group.ComputeGenesisSeed()
+	return group
+}
+
+func TestGroupWire(t *testing.T) {
+	group := wireTestGroup()
+	data, err := group.MarshalWire()
+	require.NoError(t, err)
+	require.Equal(t, byte(groupWireVersion), data[0])
+
+	decoded, err := UnmarshalGroupWire(data)
+	require.NoError(t, err)
+	require.True(t, decoded.Equal(group))
+	require.Equal(t, group.Hash(), decoded.Hash())
+	require.Equal(t, group.CatchupPeriod, decoded.CatchupPeriod)
+	require.Equal(t, group.Scheme.ID, decoded.Scheme.ID)
+	require.False(t, decoded.Nodes[1].TLS)
+	require.NoError(t, decoded.Nodes[0].ValidSignature())
+	again, err := decoded.MarshalWire()
+	require.NoError(t, err)
+	require.Equal(t, data, again)
+
+	_, err = group.WithDistPublicReference().MarshalWire()
+	require.Error(t, err)
+
+	// before the DKG
+	group.PublicKey = nil
+	group.GenesisSeed = nil
+	data, err = group.MarshalWire()
+	require.NoError(t, err)
+	decoded, err = UnmarshalGroupWire(data)
+	require.NoError(t, err)
+	require.Nil(t, decoded.PublicKey)
+	require.True(t, decoded.Equal(group))
+
+	// every truncation is rejected
+	for i := range data {
+		_, err := UnmarshalGroupWire(data[:i])
+		require.ErrorIs(t, err, ErrGroupWire, i)
+	}
+	_, err = UnmarshalGroupWire(append(data, 0))
+	require.ErrorIs(t, err, ErrGroupWire)
+	// the keys must be valid for the scheme of the group
+	key, err := group.Nodes[0].Key.MarshalBinary()
+	require.NoError(t, err)
+	invalid := append([]byte{}, data...)
+	at := bytes.Index(invalid, key)
+	require.Positive(t, at)
+	invalid[at] ^= 0xff
+	_, err = UnmarshalGroupWire(invalid)
+	require.ErrorIs(t, err, ErrGroupWire)
+	require.Contains(t, err.Error(), ErrSchemeMismatch.Error())
+	future := append([]byte{groupWireVersion + 1}, data[1:]...)
+	_, err = UnmarshalGroupWire(future)
+	require.ErrorIs(t, err, ErrGroupWire)
+	require.Contains(t, err.Error(), "unknown version")
+}
+
+// BenchmarkGroupWireSize compares the size of the wire and TOML encodings of
+// a large group.
+func BenchmarkGroupWireSize(b *testing.B) {
+	group := largeGroup(1000)
+	var plain bytes.Buffer
+	if err := toml.NewEncoder(&plain).Encode(group.TOML()); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	var data []byte
+	for i := 0; i < b.N; i++ {
+		var err error
+		if data, err = group.MarshalWire(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(len(data)), "wire-B")
+	b.ReportMetric(float64(plain.Len()), "toml-B")
+}
@@ -0,0 +1,40 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/drand/drand/common/scheme"
+	kyber "github.com/drand/kyber"
+)
+
+// ErrIncompatibleIdentity is returned when two nodes can't take part in the
+// same network as their keys are on different curves.
+var ErrIncompatibleIdentity = errors.New("identity: incompatible curve")
+
+// CompatibleWith checks the identity and the other one can run a DKG together,
+// e.g. when a peer connects, so that incompatible peers are rejected at once
+// instead of failing later while decoding their points. Identities don't carry
+// a scheme identifier, so their compatibility is derived from their keys: both
+// must be points of the key group of the default scheme, which all the
+// supported schemes share. It returns an error wrapping
+// ErrIncompatibleIdentity describing the mismatch otherwise.
+func (i *Identity) CompatibleWith(other *Identity) error {
+	if other == nil || i.Key == nil || other.Key == nil {
+		return fmt.Errorf("%w: missing public key", ErrIncompatibleIdentity)
+	}
+	group := schemeKeyGroup(scheme.DefaultSchemeID)
+	for _, id := range []*Identity{i, other} {
+		if !isPointOf(group, id.Key) {
+			return fmt.Errorf("%w: key of %s is a %s point, expected a %s point", ErrIncompatibleIdentity,
+				id.Addr, reflect.TypeOf(id.Key), group)
+		}
+	}
+	return nil
+}
+
+// isPointOf returns true if p is a point of the group g.
+func isPointOf(g kyber.Group, p kyber.Point) bool {
+	return reflect.TypeOf(p) == reflect.TypeOf(g.Point())
+}
@@ -0,0 +1,25 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/drand/kyber/util/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentityCompatibleWith(t *testing.T) {
+	a := NewKeyPair("127.0.0.1:8000").Public
+	b := NewTLSKeyPair("127.0.0.1:8001").Public
+	require.NoError(t, a.CompatibleWith(b))
+	require.NoError(t, b.CompatibleWith(a))
+
+	// a peer whose key is on the signature curve
+	foreign := &Identity{Key: SigGroup.Point().Pick(random.New()), Addr: "127.0.0.1:8002"}
+	err := a.CompatibleWith(foreign)
+	require.ErrorIs(t, err, ErrIncompatibleIdentity)
+	require.Contains(t, err.Error(), "127.0.0.1:8002")
+	require.ErrorIs(t, foreign.CompatibleWith(a), ErrIncompatibleIdentity)
+
+	require.ErrorIs(t, a.CompatibleWith(nil), ErrIncompatibleIdentity)
+	require.ErrorIs(t, a.CompatibleWith(&Identity{Addr: "127.0.0.1:8003"}), ErrIncompatibleIdentity)
+}
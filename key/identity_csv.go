@@ -0,0 +1,74 @@
+package key
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ParseIdentitiesCSV reads the identities of nodes from CSV rows of the form
+// "address,base64pubkey", as exported from a spreadsheet when collecting the
+// members of a group. An optional third column sets whether the node is
+// reachable over TLS, which it is by default as with Init. A first row whose
+// first column is "address" is skipped as a header. Whitespace around the
+// fields, and empty lines, are ignored. The address must be "host:port" and the
+// key a valid point of the key group. Errors report the line of the first
+// malformed row.
+//
+// The returned identities are not signed; they can be passed to NewGroup.
+func ParseIdentitiesCSV(r io.Reader) ([]*Identity, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	var ids []*Identity
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return ids, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("identities csv: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+		if first && strings.EqualFold(record[0], "address") {
+			continue
+		}
+		id, err := parseIdentityRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("identities csv: line %d: %w", line, err)
+		}
+		ids = append(ids, id)
+	}
+}
+
+func parseIdentityRecord(record []string) (*Identity, error) {
+	if len(record) < 2 || len(record) > 3 {
+		return nil, fmt.Errorf("expected 2 or 3 columns, got %d", len(record))
+	}
+	if _, _, err := net.SplitHostPort(record[0]); err != nil {
+		return nil, fmt.Errorf("invalid address %q: %v", record[0], err)
+	}
+	buff, err := base64.StdEncoding.DecodeString(record[1])
+	if err != nil {
+		return nil, fmt.Errorf("decoding public key: %v", err)
+	}
+	key := KeyGroup.Point()
+	if err := key.UnmarshalBinary(buff); err != nil {
+		return nil, fmt.Errorf("invalid public key: %v", err)
+	}
+	id := &Identity{Key: key, Addr: record[0], TLS: true}
+	if len(record) == 3 {
+		if id.TLS, err = strconv.ParseBool(record[2]); err != nil {
+			return nil, fmt.Errorf("invalid tls flag %q", record[2])
+		}
+	}
+	return id, nil
+}
@@ -0,0 +1,47 @@
+package key
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIdentitiesCSV(t *testing.T) {
+	pairs, _ := BatchIdentities(3)
+	keys := make([]string, len(pairs))
+	for i, p := range pairs {
+		buff, err := p.Public.Key.MarshalBinary()
+		require.NoError(t, err)
+		keys[i] = base64.StdEncoding.EncodeToString(buff)
+	}
+	input := fmt.Sprintf("Address, Key\n  a.drand.sh:443 , %s \n\nb.drand.sh:443,%s,false\n10.0.0.1:4444,%s\n", keys[0], keys[1], keys[2])
+	ids, err := ParseIdentitiesCSV(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, ids, 3)
+	for i, addr := range []string{"a.drand.sh:443", "b.drand.sh:443", "10.0.0.1:4444"} {
+		require.Equal(t, addr, ids[i].Addr)
+		require.True(t, ids[i].Key.Equal(pairs[i].Public.Key))
+	}
+	require.True(t, ids[0].TLS)
+	require.False(t, ids[1].TLS)
+
+	// no header
+	ids, err = ParseIdentitiesCSV(strings.NewReader("a.drand.sh:443," + keys[0]))
+	require.NoError(t, err)
+	require.Len(t, ids, 1)
+
+	for input, line := range map[string]string{
+		"a.drand.sh:443," + keys[0] + "\nb.drand.sh," + keys[1]:              "line 2: invalid address",
+		"address,key\na.drand.sh:443,notbase64!":                             "line 2: decoding public key",
+		"a.drand.sh:443,AAAA":                                                "line 1: invalid public key",
+		"a.drand.sh:443\n":                                                   "line 1: expected 2 or 3 columns",
+		"a.drand.sh:443," + keys[0] + "\n\nb.drand.sh:443," + keys[1] + ",x": "line 3: invalid tls flag",
+	} {
+		_, err := ParseIdentitiesCSV(strings.NewReader(input))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), line)
+	}
+}
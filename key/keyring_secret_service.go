@@ -0,0 +1,73 @@
+package key
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// secretToolCommand is the command line tool of libsecret, shipped with most
+// Linux desktops, used to reach the Secret Service.
+const secretToolCommand = "secret-tool"
+
+// NewSecretServiceStore returns a SecretStore keeping the secrets in the Secret
+// Service of the user session on Linux, e.g. GNOME Keyring or KWallet. It uses
+// the secret-tool command of libsecret, which must be installed, and does not
+// require any D-Bus binding.
+func NewSecretServiceStore() SecretStore {
+	return &secretServiceStore{command: secretToolCommand}
+}
+
+type secretServiceStore struct {
+	command string
+}
+
+// run runs the command with the given arguments, followed by the attributes
+// identifying the secret.
+func (s *secretServiceStore) run(stdin []byte, name string, args ...string) (stdout, stderr []byte, err error) {
+	args = append(args, "application", "drand", "name", name)
+	cmd := exec.Command(s.command, args...)
+	var out, errOut bytes.Buffer
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	err = cmd.Run()
+	return out.Bytes(), errOut.Bytes(), err
+}
+
+// SetSecret stores the secret encoded in base64, as secret-tool handles text.
+func (s *secretServiceStore) SetSecret(name string, secret []byte) error {
+	encoded := []byte(base64.StdEncoding.EncodeToString(secret))
+	if _, stderr, err := s.run(encoded, name, "store", "--label=drand "+name); err != nil {
+		return fmt.Errorf("secret service: storing %s: %v: %s", name, err, strings.TrimSpace(string(stderr)))
+	}
+	return nil
+}
+
+// GetSecret returns ErrAbsent when secret-tool fails without any output, which
+// is how it reports a missing secret.
+func (s *secretServiceStore) GetSecret(name string) ([]byte, error) {
+	stdout, stderr, err := s.run(nil, name, "lookup")
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(stdout) == 0 && len(stderr) == 0 {
+		return nil, fmt.Errorf("%w: secret %s", ErrAbsent, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("secret service: looking up %s: %v: %s", name, err, strings.TrimSpace(string(stderr)))
+	}
+	secret, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(stdout)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: decoding secret %s: %v", ErrStoreFile, name, err)
+	}
+	return secret, nil
+}
+
+func (s *secretServiceStore) DeleteSecret(name string) error {
+	if _, stderr, err := s.run(nil, name, "clear"); err != nil {
+		return fmt.Errorf("secret service: clearing %s: %v: %s", name, err, strings.TrimSpace(string(stderr)))
+	}
+	return nil
+}
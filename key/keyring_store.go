@@ -0,0 +1,172 @@
+package key
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// SecretStore keeps secrets in a secret storage of the operating system, such
+// as the macOS Keychain or the Secret Service of Linux desktops, so that they
+// benefit from the protection of the system instead of lying in files.
+type SecretStore interface {
+	// SetSecret stores the secret under the given name, replacing the secret
+	// stored under that name if any.
+	SetSecret(name string, secret []byte) error
+	// GetSecret returns the secret stored under the given name. It returns an
+	// error wrapping ErrAbsent if there is none.
+	GetSecret(name string) ([]byte, error)
+	// DeleteSecret deletes the secret stored under the given name, if any.
+	DeleteSecret(name string) error
+}
+
+// keyringStore is a Store keeping the private objects, the private key and
+// the share, in a SecretStore and delegating the public ones to a file store.
+type keyringStore struct {
+	files   *fileStore
+	secrets SecretStore
+	// codecs are applied to the secrets, as the file store applies its own
+	// to the public objects, see WithCodec
+	codecs []Codec
+}
+
+// NewKeyringStore returns a store keeping the private key and the share of the
+// node in the given secret store, under names derived from the beacon id. The
+// public objects are kept by a file store created with the given base folder,
+// beacon id and options, as NewFileStore does.
+func NewKeyringStore(secrets SecretStore, baseFolder, beaconID string, opts ...StoreOption) Store {
+	return &keyringStore{
+		files:   NewFileStore(baseFolder, beaconID, opts...).(*fileStore),
+		secrets: secrets,
+	}
+}
+
+// secretName returns the name of the secret holding the object of that kind.
+func (k *keyringStore) secretName(kind StoreKind) string {
+	return "drand/" + k.files.beaconID + "/" + kind.String()
+}
+
+// saveSecret stores the encoded object, refusing to replace an existing one
+// unless allowed by the options.
+func (k *keyringStore) saveSecret(kind StoreKind, t Tomler, opts []SaveOption) error {
+	if err := k.files.closed.check(); err != nil {
+		return err
+	}
+	name := k.secretName(kind)
+	if !newSaveConfig(false, opts).overwrite {
+		if _, err := k.secrets.GetSecret(name); err == nil {
+			return fmt.Errorf("%w: secret %s", ErrExists, name)
+		} else if !errors.Is(err, ErrAbsent) {
+			return err
+		}
+	}
+	var buf bytes.Buffer
+	if err := encodeWith(&buf, t, k.codecs); err != nil {
+		return err
+	}
+	return k.secrets.SetSecret(name, buf.Bytes())
+}
+
+func (k *keyringStore) loadSecret(kind StoreKind, t Tomler) error {
+	if err := k.files.closed.check(); err != nil {
+		return err
+	}
+	secret, err := k.secrets.GetSecret(k.secretName(kind))
+	if err != nil {
+		return err
+	}
+	data, err := decodeWith(bytes.NewReader(secret), k.codecs, DefaultMaxFileSize)
+	if err != nil {
+		return err
+	}
+	if err := Decode(bytes.NewReader(data), t); err != nil {
+		return fmt.Errorf("%w: decoding secret %s: %v", ErrStoreFile, k.secretName(kind), err)
+	}
+	return nil
+}
+
+// WithCodec returns a store whose secrets and files also go through the codec.
+func (k *keyringStore) WithCodec(c Codec) Store {
+	derived := *k
+	derived.files = k.files.WithCodec(c).(*fileStore)
+	derived.codecs = append([]Codec{c}, k.codecs...)
+	return &derived
+}
+
+func (k *keyringStore) SaveKeyPair(p *Pair, opts ...SaveOption) error {
+	if err := k.saveSecret(KeyPairKind, p, opts); err != nil {
+		return err
+	}
+	return k.files.save(k.files.publicKeyFile, p.Public, false)
+}
+
+func (k *keyringStore) LoadKeyPair() (*Pair, error) {
+	p := new(Pair)
+	if err := k.loadSecret(KeyPairKind, p); err != nil {
+		return nil, err
+	}
+	return p, k.files.load(k.files.publicKeyFile, p.Public)
+}
+
+func (k *keyringStore) SaveShare(share *Share, opts ...SaveOption) error {
+	return k.saveSecret(ShareKind, share, opts)
+}
+
+func (k *keyringStore) LoadShare() (*Share, error) {
+	s := new(Share)
+	return s, k.loadSecret(ShareKind, s)
+}
+
+func (k *keyringStore) SaveDistPublic(d *DistPublic, opts ...SaveOption) error {
+	return k.files.SaveDistPublic(d, opts...)
+}
+
+func (k *keyringStore) LoadDistPublic() (*DistPublic, error) {
+	return k.files.LoadDistPublic()
+}
+
+// SaveDKGResult saves the share then the distributed public key. Both can't be
+// replaced atomically as with the file store, so the previous share is
+// restored if the distributed public key can't be saved.
+func (k *keyringStore) SaveDKGResult(share *Share, d *DistPublic, opts ...SaveOption) error {
+	name := k.secretName(ShareKind)
+	previous, err := k.secrets.GetSecret(name)
+	if err != nil && !errors.Is(err, ErrAbsent) {
+		return err
+	}
+	if err := k.SaveShare(share, opts...); err != nil {
+		return err
+	}
+	if err := k.files.SaveDistPublic(d, WithOverwrite(true)); err != nil {
+		if previous == nil {
+			_ = k.secrets.DeleteSecret(name)
+		} else {
+			_ = k.secrets.SetSecret(name, previous)
+		}
+		return err
+	}
+	return nil
+}
+
+func (k *keyringStore) SaveGroup(g *Group, opts ...SaveOption) error {
+	return k.files.SaveGroup(g, opts...)
+}
+
+func (k *keyringStore) LoadGroup() (*Group, error) {
+	return k.files.LoadGroup()
+}
+
+func (k *keyringStore) CompareAndSwapGroup(expected, newGroup *Group) error {
+	return k.files.CompareAndSwapGroup(expected, newGroup)
+}
+
+// Reset deletes the share along with the objects deleted by the file store.
+func (k *keyringStore) Reset(opts ...ResetOption) error {
+	if err := k.files.closed.check(); err != nil {
+		return err
+	}
+	if err := k.secrets.DeleteSecret(k.secretName(ShareKind)); err != nil {
+		return fmt.Errorf("drand: err deleting share secret: %w", err)
+	}
+	return k.files.Reset(opts...)
+}
@@ -0,0 +1,137 @@
+package key
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memorySecrets is a SecretStore keeping the secrets in memory.
+type memorySecrets struct {
+	sync.Mutex
+	secrets map[string][]byte
+}
+
+func (m *memorySecrets) SetSecret(name string, secret []byte) error {
+	m.Lock()
+	defer m.Unlock()
+	if m.secrets == nil {
+		m.secrets = make(map[string][]byte)
+	}
+	m.secrets[name] = append([]byte{}, secret...)
+	return nil
+}
+
+func (m *memorySecrets) GetSecret(name string) ([]byte, error) {
+	m.Lock()
+	defer m.Unlock()
+	secret, ok := m.secrets[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrAbsent, name)
+	}
+	return secret, nil
+}
+
+func (m *memorySecrets) DeleteSecret(name string) error {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.secrets, name)
+	return nil
+}
+
+func testKeyringStore(t *testing.T, secrets SecretStore) {
+	base := t.TempDir()
+	store := NewKeyringStore(secrets, base, "")
+	_, err := store.LoadKeyPair()
+	require.ErrorIs(t, err, ErrAbsent)
+	_, err = store.LoadShare()
+	require.ErrorIs(t, err, ErrAbsent)
+
+	pairs, group := BatchIdentities(3)
+	shares, dist := dealShares(3, group.Threshold)
+	group.PublicKey = dist
+	require.NoError(t, store.SaveKeyPair(pairs[0]))
+	require.ErrorIs(t, store.SaveKeyPair(pairs[1]), ErrExists)
+	require.NoError(t, store.SaveGroup(group))
+	require.NoError(t, store.SaveDKGResult(shares[0], dist))
+
+	pair, err := store.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, pair.Equal(pairs[0]))
+	share, err := store.LoadShare()
+	require.NoError(t, err)
+	require.True(t, share.Equal(shares[0]))
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(group))
+
+	// no private file is written
+	f := store.(*keyringStore).files
+	for _, file := range []string{f.privateKeyFile, f.shareFile} {
+		_, err := os.Stat(file)
+		require.ErrorIs(t, err, os.ErrNotExist)
+	}
+
+	// the share is restored if the distributed key can't be saved
+	reshared, next := dealShares(3, group.Threshold)
+	groups := filepath.Dir(f.distKeyFile)
+	require.NoError(t, os.Rename(groups, groups+".saved"))
+	require.NoError(t, os.WriteFile(groups, nil, 0600))
+	require.Error(t, store.SaveDKGResult(reshared[0], next, WithOverwrite(true)))
+	share, err = store.LoadShare()
+	require.NoError(t, err)
+	require.True(t, share.Equal(shares[0]))
+	require.NoError(t, os.Remove(groups))
+	require.NoError(t, os.Rename(groups+".saved", groups))
+
+	require.NoError(t, store.Reset())
+	_, err = store.LoadShare()
+	require.ErrorIs(t, err, ErrAbsent)
+	_, err = store.LoadKeyPair()
+	require.NoError(t, err)
+}
+
+func TestKeyringStore(t *testing.T) {
+	testKeyringStore(t, new(memorySecrets))
+}
+
+// fakeSecretTool is a script behaving as secret-tool, keeping each secret in a
+// file of the folder.
+const fakeSecretTool = `#!/bin/sh
+cmd=$1
+shift
+[ "$cmd" = store ] && shift
+file="%s/$(echo "$4" | tr / _)"
+case $cmd in
+store) cat > "$file" ;;
+lookup) [ -f "$file" ] || exit 1; cat "$file" ;;
+clear) rm -f "$file" ;;
+esac
+`
+
+func TestSecretServiceStore(t *testing.T) {
+	dir := t.TempDir()
+	tool := filepath.Join(dir, "secret-tool")
+	require.NoError(t, os.WriteFile(tool, []byte(fmt.Sprintf(fakeSecretTool, dir)), 0o700))
+	secrets := &secretServiceStore{command: tool}
+
+	_, err := secrets.GetSecret("drand/test")
+	require.ErrorIs(t, err, ErrAbsent)
+	require.NoError(t, secrets.SetSecret("drand/test", []byte{0, 1, 2, '\n'}))
+	secret, err := secrets.GetSecret("drand/test")
+	require.NoError(t, err)
+	require.Equal(t, []byte{0, 1, 2, '\n'}, secret)
+	require.NoError(t, secrets.DeleteSecret("drand/test"))
+	_, err = secrets.GetSecret("drand/test")
+	require.ErrorIs(t, err, ErrAbsent)
+
+	testKeyringStore(t, secrets)
+
+	_, err = (&secretServiceStore{command: filepath.Join(dir, "missing")}).GetSecret("drand/test")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrAbsent)
+}
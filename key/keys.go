@@ -2,10 +2,12 @@ package key
 
 import (
 	"bytes"
+	"crypto/cipher"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"net"
+	"time"
 
 	kyber "github.com/drand/kyber"
 	"github.com/drand/kyber/share"
@@ -20,6 +22,9 @@ import (
 type Pair struct {
 	Key    kyber.Scalar
 	Public *Identity
+	// NotAfter is the date after which the key pair must not be used anymore
+	// and must be rotated. The zero value means it never expires.
+	NotAfter time.Time
 }
 
 // Identity holds the corresponding public key of a Private. It also includes a
@@ -86,7 +91,13 @@ func (p *Pair) SelfSign() {
 // NewKeyPair returns a freshly created private / public key pair. The group is
 // decided by the group variable by default.
 func NewKeyPair(address string) *Pair {
-	key := KeyGroup.Scalar().Pick(random.New())
+	return newKeyPair(address, random.New())
+}
+
+// newKeyPair returns a self-signed key pair whose private key is picked from
+// the given random stream.
+func newKeyPair(address string, rand cipher.Stream) *Pair {
+	key := KeyGroup.Scalar().Pick(rand)
 	pubKey := KeyGroup.Point().Mul(key, nil)
 	pub := &Identity{
 		Key:  pubKey,
@@ -111,7 +122,14 @@ func NewTLSKeyPair(address string) *Pair {
 
 // PairTOML is the TOML-able version of a private key
 type PairTOML struct {
-	Key string
+	Key      string
+	NotAfter *time.Time `toml:",omitempty"`
+	// Address, TLS and Signature of the public identity, only set when it is
+	// kept along with the private key instead of in a separate file. The
+	// public key itself is derived from the private one.
+	Address   string `toml:",omitempty"`
+	TLS       bool   `toml:",omitempty"`
+	Signature string `toml:",omitempty"`
 }
 
 // PublicTOML is the TOML-able version of a public key
@@ -124,8 +142,12 @@ type PublicTOML struct {
 
 // TOML returns a struct that can be marshaled using a TOML-encoding library
 func (p *Pair) TOML() interface{} {
-	hexKey := ScalarToString(p.Key)
-	return &PairTOML{hexKey}
+	ptoml := &PairTOML{Key: ScalarToString(p.Key)}
+	if !p.NotAfter.IsZero() {
+		notAfter := p.NotAfter
+		ptoml.NotAfter = &notAfter
+	}
+	return ptoml
 }
 
 // FromTOML constructs the private key from an unmarshalled structure from TOML
@@ -138,14 +160,49 @@ func (p *Pair) FromTOML(i interface{}) error {
 	var err error
 	p.Key, err = StringToScalar(KeyGroup, ptoml.Key)
 	p.Public = new(Identity)
+	p.NotAfter = time.Time{}
+	if ptoml.NotAfter != nil {
+		p.NotAfter = *ptoml.NotAfter
+	}
+	if err != nil || ptoml.Address == "" {
+		return err
+	}
+	p.Public.Key = KeyGroup.Point().Mul(p.Key, nil)
+	p.Public.Addr = ptoml.Address
+	p.Public.TLS = ptoml.TLS
+	if ptoml.Signature != "" {
+		p.Public.Signature, err = hex.DecodeString(ptoml.Signature)
+	}
 	return err
 }
 
+// pairWithIdentity encodes the key pair with its public identity in the same
+// file.
+type pairWithIdentity struct {
+	*Pair
+}
+
+func (p pairWithIdentity) TOML() interface{} {
+	ptoml := p.Pair.TOML().(*PairTOML)
+	ptoml.Address = p.Public.Addr
+	ptoml.TLS = p.Public.TLS
+	ptoml.Signature = hex.EncodeToString(p.Public.Signature)
+	return ptoml
+}
+
 // TOMLValue returns an empty TOML-compatible interface value
 func (p *Pair) TOMLValue() interface{} {
 	return &PairTOML{}
 }
 
+// Equal indicates if two key pairs are equal
+func (p *Pair) Equal(p2 *Pair) bool {
+	if !p.Key.Equal(p2.Key) || !p.NotAfter.Equal(p2.NotAfter) {
+		return false
+	}
+	return p.Public.Equal(p2.Public)
+}
+
 // FromTOML loads reads the TOML description of the public key
 func (i *Identity) FromTOML(t interface{}) error {
 	ptoml, ok := t.(*PublicTOML)
@@ -251,6 +308,109 @@ func (s *Share) Public() *DistPublic {
 	return &DistPublic{s.Commits}
 }
 
+// ErrShareSlot is returned when a share is not the one expected for the index
+// it claims in the group.
+var ErrShareSlot = errors.New("share does not match this node's slot in the group")
+
+// ErrShareGroupKey is returned when a share commits to another distributed key
+// than the one of the group.
+var ErrShareGroupKey = errors.New("share reconstructs a different group key")
+
+// ErrShareThreshold is returned when a share was dealt under another threshold
+// than the one of the group.
+var ErrShareThreshold = errors.New("share threshold differs from the group's")
+
+// Threshold returns the threshold the share was dealt under, the number of
+// commitments of the polynomial it was dealt from.
+func (s *Share) Threshold() int {
+	return len(s.Commits)
+}
+
+// VerifyThreshold checks the share was dealt under the threshold of the group,
+// as a share of another configuration makes the group recover its signatures
+// from the wrong number of partials. It returns an error wrapping
+// ErrShareThreshold with both thresholds otherwise.
+func (s *Share) VerifyThreshold(group *Group) error {
+	if s.Threshold() != group.Threshold {
+		return fmt.Errorf("%w: share dealt with threshold %d, the group has threshold %d",
+			ErrShareThreshold, s.Threshold(), group.Threshold)
+	}
+	return nil
+}
+
+// VerifyAgainst checks the share is consistent with the given distributed
+// public key and group, as expected after a resharing refreshing the shares
+// of a group while keeping its distributed key: the share must commit to the
+// same distributed key, and its private part must be the evaluation, at the
+// index of a node of the group, of the refreshed polynomial it commits to.
+func (s *Share) VerifyAgainst(dp *DistPublic, group *Group) error {
+	if s.Share == nil {
+		return fmt.Errorf("%w: missing private share", ErrShareSlot)
+	}
+	if len(s.Commits) == 0 || dp == nil || len(dp.Coefficients) == 0 {
+		return fmt.Errorf("%w: missing commitments", ErrShareGroupKey)
+	}
+	if !s.Commits[0].Equal(dp.Key()) {
+		return ErrShareGroupKey
+	}
+	if group.PublicKey != nil && !group.PublicKey.Key().Equal(dp.Key()) {
+		return fmt.Errorf("%w: the group holds another distributed key", ErrShareGroupKey)
+	}
+	if group.Node(Index(s.Share.I)) == nil {
+		return fmt.Errorf("%w: no node at index %d", ErrShareSlot, s.Share.I)
+	}
+	expected := s.PubPoly().Eval(s.Share.I).V
+	if !KeyGroup.Point().Mul(s.Share.V, nil).Equal(expected) {
+		return fmt.Errorf("%w: share at index %d does not match the commitments", ErrShareSlot, s.Share.I)
+	}
+	return nil
+}
+
+// ErrShareCommitments is returned when a share is not consistent with the
+// commitments published by its dealer.
+var ErrShareCommitments = errors.New("share does not match the published commitments")
+
+// VerifyCommitments checks the share against the commitments of the polynomial
+// it was dealt from, as in Feldman's verifiable secret sharing: the commitment
+// polynomial evaluated at the index of the share must be g^share, g being the
+// base point of the key group. This lets the recipient of a share detect a
+// malicious dealer. It returns an error wrapping ErrShareCommitments if the
+// check fails.
+func (s *Share) VerifyCommitments(commitments []kyber.Point) error {
+	if len(commitments) == 0 {
+		return fmt.Errorf("%w: no commitments", ErrShareCommitments)
+	}
+	if s.Share == nil || s.Share.V == nil {
+		return fmt.Errorf("%w: no private share", ErrShareCommitments)
+	}
+	expected := share.NewPubPoly(KeyGroup, KeyGroup.Point().Base(), commitments).Eval(s.Share.I).V
+	if !KeyGroup.Point().Mul(s.Share.V, nil).Equal(expected) {
+		return fmt.Errorf("%w: share at index %d", ErrShareCommitments, s.Share.I)
+	}
+	return nil
+}
+
+// RecoverIdentity returns the identity of the node holding the share, as
+// listed in the group, so that a node which lost its public key file but kept
+// its share and group can rebuild it. The share must match its own
+// commitments, and the distributed key of the group if it holds one; the node
+// is then the one of the group at the index of the share. It returns an error
+// wrapping ErrShareSlot if the group has no node at that index.
+func RecoverIdentity(s *Share, group *Group) (*Identity, error) {
+	if err := s.VerifyCommitments(s.Commits); err != nil {
+		return nil, err
+	}
+	if group.PublicKey != nil && !group.PublicKey.Key().Equal(s.Commits[0]) {
+		return nil, fmt.Errorf("%w: the group holds another distributed key", ErrShareGroupKey)
+	}
+	node := group.Node(Index(s.Share.I))
+	if node == nil {
+		return nil, fmt.Errorf("%w: no node at index %d", ErrShareSlot, s.Share.I)
+	}
+	id := *node.Identity
+	return &id, nil
+}
+
 // TOML returns a TOML-compatible version of this share
 func (s *Share) TOML() interface{} {
 	dtoml := &ShareTOML{}
@@ -291,6 +451,22 @@ func (s *Share) TOMLValue() interface{} {
 	return &ShareTOML{}
 }
 
+// Equal indicates if two shares are equal
+func (s *Share) Equal(s2 *Share) bool {
+	if s.Share.I != s2.Share.I || !s.Share.V.Equal(s2.Share.V) {
+		return false
+	}
+	if len(s.Commits) != len(s2.Commits) {
+		return false
+	}
+	for i := range s.Commits {
+		if !s.Commits[i].Equal(s2.Commits[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 // ShareTOML is the TOML representation of a dkg.DistKeyShare
 type ShareTOML struct {
 	// index of the share.
@@ -356,15 +532,24 @@ func (d *DistPublic) FromTOML(i interface{}) error {
 	points := make([]kyber.Point, len(dtoml.Coefficients))
 	var err error
 	for i, s := range dtoml.Coefficients {
+		// decoding checks the point is on the curve and in the prime order
+		// subgroup
 		points[i], err = StringToPoint(KeyGroup, s)
 		if err != nil {
-			return err
+			return fmt.Errorf("%w: coefficient %d: %v", ErrInvalidPoint, i, err)
+		}
+		if points[i].Equal(KeyGroup.Point().Null()) {
+			return fmt.Errorf("%w: coefficient %d is the identity", ErrInvalidPoint, i)
 		}
 	}
 	d.Coefficients = points
 	return nil
 }
 
+// ErrInvalidPoint is returned when a distributed public key holds a point that
+// is not a valid element of the key group.
+var ErrInvalidPoint = errors.New("invalid point in distributed public key")
+
 // TOMLValue returns an empty TOML-compatible dist public interface
 func (d *DistPublic) TOMLValue() interface{} {
 	return &DistPublicTOML{}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"os"
+	"sort"
 	"strconv"
 	"testing"
 
@@ -97,6 +98,29 @@ func TestKeyDistributedPublic(t *testing.T) {
 	require.NoError(t, check.FromTOML(checkTOML))
 }
 
+func TestKeyDistributedPublicInvalidPoint(t *testing.T) {
+	valid, _ := KeyGroup.Point().Pick(random.New()).MarshalBinary()
+	compressed := func(x byte) []byte {
+		b := make([]byte, len(valid))
+		b[0] = 0x80
+		b[len(b)-1] = x
+		return b
+	}
+	identity := make([]byte, len(valid))
+	identity[0] = 0xc0
+
+	for name, b := range map[string][]byte{
+		"not on curve":        compressed(1),
+		"not in the subgroup": compressed(0),
+		"identity":            identity,
+		"truncated":           valid[:len(valid)-1],
+	} {
+		coeffs := []string{hex.EncodeToString(valid), hex.EncodeToString(b)}
+		err := new(DistPublic).FromTOML(&DistPublicTOML{Coefficients: coeffs})
+		require.ErrorIs(t, err, ErrInvalidPoint, name)
+	}
+}
+
 func TestKeyGroup(t *testing.T) {
 	n := 5
 	_, group := BatchIdentities(n)
@@ -134,11 +158,19 @@ func BatchIdentities(n int) ([]*Pair, *Group) {
 	startPort := 8000
 	startAddr := "127.0.0.1:"
 	privs := make([]*Pair, n)
-	pubs := make([]*Node, n)
 	for i := 0; i < n; i++ {
 		port := strconv.Itoa(startPort + i)
 		addr := startAddr + port
 		privs[i] = NewTLSKeyPair(addr)
+	}
+	// the indexes follow the canonical assignment, see AssignIndices
+	sort.Slice(privs, func(i, j int) bool {
+		a, _ := privs[i].Public.Key.MarshalBinary()
+		b, _ := privs[j].Public.Key.MarshalBinary()
+		return bytes.Compare(a, b) < 0
+	})
+	pubs := make([]*Node, n)
+	for i := 0; i < n; i++ {
 		pubs[i] = &Node{
 			Index:    uint32(i),
 			Identity: privs[i].Public,
@@ -153,3 +185,95 @@ func BatchIdentities(n int) ([]*Pair, *Group) {
 	}
 	return privs, group
 }
+
+// dealShares deals n shares of a random secret with the given threshold, as a
+// DKG would, and returns them along their distributed public key.
+func dealShares(n, thr int) ([]*Share, *DistPublic) {
+	return dealSecret(n, thr, KeyGroup.Scalar().Pick(random.New()))
+}
+
+func dealSecret(n, thr int, secret kyber.Scalar) ([]*Share, *DistPublic) {
+	priPoly := share.NewPriPoly(KeyGroup, thr, secret, random.New())
+	_, commits := priPoly.Commit(KeyGroup.Point().Base()).Info()
+	shares := make([]*Share, n)
+	for i, s := range priPoly.Shares(n) {
+		shares[i] = &Share{Commits: commits, Share: s}
+	}
+	return shares, &DistPublic{Coefficients: commits}
+}
+
+func TestShareVerifyAgainst(t *testing.T) {
+	n, thr := 5, 3
+	_, group := BatchIdentities(n)
+	group.Threshold = thr
+	secret := KeyGroup.Scalar().Pick(random.New())
+	shares, dist := dealSecret(n, thr, secret)
+	group.PublicKey = dist
+	for _, s := range shares {
+		require.NoError(t, s.VerifyAgainst(dist, group))
+	}
+
+	// refreshed shares keep the same distributed key
+	refreshed, _ := dealSecret(n, thr, secret)
+	for _, s := range refreshed {
+		require.NoError(t, s.VerifyAgainst(dist, group))
+	}
+
+	// a share of another key
+	others, otherDist := dealShares(n, thr)
+	require.ErrorIs(t, others[0].VerifyAgainst(dist, group), ErrShareGroupKey)
+	require.ErrorIs(t, shares[0].VerifyAgainst(otherDist, group), ErrShareGroupKey)
+
+	// a share placed in the wrong slot
+	wrongSlot := &Share{Commits: shares[0].Commits, Share: &share.PriShare{I: 1, V: shares[0].Share.V}}
+	require.ErrorIs(t, wrongSlot.VerifyAgainst(dist, group), ErrShareSlot)
+	require.ErrorIs(t, (&Share{Commits: shares[0].Commits}).VerifyAgainst(dist, group), ErrShareSlot)
+	group.Nodes = group.Nodes[1:]
+	require.ErrorIs(t, shares[0].VerifyAgainst(dist, group), ErrShareSlot)
+}
+
+func TestShareVerifyCommitments(t *testing.T) {
+	shares, dist := dealShares(4, 3)
+	for _, s := range shares {
+		require.NoError(t, s.VerifyCommitments(dist.Coefficients))
+	}
+
+	// a dealer sending a share off its polynomial
+	cheated := &Share{Commits: shares[0].Commits, Share: &share.PriShare{I: 0, V: shares[1].Share.V}}
+	err := cheated.VerifyCommitments(dist.Coefficients)
+	require.ErrorIs(t, err, ErrShareCommitments)
+	require.Contains(t, err.Error(), "published commitments")
+
+	_, otherDist := dealShares(4, 3)
+	require.ErrorIs(t, shares[0].VerifyCommitments(otherDist.Coefficients), ErrShareCommitments)
+	require.ErrorIs(t, shares[0].VerifyCommitments(nil), ErrShareCommitments)
+}
+
+func TestRecoverIdentity(t *testing.T) {
+	n, thr := 4, 3
+	_, group := BatchIdentities(n)
+	group.Threshold = thr
+	shares, dist := dealShares(n, thr)
+	group.PublicKey = dist
+	for _, s := range shares {
+		id, err := RecoverIdentity(s, group)
+		require.NoError(t, err)
+		require.True(t, id.Equal(group.Node(Index(s.Share.I)).Identity))
+	}
+
+	// a share of another distributed key
+	others, _ := dealShares(n, thr)
+	_, err := RecoverIdentity(others[0], group)
+	require.ErrorIs(t, err, ErrShareGroupKey)
+
+	// a share off its own commitments
+	cheated := &Share{Commits: shares[0].Commits, Share: &share.PriShare{I: 0, V: shares[1].Share.V}}
+	_, err = RecoverIdentity(cheated, group)
+	require.ErrorIs(t, err, ErrShareCommitments)
+
+	// no node at the index of the share
+	last := shares[n-1]
+	group.Nodes = group.Nodes[:n-1]
+	_, err = RecoverIdentity(last, group)
+	require.ErrorIs(t, err, ErrShareSlot)
+}
@@ -21,6 +21,10 @@ type Index = dkg.Index
 type Node struct {
 	*Identity
 	Index Index
+	// Comment is the free form annotation written by operators above the node
+	// in the group file. It is kept when the group is saved again but is not
+	// part of the group's content.
+	Comment string
 }
 
 // Hash is a compact representation of the node
@@ -41,6 +45,7 @@ func (n *Node) TOML() interface{} {
 	return &NodeTOML{
 		PublicTOML: n.Identity.TOML().(*PublicTOML),
 		Index:      n.Index,
+		Comment:    n.Comment,
 	}
 }
 
@@ -48,6 +53,7 @@ func (n *Node) TOML() interface{} {
 func (n *Node) FromTOML(t interface{}) error {
 	ntoml := t.(*NodeTOML)
 	n.Index = ntoml.Index
+	n.Comment = ntoml.Comment
 	n.Identity = new(Identity)
 	return n.Identity.FromTOML(ntoml.PublicTOML)
 }
@@ -61,6 +67,8 @@ func (n *Node) TOMLValue() interface{} {
 type NodeTOML struct {
 	*PublicTOML
 	Index Index
+	// Comment is written as comment lines above the node and not as a field
+	Comment string `toml:"-"`
 }
 
 // NodeFromProto creates a node from its wire representation
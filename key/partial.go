@@ -0,0 +1,110 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/drand/drand/common/scheme"
+	"github.com/drand/kyber/share"
+)
+
+// ErrInvalidPartial is returned when a partial signature does not verify.
+var ErrInvalidPartial = errors.New("invalid partial signature")
+
+// ErrInvalidBeacon is returned when the signature of a beacon does not verify.
+var ErrInvalidBeacon = errors.New("invalid beacon signature")
+
+// VerifyBeacon checks the signature of the beacon of the given round, chained
+// to the given previous signature, against the distributed public key of the
+// group. The message signed is built by the scheme of the group, see
+// scheme.Scheme.DigestBeacon, the default one if it is not set, so the same
+// code verifies the beacons of networks running different schemes. All the
+// schemes use the same curve, see schemeKeyGroup. It returns an error wrapping
+// ErrInvalidBeacon if the signature does not verify.
+func (g *Group) VerifyBeacon(round uint64, prevSig, sig []byte) error {
+	if g.PublicKey == nil || len(g.PublicKey.Coefficients) == 0 {
+		return errors.New("group: no distributed public key")
+	}
+	sch := g.Scheme
+	if sch.ID == "" {
+		var err error
+		if sch, err = scheme.GetSchemeByIDWithDefault(""); err != nil {
+			return err
+		}
+	}
+	msg := sch.DigestBeacon(round, prevSig)
+	if err := Scheme.VerifyRecovered(g.PublicKey.Key(), msg, sig); err != nil {
+		return fmt.Errorf("%w: round %d under scheme %s: %v", ErrInvalidBeacon, round, sch.ID, err)
+	}
+	return nil
+}
+
+// VerifyPartial checks the partial signature over msg produced by the node at
+// the given index of the group, using the commitment of that index derived
+// from the distributed public key of the group. It returns an error wrapping
+// ErrInvalidPartial if the signature is malformed, is the one of another
+// index or does not verify.
+func VerifyPartial(group *Group, index int, msg, partialSig []byte) error {
+	pubPoly, err := group.PublicPoly()
+	if err != nil {
+		return err
+	}
+	return verifyPartial(group, pubPoly, index, msg, partialSig)
+}
+
+// VerifyPartials checks the partial signatures over msg received for a round,
+// keyed by the index of the node they claim to come from, as VerifyPartial
+// does, and returns the sorted indices of the nodes whose signature verifies,
+// e.g. to aggregate only those and exclude the faulty nodes. Invalid partials
+// are discarded, so the only errors are the ones of the group, as when it did
+// not run a DKG.
+func VerifyPartials(group *Group, msg []byte, partials map[int][]byte) (valid []int, err error) {
+	pubPoly, err := group.PublicPoly()
+	if err != nil {
+		return nil, err
+	}
+	for index, sig := range partials {
+		if verifyPartial(group, pubPoly, index, msg, sig) == nil {
+			valid = append(valid, index)
+		}
+	}
+	sort.Ints(valid)
+	return valid, nil
+}
+
+func verifyPartial(group *Group, pubPoly *share.PubPoly, index int, msg, partialSig []byte) error {
+	if index < 0 || group.Node(Index(index)) == nil {
+		return fmt.Errorf("group: no node at index %d", index)
+	}
+	sigIndex, err := Scheme.IndexOf(partialSig)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature: %v", ErrInvalidPartial, err)
+	}
+	if sigIndex != index {
+		return fmt.Errorf("%w: signature of index %d, not %d", ErrInvalidPartial, sigIndex, index)
+	}
+	if err := Scheme.VerifyPartial(pubPoly, msg, partialSig); err != nil {
+		return fmt.Errorf("%w: index %d: %v", ErrInvalidPartial, index, err)
+	}
+	return nil
+}
+
+// PublicPoly returns the public polynomial of the group, whose coefficients are
+// the commitments of the distributed public key. Its constant term, i.e. its
+// evaluation at zero, is the collective public key verifying the beacons,
+// while its evaluation at the index of a node is the public key verifying the
+// partial signatures of that node, see VerifyPartial. It returns an error if
+// the group did not run a DKG, references its distributed public key by hash
+// only, or holds fewer commitments than its threshold.
+func (g *Group) PublicPoly() (*share.PubPoly, error) {
+	switch {
+	case g.PublicKey == nil && g.DistPublicHash() != nil:
+		return nil, errors.New("group: distributed public key referenced by hash only")
+	case g.PublicKey == nil || len(g.PublicKey.Coefficients) == 0:
+		return nil, errors.New("group: no distributed public key")
+	case len(g.PublicKey.Coefficients) < g.Threshold:
+		return nil, fmt.Errorf("group: %d commitments for a threshold of %d", len(g.PublicKey.Coefficients), g.Threshold)
+	}
+	return g.PublicKey.PubPoly(), nil
+}
@@ -0,0 +1,139 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/drand/drand/common/scheme"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyPartial(t *testing.T) {
+	n := 4
+	_, group := BatchIdentities(n)
+	shares, dist := dealShares(n, group.Threshold)
+	msg := []byte("round 42")
+
+	require.Error(t, VerifyPartial(group, 0, msg, nil))
+	group.PublicKey = dist
+
+	for i, s := range shares {
+		sig, err := Scheme.Sign(s.PrivateShare(), msg)
+		require.NoError(t, err)
+		require.NoError(t, VerifyPartial(group, i, msg, sig))
+		require.ErrorIs(t, VerifyPartial(group, i, []byte("round 43"), sig), ErrInvalidPartial)
+		require.ErrorIs(t, VerifyPartial(group, (i+1)%n, msg, sig), ErrInvalidPartial)
+	}
+
+	sig, err := Scheme.Sign(shares[1].PrivateShare(), msg)
+	require.NoError(t, err)
+	for _, index := range []int{-1, n} {
+		err := VerifyPartial(group, index, msg, sig)
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrInvalidPartial)
+	}
+	require.ErrorIs(t, VerifyPartial(group, 1, msg, sig[:1]), ErrInvalidPartial)
+	require.ErrorIs(t, VerifyPartial(group, 1, msg, sig[:len(sig)-1]), ErrInvalidPartial)
+	tampered := append([]byte{}, sig...)
+	tampered[len(tampered)-1] ^= 1
+	require.ErrorIs(t, VerifyPartial(group, 1, msg, tampered), ErrInvalidPartial)
+}
+
+func TestGroupPublicPoly(t *testing.T) {
+	n := 4
+	_, group := BatchIdentities(n)
+	_, err := group.PublicPoly()
+	require.Error(t, err)
+
+	shares, dist := dealShares(n, group.Threshold)
+	group.PublicKey = dist
+	pubPoly, err := group.PublicPoly()
+	require.NoError(t, err)
+	require.True(t, pubPoly.Commit().Equal(dist.Key()))
+	for _, s := range shares {
+		public := KeyGroup.Point().Mul(s.PrivateShare().V, nil)
+		require.True(t, pubPoly.Eval(s.PrivateShare().I).V.Equal(public))
+	}
+
+	ref := group.WithDistPublicReference()
+	_, err = ref.PublicPoly()
+	require.Error(t, err)
+
+	group.PublicKey = &DistPublic{Coefficients: dist.Coefficients[:group.Threshold-1]}
+	_, err = group.PublicPoly()
+	require.Error(t, err)
+}
+
+func TestVerifyPartials(t *testing.T) {
+	n := 5
+	_, group := BatchIdentities(n)
+	shares, dist := dealShares(n, group.Threshold)
+	msg := []byte("round 42")
+	_, err := VerifyPartials(group, msg, nil)
+	require.Error(t, err)
+	group.PublicKey = dist
+
+	partials := make(map[int][]byte)
+	for i, s := range shares {
+		sig, err := Scheme.Sign(s.PrivateShare(), msg)
+		require.NoError(t, err)
+		partials[i] = sig
+	}
+	valid, err := VerifyPartials(group, msg, partials)
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 1, 2, 3, 4}, valid)
+
+	// a tampered signature, one signed for another round, one claimed by
+	// another node and one of an unknown index are all discarded
+	partials[1] = append([]byte{}, partials[1]...)
+	partials[1][len(partials[1])-1] ^= 1
+	partials[2], err = Scheme.Sign(shares[2].PrivateShare(), []byte("round 43"))
+	require.NoError(t, err)
+	partials[3] = partials[4]
+	partials[n] = partials[0]
+	valid, err = VerifyPartials(group, msg, partials)
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 4}, valid)
+
+	valid, err = VerifyPartials(group, msg, nil)
+	require.NoError(t, err)
+	require.Empty(t, valid)
+}
+
+func TestGroupVerifyBeacon(t *testing.T) {
+	n := 4
+	_, group := BatchIdentities(n)
+	shares, dist := dealShares(n, group.Threshold)
+	prev := []byte("signature of round 41")
+	sign := func(sch scheme.Scheme) []byte {
+		msg := sch.DigestBeacon(42, prev)
+		var partials [][]byte
+		for _, s := range shares {
+			sig, err := Scheme.Sign(s.PrivateShare(), msg)
+			require.NoError(t, err)
+			partials = append(partials, sig)
+		}
+		sig, err := Scheme.Recover(dist.PubPoly(), msg, partials, group.Threshold, n)
+		require.NoError(t, err)
+		return sig
+	}
+	chained, _ := scheme.GetSchemeByID(scheme.DefaultSchemeID)
+	unchained, _ := scheme.GetSchemeByID(scheme.UnchainedSchemeID)
+
+	require.Error(t, group.VerifyBeacon(42, prev, sign(chained)))
+	group.PublicKey = dist
+
+	// without a scheme, the group runs the default chained one
+	sig := sign(chained)
+	require.NoError(t, group.VerifyBeacon(42, prev, sig))
+	group.Scheme = chained
+	require.NoError(t, group.VerifyBeacon(42, prev, sig))
+	require.ErrorIs(t, group.VerifyBeacon(43, prev, sig), ErrInvalidBeacon)
+	require.ErrorIs(t, group.VerifyBeacon(42, []byte("another"), sig), ErrInvalidBeacon)
+
+	group.Scheme = unchained
+	require.ErrorIs(t, group.VerifyBeacon(42, prev, sig), ErrInvalidBeacon)
+	sig = sign(unchained)
+	require.NoError(t, group.VerifyBeacon(42, prev, sig))
+	require.NoError(t, group.VerifyBeacon(42, nil, sig))
+	require.ErrorIs(t, group.VerifyBeacon(43, nil, sig), ErrInvalidBeacon)
+}
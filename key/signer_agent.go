@@ -0,0 +1,132 @@
+package key
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// ErrAgentUnavailable is returned when the signing agent can't be reached.
+var ErrAgentUnavailable = errors.New("agent: signing agent unavailable")
+
+// The agent protocol exchanges frames over a Unix socket, one request and its
+// response per connection. A frame is a 4 bytes big endian length followed by
+// that many bytes of payload, at most maxAgentFrame.
+//
+// A sign request is the agentSign byte, the 2 bytes big endian length of the
+// public key to sign with, the marshaled key and the message. The agent
+// responds with agentOK followed by the AuthScheme signature of the message,
+// or with agentFailure followed by an error message.
+const (
+	agentSign    byte = 1
+	agentOK      byte = 0
+	agentFailure byte = 1
+
+	maxAgentFrame = 1 << 20
+	// agentTimeout bounds a whole exchange with the agent
+	agentTimeout = 10 * time.Second
+)
+
+// agentSigner signs with a private key held by an agent process instead of
+// the store.
+type agentSigner struct {
+	socket string
+	id     *Identity
+}
+
+// NewAgentSigner returns a signer requesting the signatures from the agent
+// listening on the given Unix socket, which holds the private key of the
+// identity, so that the node only needs its public identity, as loaded by
+// LoadIdentity. Each signature is checked against the key of the identity.
+// Signing fails with an error wrapping ErrAgentUnavailable if the agent can't
+// be reached.
+func NewAgentSigner(socket string, id *Identity) Signer {
+	return &agentSigner{socket: socket, id: id}
+}
+
+func (a *agentSigner) Sign(msg []byte) ([]byte, error) {
+	key, err := a.id.Key.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if len(key) > 0xffff {
+		return nil, errors.New("agent: public key too long")
+	}
+	conn, err := net.DialTimeout("unix", a.socket, agentTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAgentUnavailable, err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(agentTimeout))
+
+	req := make([]byte, 3, 3+len(key)+len(msg))
+	req[0] = agentSign
+	binary.BigEndian.PutUint16(req[1:], uint16(len(key)))
+	req = append(append(req, key...), msg...)
+	if err := writeAgentFrame(conn, req); err != nil {
+		return nil, fmt.Errorf("%w: sending request: %v", ErrAgentUnavailable, err)
+	}
+	resp, err := readAgentFrame(conn)
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading response: %v", ErrAgentUnavailable, err)
+	}
+	switch {
+	case len(resp) == 0:
+		return nil, errors.New("agent: empty response")
+	case resp[0] == agentFailure:
+		return nil, fmt.Errorf("agent: signing refused: %s", resp[1:])
+	case resp[0] != agentOK:
+		return nil, fmt.Errorf("agent: unknown response status %d", resp[0])
+	}
+	sig := resp[1:]
+	if err := AuthScheme.Verify(a.id.Key, msg, sig); err != nil {
+		return nil, fmt.Errorf("%w: agent signature for %s: %v", ErrBadSignature, a.id.Addr, err)
+	}
+	return sig, nil
+}
+
+func writeAgentFrame(w io.Writer, payload []byte) error {
+	if len(payload) > maxAgentFrame {
+		return fmt.Errorf("frame of %d bytes too large", len(payload))
+	}
+	frame := make([]byte, 4, 4+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	_, err := w.Write(append(frame, payload...))
+	return err
+}
+
+func readAgentFrame(r io.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxAgentFrame {
+		return nil, fmt.Errorf("frame of %d bytes too large", n)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// IdentityStore is implemented by stores able to load the public identity of
+// the node on its own, e.g. when its private key is held by an agent, see
+// NewAgentSigner.
+type IdentityStore interface {
+	// LoadIdentity loads the public identity of the node without reading its
+	// private key.
+	LoadIdentity() (*Identity, error)
+}
+
+func (f *fileStore) LoadIdentity() (*Identity, error) {
+	if !f.separatePublicFile {
+		return nil, errors.New("store: the identity is kept in the private key file")
+	}
+	id := new(Identity)
+	return id, f.load(f.publicKeyFile, id)
+}
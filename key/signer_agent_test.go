@@ -0,0 +1,79 @@
+package key
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAgent serves the sign requests for the given pair on a Unix socket.
+func fakeAgent(t *testing.T, pair *Pair) string {
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	key, err := pair.Public.Key.MarshalBinary()
+	require.NoError(t, err)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			req, err := readAgentFrame(conn)
+			if err == nil && len(req) > 3 && req[0] == agentSign {
+				n := int(binary.BigEndian.Uint16(req[1:]))
+				resp := []byte{agentFailure}
+				resp = append(resp, "unknown key"...)
+				if 3+n <= len(req) && bytes.Equal(req[3:3+n], key) {
+					sig, _ := pair.Sign(req[3+n:])
+					resp = append([]byte{agentOK}, sig...)
+				}
+				_ = writeAgentFrame(conn, resp)
+			}
+			conn.Close()
+		}
+	}()
+	return socket
+}
+
+func TestAgentSigner(t *testing.T) {
+	store := NewFileStore(t.TempDir(), "")
+	pair, err := Init(store, "127.0.0.1:8080")
+	require.NoError(t, err)
+	id, err := store.(IdentityStore).LoadIdentity()
+	require.NoError(t, err)
+	require.True(t, id.Equal(pair.Public))
+	socket := fakeAgent(t, pair)
+
+	signer := NewAgentSigner(socket, id)
+	msg := []byte("group hash")
+	sig, err := signer.Sign(msg)
+	require.NoError(t, err)
+	require.NoError(t, AuthScheme.Verify(id.Key, msg, sig))
+
+	// the signer works with the signed group store
+	_, group := BatchIdentities(3)
+	signed := store.(SignedGroupStore)
+	require.NoError(t, signed.SaveSignedGroup(group, signer))
+	_, err = signed.LoadSignedGroup(id.Key)
+	require.NoError(t, err)
+
+	// the agent does not hold the key of another identity
+	other := NewKeyPair("127.0.0.1:8081")
+	_, err = NewAgentSigner(socket, other.Public).Sign(msg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown key")
+
+	// nor is a signature by another key accepted
+	rogue := fakeAgent(t, &Pair{Key: other.Key, Public: pair.Public})
+	_, err = NewAgentSigner(rogue, id).Sign(msg)
+	require.ErrorIs(t, err, ErrBadSignature)
+
+	_, err = NewAgentSigner(filepath.Join(t.TempDir(), "none.sock"), id).Sign(msg)
+	require.ErrorIs(t, err, ErrAgentUnavailable)
+}
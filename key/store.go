@@ -1,32 +1,65 @@
 package key
 
 import (
+	"bytes"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"reflect"
+	"sync"
+	"time"
 
 	"github.com/drand/drand/common"
 
 	"github.com/BurntSushi/toml"
+	clock "github.com/jonboulle/clockwork"
+
 	"github.com/drand/drand/fs"
+	"github.com/drand/drand/log"
 )
 
 // Store abstracts the loading and saving of any private/public cryptographic
 // material to be used by drand. For the moment, only a file based store is
-// implemented.
+// implemented. Loading an object the store does not hold returns an error
+// wrapping ErrAbsent, and the other failures wrap one of the store errors
+// such as ErrStoreFile.
 type Store interface {
 	// SaveKeyPair saves the private key generated by drand as well as the
-	// public identity key associated
-	SaveKeyPair(p *Pair) error
+	// public identity key associated. An existing key pair is only replaced
+	// when called with WithOverwrite(true).
+	SaveKeyPair(p *Pair, opts ...SaveOption) error
 	// LoadKeyPair loads the private/public key pair associated with the drand
 	// operator
 	LoadKeyPair() (*Pair, error)
-	SaveShare(share *Share) error
+	// SaveShare saves the private share. An existing share is only replaced
+	// when called with WithOverwrite(true).
+	SaveShare(share *Share, opts ...SaveOption) error
 	LoadShare() (*Share, error)
-	SaveGroup(*Group) error
+	// SaveDistPublic saves the distributed public key and keeps the copy
+	// embedded in the stored group, if any, in sync with it.
+	SaveDistPublic(d *DistPublic, opts ...SaveOption) error
+	LoadDistPublic() (*DistPublic, error)
+	// SaveDKGResult saves both the share and the distributed public key
+	// resulting from a DKG, or none of them. As for SaveShare, an existing
+	// share is only replaced when called with WithOverwrite(true).
+	SaveDKGResult(share *Share, d *DistPublic, opts ...SaveOption) error
+	// SaveGroup saves the group as is. Stores implementing GroupSetter also
+	// offer SetGroup, which validates and verifies the group it saves.
+	SaveGroup(g *Group, opts ...SaveOption) error
 	LoadGroup() (*Group, error)
+	// CompareAndSwapGroup saves the new group only if the group currently
+	// stored is equal, by hash, to the expected one. A nil expected group
+	// means no group must be stored yet. It returns ErrConflict otherwise.
+	CompareAndSwapGroup(expected, new *Group) error
 	Reset(...ResetOption) error
+	// Close makes the pending writes durable and releases the resources of
+	// the store, e.g. for a clean shutdown. Once the store, or a store derived
+	// from it as through WithCodec, is closed, its operations fail with an
+	// error wrapping ErrClosed. Closing a store again does nothing.
+	Close() error
 }
 
 // KeyFolderName is the name of the folder where drand keeps its keys
@@ -38,9 +71,39 @@ const keyFileName = "drand_id"
 const privateExtension = ".private"
 const publicExtension = ".public"
 const groupFileName = "drand_group.toml"
+const pendingGroupFileName = "drand_group.pending.toml"
 const shareFileName = "dist_key.private"
 const distKeyFileName = "dist_key.public"
 
+// StoreKind identifies the different kinds of objects kept by a Store.
+type StoreKind int
+
+const (
+	// KeyPairKind is the private / public key pair of the node
+	KeyPairKind StoreKind = iota
+	// ShareKind is the private share obtained after a DKG
+	ShareKind
+	// GroupKind is the group file
+	GroupKind
+	// DistPublicKind is the distributed public key
+	DistPublicKind
+)
+
+func (k StoreKind) String() string {
+	switch k {
+	case KeyPairKind:
+		return "keypair"
+	case ShareKind:
+		return "share"
+	case GroupKind:
+		return "group"
+	case DistPublicKind:
+		return "distpublic"
+	default:
+		return "unknown"
+	}
+}
+
 // Tomler represents any struct that can be (un)marshaled into/from toml format
 // XXX surely golang reflect package can automatically return the TOMLValue()
 // for us
@@ -52,15 +115,87 @@ type Tomler interface {
 
 // fileStore is a Store using filesystem to store informations
 type fileStore struct {
+	// mu serializes the read-modify-write operations of this store, and is
+	// shared with the stores derived from it through WithCodec
+	mu             *sync.Mutex
 	baseFolder     string
+	privateBase    string
+	publicBase     string
 	beaconID       string
 	privateKeyFile string
 	publicKeyFile  string
 	shareFile      string
 	distKeyFile    string
 	groupFile      string
+	groupSigFile   string
+	// pendingGroupFile holds the group of a running DKG
+	pendingGroupFile string
+
+	// closed is shared with the stores derived from this store
+	closed *closeState
+
+	log          log.Logger
+	clock        clock.Clock
+	maxClockSkew time.Duration
+	// maxFileSize is the maximum size of the loaded files
+	maxFileSize int64
+	// readTimeout bounds each file read of the loads, if positive
+	readTimeout time.Duration
+	// watchInterval is the interval at which WatchGroup checks the group
+	watchInterval time.Duration
+	hooks         Hooks
+	// verifyAfterWrite makes the saves read the objects back to check them
+	verifyAfterWrite bool
+	// separatePublicFile is false if the public identity is kept in the
+	// private key file
+	separatePublicFile bool
+	// startupValidation makes OpenFileStore check the store
+	startupValidation bool
+	// shareHistory is the number of replaced shares kept
+	shareHistory int
+	// groupCandidates are the names of the files, in the groups folder,
+	// considered by LoadGroup along with the group file
+	groupCandidates []string
+	// distPublicReference makes the group file reference the distributed
+	// public key file by hash
+	distPublicReference bool
+	// minimalGroupDiffs makes the saves of the group file keep the entries
+	// that did not change byte for byte
+	minimalGroupDiffs bool
+	// contentAddressedGroups makes the group file a link to the saved group
+	// archived under its hash
+	contentAddressedGroups bool
+	// strictNodeOrder makes LoadGroup fail on a group file whose nodes are
+	// not listed by index
+	strictNodeOrder bool
+	// codecs transform the serialized objects, the first one being applied
+	// first when writing
+	codecs []Codec
+	// seal holds the key of the private objects, see WithSealing
+	seal *sealState
+	// wal logs the atomic writes of the store, so that those interrupted by a
+	// crash are recovered when the store is opened again
+	wal *writeAheadLog
+	// journalSaves makes every save go through the log, see WithWriteAheadLog
+	journalSaves bool
+	// observer is notified of the lifecycle events, see WithObserver
+	observer StoreObserver
+}
+
+// ClockSkewChecker is implemented by stores able to check the local clock
+// against the schedule of their group.
+type ClockSkewChecker interface {
+	// CheckClockSkew compares the round computed from the local clock with
+	// the round the network is expected to be at. It returns the estimated
+	// skew, and an error wrapping ErrClockSkew if it is larger than the
+	// tolerated skew.
+	CheckClockSkew(expectedRound uint64) (time.Duration, error)
 }
 
+// ErrClockSkew is returned when the local clock seems to be off compared to
+// the schedule of the group.
+var ErrClockSkew = errors.New("store: local clock is off the group schedule")
+
 // GetFirstStore will return the first store from the stores map
 func GetFirstStore(stores map[string]Store) (string, Store) {
 	for k, v := range stores {
@@ -92,74 +227,399 @@ func NewFileStores(baseFolder string) (map[string]Store, error) {
 }
 
 // NewFileStore is used to create the config folder and all the subfolders.
-// If a folder alredy exists, we simply check the rights
-func NewFileStore(baseFolder, beaconID string) Store {
+// If a folder alredy exists, we simply check the rights. Private and public
+// objects are kept under baseFolder unless WithPrivateBase or WithPublicBase
+// are given.
+func NewFileStore(baseFolder, beaconID string, opts ...StoreOption) Store {
 	if beaconID == "" {
 		beaconID = common.DefaultBeaconID
 	}
 
-	store := &fileStore{baseFolder: baseFolder, beaconID: beaconID}
+	store := &fileStore{
+		mu:                 new(sync.Mutex),
+		baseFolder:         baseFolder,
+		privateBase:        baseFolder,
+		publicBase:         baseFolder,
+		beaconID:           beaconID,
+		closed:             newCloseState(),
+		log:                log.DefaultLogger(),
+		clock:              clock.NewRealClock(),
+		maxClockSkew:       DefaultMaxClockSkew,
+		watchInterval:      DefaultWatchInterval,
+		maxFileSize:        DefaultMaxFileSize,
+		separatePublicFile: true,
+		observer:           NopObserver{},
+		wal:                new(writeAheadLog),
+	}
+	for _, opt := range opts {
+		opt(store)
+	}
 
-	keyFolder := fs.CreateSecureFolder(path.Join(baseFolder, beaconID, KeyFolderName))
-	groupFolder := fs.CreateSecureFolder(path.Join(baseFolder, beaconID, GroupFolderName))
+	// both bases use the same layout
+	privateKeyFolder := fs.CreateSecureFolder(path.Join(store.privateBase, beaconID, KeyFolderName))
+	privateGroupFolder := fs.CreateSecureFolder(path.Join(store.privateBase, beaconID, GroupFolderName))
+	publicKeyFolder := fs.CreateSecureFolder(path.Join(store.publicBase, beaconID, KeyFolderName))
+	publicGroupFolder := fs.CreateSecureFolder(path.Join(store.publicBase, beaconID, GroupFolderName))
 
-	store.privateKeyFile = path.Join(keyFolder, keyFileName) + privateExtension
-	store.publicKeyFile = path.Join(keyFolder, keyFileName) + publicExtension
-	store.groupFile = path.Join(groupFolder, groupFileName)
-	store.shareFile = path.Join(groupFolder, shareFileName)
-	store.distKeyFile = path.Join(groupFolder, distKeyFileName)
+	store.privateKeyFile = path.Join(privateKeyFolder, keyFileName) + privateExtension
+	store.publicKeyFile = path.Join(publicKeyFolder, keyFileName) + publicExtension
+	store.groupFile = path.Join(publicGroupFolder, groupFileName)
+	store.groupSigFile = store.groupFile + groupSignatureExtension
+	store.pendingGroupFile = path.Join(publicGroupFolder, pendingGroupFileName)
+	store.shareFile = path.Join(privateGroupFolder, shareFileName)
+	store.distKeyFile = path.Join(publicGroupFolder, distKeyFileName)
+	store.wal.setup(store)
+	if err := store.wal.recover(); err != nil {
+		store.log.Errorw("", "store", "recovering the interrupted writes", "err", err)
+	}
 
+	store.observer.OnOpen(beaconID)
 	return store
 }
 
 // SaveKeyPair first saves the private key in a file with tight permissions and then
 // saves the public part in another file.
-func (f *fileStore) SaveKeyPair(p *Pair) error {
-	if err := Save(f.privateKeyFile, p, true); err != nil {
+func (f *fileStore) SaveKeyPair(p *Pair, opts ...SaveOption) error {
+	f.lock()
+	defer f.unlock()
+	return f.saveKeyPair(p, opts...)
+}
+
+// saveKeyPair saves the key pair. Unless it may overwrite it, the private key
+// file is created exclusively, so that two processes initializing the same
+// store can't both write their key.
+func (f *fileStore) saveKeyPair(p *Pair, opts ...SaveOption) (err error) {
+	defer func() { f.observer.OnSave(KeyPairKind, err) }()
+	c := newSaveConfig(false, opts)
+	if err := checkOverwrite(f.privateKeyFile, c); err != nil {
+		return err
+	}
+	if err := f.beforeSave(KeyPairKind, p.Public.Addr); err != nil {
+		return err
+	}
+	savePrivate := f.saveExclusive
+	if c.overwrite {
+		savePrivate = f.save
+	}
+	if !f.separatePublicFile {
+		if err := savePrivate(f.privateKeyFile, pairWithIdentity{p}, true); err != nil {
+			return err
+		}
+		fmt.Printf("Saved the key : %s at %s\n", p.Public.Addr, f.privateKeyFile)
+	} else {
+		if err := savePrivate(f.privateKeyFile, p, true); err != nil {
+			return err
+		}
+		fmt.Printf("Saved the key : %s at %s\n", p.Public.Addr, f.publicKeyFile)
+		if err := f.save(f.publicKeyFile, p.Public, false); err != nil {
+			return err
+		}
+	}
+	if err := f.verifyKeyPair(p); err != nil {
 		return err
 	}
-	fmt.Printf("Saved the key : %s at %s\n", p.Public.Addr, f.publicKeyFile)
-	return Save(f.publicKeyFile, p.Public, false)
+	f.afterSave(KeyPairKind, f.hooks.OnKeyPairSaved, p.Public.Addr)
+	return nil
 }
 
-// LoadKeyPair decode private key first then public
-func (f *fileStore) LoadKeyPair() (*Pair, error) {
+// LoadKeyPair decode private key first then public. Without a separate public
+// file, the public identity is read from the private file, unless it was
+// written with one.
+func (f *fileStore) LoadKeyPair() (_ *Pair, err error) {
+	defer func() { f.observer.OnLoad(KeyPairKind, err) }()
 	p := new(Pair)
-	if err := Load(f.privateKeyFile, p); err != nil {
+	if err := f.load(f.privateKeyFile, p); err != nil {
 		return nil, err
 	}
-	return p, Load(f.publicKeyFile, p.Public)
+	if !f.separatePublicFile && p.Public.Addr != "" {
+		return p, nil
+	}
+	return p, f.load(f.publicKeyFile, p.Public)
 }
 
-func (f *fileStore) LoadGroup() (*Group, error) {
+func (f *fileStore) LoadGroup() (_ *Group, err error) {
+	defer func() { f.observer.OnLoad(GroupKind, err) }()
 	g := new(Group)
-	return g, Load(f.groupFile, g)
+	if len(f.groupCandidates) > 0 {
+		if g, err = f.loadNewestGroup(); err != nil {
+			return g, err
+		}
+	} else if err := f.loadGroupFile(f.groupFile, g); err != nil {
+		return g, err
+	}
+	if f.distPublicReference && g.DistPublicHash() != nil {
+		return g, f.resolveDistPublic(g)
+	}
+	return g, nil
+}
+
+func (f *fileStore) SaveGroup(g *Group, opts ...SaveOption) error {
+	f.lock()
+	defer f.unlock()
+	if err := checkOverwrite(f.groupFile, newSaveConfig(true, opts)); err != nil {
+		return err
+	}
+	return f.saveGroup(g, nil)
+}
+
+// saveGroup writes the group, along with its signature if not nil.
+func (f *fileStore) saveGroup(g *Group, sig *groupSignature) (err error) {
+	defer func() { f.observer.OnSave(GroupKind, err) }()
+	hash := hex.EncodeToString(g.Hash())
+	if err := f.beforeSave(GroupKind, hash); err != nil {
+		return err
+	}
+	if err := f.writeGroup(g, sig); err != nil {
+		return err
+	}
+	if err := f.verifyGroup(g); err != nil {
+		return err
+	}
+	f.afterSave(GroupKind, f.hooks.OnGroupSaved, hash)
+	return nil
 }
 
-func (f *fileStore) SaveGroup(g *Group) error {
-	return Save(f.groupFile, g, false)
+// CompareAndSwapGroup writes the new group under the store lock if the group
+// on disk has the same hash as the expected one.
+func (f *fileStore) CompareAndSwapGroup(expected, newGroup *Group) error {
+	f.lock()
+	defer f.unlock()
+
+	exists, err := fs.Exists(f.groupFile)
+	if err != nil {
+		return wrapFileError(f.groupFile, err)
+	}
+	switch {
+	case !exists && expected != nil:
+		return fmt.Errorf("%w: no group stored", ErrConflict)
+	case exists && expected == nil:
+		return fmt.Errorf("%w: a group is already stored", ErrConflict)
+	case exists:
+		current := new(Group)
+		if err := f.load(f.groupFile, current); err != nil {
+			return err
+		}
+		if !bytes.Equal(current.Hash(), expected.Hash()) {
+			return fmt.Errorf("%w: group hash %x", ErrConflict, current.Hash())
+		}
+	}
+	return f.saveGroup(newGroup, nil)
 }
 
-func (f *fileStore) SaveShare(share *Share) error {
+func (f *fileStore) SaveShare(share *Share, opts ...SaveOption) (err error) {
+	defer func() { f.observer.OnSave(ShareKind, err) }()
+	f.lock()
+	defer f.unlock()
+	if err := checkOverwrite(f.shareFile, newSaveConfig(false, opts)); err != nil {
+		return err
+	}
+	var groupHash string
+	if g, err := f.LoadGroup(); err == nil {
+		groupHash = hex.EncodeToString(g.Hash())
+	}
+	if err := f.beforeSave(ShareKind, groupHash); err != nil {
+		return err
+	}
+	if err := f.rotateShares(); err != nil {
+		return err
+	}
 	fmt.Printf("crypto store: saving private share in %s\n", f.shareFile)
-	return Save(f.shareFile, share, true)
+	if err := f.save(f.shareFile, share, true); err != nil {
+		return err
+	}
+	if err := f.verifyShare(share); err != nil {
+		return err
+	}
+	f.afterSave(ShareKind, f.hooks.OnShareSaved, groupHash)
+	return nil
 }
 
 func (f *fileStore) LoadShare() (*Share, error) {
 	s := new(Share)
-	return s, Load(f.shareFile, s)
+	err := f.load(f.shareFile, s)
+	f.observer.OnLoad(ShareKind, err)
+	return s, err
+}
+
+// SaveDistPublic writes the distributed public key and updates the one
+// embedded in the stored group, if any. Both files are replaced atomically.
+func (f *fileStore) SaveDistPublic(d *DistPublic, opts ...SaveOption) (err error) {
+	defer func() { f.observer.OnSave(DistPublicKind, err) }()
+	f.lock()
+	defer f.unlock()
+
+	if err := checkOverwrite(f.distKeyFile, newSaveConfig(true, opts)); err != nil {
+		return err
+	}
+
+	if err := f.beforeSave(DistPublicKind, hex.EncodeToString(d.Hash())); err != nil {
+		return err
+	}
+	var group *Group
+	if exists, _ := fs.Exists(f.groupFile); exists {
+		group = new(Group)
+		if err := f.load(f.groupFile, group); err != nil {
+			return err
+		}
+	}
+
+	w := &atomicWrite{closed: f.closed, wal: f.wal, codecs: f.codecs}
+	if err := w.add(f.distKeyFile, d, false); err != nil {
+		return err
+	}
+	if group != nil && (group.PublicKey == nil || !group.PublicKey.Equal(d)) {
+		group.setDistPublic(d)
+		if err := w.add(f.groupFile, f.groupFileObject(group), false); err != nil {
+			return err
+		}
+	}
+	if err := w.commit(); err != nil {
+		return err
+	}
+	if group != nil {
+		if err := f.archiveGroup(); err != nil {
+			return err
+		}
+		if err := f.verifyGroup(group); err != nil {
+			return err
+		}
+	}
+	return f.verifyDistPublic(d)
+}
+
+// SaveDKGResult saves the share and the distributed public key obtained at the
+// end of a DKG. Either both replace the current ones or, on any error, the
+// previous share and distributed public key are left untouched.
+func (f *fileStore) SaveDKGResult(share *Share, d *DistPublic, opts ...SaveOption) (err error) {
+	defer func() {
+		f.observer.OnSave(ShareKind, err)
+		f.observer.OnSave(DistPublicKind, err)
+	}()
+	f.lock()
+	defer f.unlock()
+
+	if err := checkOverwrite(f.shareFile, newSaveConfig(false, opts)); err != nil {
+		return err
+	}
+
+	var groupHash string
+	if g, err := f.LoadGroup(); err == nil {
+		groupHash = hex.EncodeToString(g.Hash())
+	}
+	if err := f.beforeSave(ShareKind, groupHash); err != nil {
+		return err
+	}
+	if err := f.beforeSave(DistPublicKind, hex.EncodeToString(d.Hash())); err != nil {
+		return err
+	}
+
+	private, err := f.codecsFor(true)
+	if err != nil {
+		return err
+	}
+	w := &atomicWrite{closed: f.closed, wal: f.wal, codecs: f.codecs, privateCodecs: private}
+	if err := w.add(f.shareFile, share, true); err != nil {
+		return err
+	}
+	if err := w.add(f.distKeyFile, d, false); err != nil {
+		return err
+	}
+	if err := f.rotateShares(); err != nil {
+		w.abort()
+		return err
+	}
+	if err := w.commit(); err != nil {
+		return err
+	}
+	if err := f.verifyShare(share); err != nil {
+		return err
+	}
+	if err := f.verifyDistPublic(d); err != nil {
+		return err
+	}
+	f.afterSave(ShareKind, f.hooks.OnShareSaved, groupHash)
+	return nil
+}
+
+// LoadDistPublic loads the distributed public key and warns if it differs
+// from the one embedded in the stored group.
+func (f *fileStore) LoadDistPublic() (_ *DistPublic, err error) {
+	defer func() { f.observer.OnLoad(DistPublicKind, err) }()
+	d := new(DistPublic)
+	if err := f.load(f.distKeyFile, d); err != nil {
+		return nil, err
+	}
+	if exists, _ := fs.Exists(f.groupFile); exists {
+		group := new(Group)
+		if err := f.load(f.groupFile, group); err == nil && group.PublicKey != nil && !group.PublicKey.Equal(d) {
+			f.log.Warnw("", "store", "distributed public key differs from the group's one",
+				"dist_key", hex.EncodeToString(d.Hash()), "group_key", hex.EncodeToString(group.PublicKey.Hash()))
+		}
+	}
+	return d, nil
+}
+
+// CheckClockSkew loads the group and compares the round computed with the
+// store's clock with the expected one, typically obtained from peers at
+// startup. A skew above the tolerated one is logged as a warning, as it
+// usually means the system clock is not synchronized.
+func (f *fileStore) CheckClockSkew(expectedRound uint64) (time.Duration, error) {
+	g, err := f.LoadGroup()
+	if err != nil {
+		return 0, err
+	}
+	now := f.clock.Now()
+	skew, err := g.ClockSkew(now, expectedRound)
+	if err != nil {
+		return 0, err
+	}
+	if skew > f.maxClockSkew || -skew > f.maxClockSkew {
+		local, _ := g.CurrentRound(now)
+		f.log.Warnw("", "store", "clock skew detected", "skew", skew, "local_round", local,
+			"expected_round", expectedRound, "hint", "check the system clock is synchronized (NTP)")
+		return skew, fmt.Errorf("%w: %s off round %d", ErrClockSkew, skew, expectedRound)
+	}
+	return skew, nil
 }
 
 func (f *fileStore) Reset(...ResetOption) error {
-	if err := Delete(f.distKeyFile); err != nil {
-		return fmt.Errorf("drand: err deleting dist. key file: %v", err)
+	if err := f.closed.check(); err != nil {
+		return err
+	}
+	err := Delete(f.distKeyFile)
+	f.observer.OnDelete(DistPublicKind, err)
+	if err != nil {
+		return fmt.Errorf("drand: err deleting dist. key file: %w", wrapFileError(f.distKeyFile, err))
 	}
+	err = f.resetShare()
+	f.observer.OnDelete(ShareKind, err)
+	if err != nil {
+		return err
+	}
+	err = f.resetGroup()
+	f.observer.OnDelete(GroupKind, err)
+	return err
+}
+
+func (f *fileStore) resetShare() error {
 	if err := Delete(f.shareFile); err != nil {
-		return fmt.Errorf("drand: errd eleting share file: %v", err)
+		return fmt.Errorf("drand: err deleting share file: %w", wrapFileError(f.shareFile, err))
 	}
+	if err := f.deleteShareHistory(); err != nil {
+		return fmt.Errorf("drand: err deleting previous shares: %w", err)
+	}
+	return nil
+}
 
+func (f *fileStore) resetGroup() error {
 	if err := Delete(f.groupFile); err != nil {
-		return fmt.Errorf("drand: err deleting group file: %v", err)
+		return fmt.Errorf("drand: err deleting group file: %w", wrapFileError(f.groupFile, err))
+	}
+	if err := Delete(f.groupSigFile); err != nil {
+		return fmt.Errorf("drand: err deleting group signature file: %w", wrapFileError(f.groupSigFile, err))
+	}
+	if err := Delete(f.pendingGroupFile); err != nil {
+		return fmt.Errorf("drand: err deleting pending group file: %w", wrapFileError(f.pendingGroupFile, err))
 	}
 	return nil
 }
@@ -168,27 +628,106 @@ func (f *fileStore) Reset(...ResetOption) error {
 // file will have a 0700 security.
 // TODO: move that to fs/
 func Save(filePath string, t Tomler, secure bool) error {
-	var fd *os.File
-	var err error
-	if secure {
-		fd, err = fs.CreateSecureFile(filePath)
-	} else {
-		fd, err = os.Create(filePath)
-	}
+	return save(filePath, t, secure, nil)
+}
+
+func save(filePath string, t Tomler, secure bool, codecs []Codec) error {
+	fd, err := createFile(filePath, secure)
 	if err != nil {
-		return fmt.Errorf("config: can't save %s to %s: %s", reflect.TypeOf(t).String(), filePath, err)
+		return fmt.Errorf("config: can't save %s to %s: %w", reflect.TypeOf(t).String(), filePath, err)
 	}
 	defer fd.Close()
-	return toml.NewEncoder(fd).Encode(t.TOML())
+	if err := encodeWith(fd, t, codecs); err != nil {
+		return err
+	}
+	// the file must be durable once saved, see fileStore.Close
+	return fd.Sync()
 }
 
-// Load the given Tomler from the given file path.
+// Load the given Tomler from the given file path. Files larger than
+// DefaultMaxFileSize are rejected with ErrTooLarge. Group files can reference
+// other fragments through their include directive, which are resolved relative
+// to filePath. Only local fragments are read, see LoadWithRemoteIncludes.
 func Load(filePath string, t Tomler) error {
+	return loadFile(filePath, t, false)
+}
+
+// LoadWithRemoteIncludes loads the given Tomler as Load does, also fetching
+// the group fragments included by URL. It is meant for the tools assembling a
+// group, the loads of the stores never reach the network.
+func LoadWithRemoteIncludes(filePath string, t Tomler) error {
+	return loadFile(filePath, t, true)
+}
+
+func loadFile(filePath string, t Tomler, remote bool) error {
+	fd, err := openLimited(filePath, DefaultMaxFileSize)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	data, err := readLimited(fd, DefaultMaxFileSize)
+	if err != nil {
+		return err
+	}
+	return loadData(filePath, data, t, remote)
+}
+
+// loadData decodes the content of the file at filePath. The group fragments
+// included by URL are only fetched if remote is true.
+func loadData(filePath string, data []byte, t Tomler, remote bool) error {
+	tomlValue := t.TOMLValue()
+	isJSON, err := decodeValue(data, tomlValue)
+	if err != nil {
+		return err
+	}
+	if gt, ok := tomlValue.(*GroupTOML); ok {
+		if !isJSON {
+			gt.setNodeComments(data)
+		}
+		if len(gt.Include) > 0 {
+			if err := resolveGroupIncludes(filePath, gt, remote); err != nil {
+				return err
+			}
+		}
+	}
+	return t.FromTOML(tomlValue)
+}
+
+// Encode writes the TOML representation of the given Tomler to w. Groups are
+// written with EncodeGroup, and the comments of their nodes are written above
+// each node.
+func Encode(w io.Writer, t Tomler) error {
+	switch g := t.(type) {
+	case *Group:
+		return EncodeGroup(w, g)
+	case *minimalDiffGroup:
+		return encodeGroupMinimalDiff(w, g.previous, g.Group)
+	}
+	return toml.NewEncoder(w).Encode(t.TOML())
+}
+
+// Decode reads the given Tomler from its TOML representation read from r, or
+// from a JSON document with the same fields. The input must not be larger than
+// DefaultMaxFileSize. The include directives of group files can't be resolved
+// without a file location and are rejected.
+func Decode(r io.Reader, t Tomler) error {
+	data, err := readLimited(r, DefaultMaxFileSize)
+	if err != nil {
+		return err
+	}
 	tomlValue := t.TOMLValue()
-	var err error
-	if _, err = toml.DecodeFile(filePath, tomlValue); err != nil {
+	isJSON, err := decodeValue(data, tomlValue)
+	if err != nil {
 		return err
 	}
+	if gt, ok := tomlValue.(*GroupTOML); ok {
+		if len(gt.Include) > 0 {
+			return errors.New("group: include directives can only be resolved from a file")
+		}
+		if !isJSON {
+			gt.setNodeComments(data)
+		}
+	}
 	return t.FromTOML(tomlValue)
 }
 
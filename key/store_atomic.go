@@ -0,0 +1,234 @@
+package key
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/drand/drand/fs"
+)
+
+const (
+	tmpExtension    = ".tmp"
+	backupExtension = ".bak"
+)
+
+// saveTemp saves the given Tomler to a temporary file next to filePath, synced
+// to disk, and returns its path. It is meant to be renamed to filePath to
+// replace the current file atomically.
+func saveTemp(filePath string, t Tomler, secure bool, codecs []Codec) (string, error) {
+	tmpPath := filePath + tmpExtension
+	fd, err := createFile(tmpPath, secure)
+	if err != nil {
+		return "", fmt.Errorf("config: can't save %s to %s: %w", reflect.TypeOf(t).String(), tmpPath, err)
+	}
+	defer fd.Close()
+	if err = encodeWith(fd, t, codecs); err == nil {
+		err = fd.Sync()
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	return tmpPath, nil
+}
+
+// saveExclusive saves the given Tomler to filePath only if the file does not
+// exist yet, failing with ErrExists otherwise. The content is written aside
+// under a name of its own, then linked in place, which fails if the file was
+// created meanwhile, even by another process.
+func saveExclusive(filePath string, t Tomler, secure bool, codecs []Codec) error {
+	id, err := newTxn()
+	if err != nil {
+		return err
+	}
+	tmpPath, err := saveTemp(filePath+"."+id, t, secure, codecs)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+	if err := os.Link(tmpPath, filePath); os.IsExist(err) {
+		return fmt.Errorf("%w: %s", ErrExists, filePath)
+	} else if err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(filePath))
+	return nil
+}
+
+func createFile(filePath string, secure bool) (*os.File, error) {
+	if secure {
+		return fs.CreateSecureFile(filePath)
+	}
+	return os.Create(filePath)
+}
+
+// atomicWrite replaces a set of files together: either all of them are
+// replaced by their new content, or, on error, all of them are left as they
+// were.
+type atomicWrite struct {
+	// closed, if set, makes the writes fail once the store is closed
+	closed *closeState
+	// codecs applied to the new contents
+	codecs []Codec
+	// privateCodecs, if set, are applied to the secure files instead
+	privateCodecs []Codec
+	// wal, if set, records the write, see WithWriteAheadLog
+	wal     *writeAheadLog
+	txn     string
+	pending []pendingFile
+}
+
+type pendingFile struct {
+	tmp, dst string
+	// hash is the hash of the new content, recorded in the write-ahead log
+	hash string
+	// backup is true if dst existed and was moved to its backup path
+	backup bool
+}
+
+// add writes the new content of filePath aside. On error, all the pending
+// writes are discarded.
+func (a *atomicWrite) add(filePath string, t Tomler, secure bool) error {
+	if err := a.closed.check(); err != nil {
+		a.abort()
+		return err
+	}
+	codecs := a.codecs
+	if secure && a.privateCodecs != nil {
+		codecs = a.privateCodecs
+	}
+	tmp, err := saveTemp(filePath, t, secure, codecs)
+	if err != nil {
+		a.abort()
+		return wrapFileError(filePath, err)
+	}
+	p := pendingFile{tmp: tmp, dst: filePath}
+	if a.wal != nil {
+		if p.hash, err = fileHash(tmp); err != nil {
+			os.Remove(tmp)
+			a.abort()
+			return wrapFileError(filePath, err)
+		}
+	}
+	a.pending = append(a.pending, p)
+	return nil
+}
+
+// logIntents appends the intent records of the pending files to the
+// write-ahead log, if any, before any of them is renamed.
+func (a *atomicWrite) logIntents() error {
+	if a.wal == nil {
+		return nil
+	}
+	txn, err := newTxn()
+	if err != nil {
+		return err
+	}
+	records := make([]walRecord, 0, len(a.pending))
+	for _, p := range a.pending {
+		records = append(records, walRecord{Op: walIntent, Txn: txn, File: p.dst, Hash: p.hash})
+	}
+	if err := a.wal.append(records...); err != nil {
+		return err
+	}
+	a.txn = txn
+	return nil
+}
+
+// logEnd appends the commit or abort record of the write to the write-ahead
+// log, if any. Errors are ignored: the renames are already done or undone, and
+// the next recovery reaches the same outcome from the files.
+func (a *atomicWrite) logEnd(op string) {
+	if a.wal != nil && a.txn != "" {
+		_ = a.wal.close(a.txn, op)
+	}
+}
+
+func (a *atomicWrite) abort() {
+	for _, p := range a.pending {
+		os.Remove(p.tmp)
+	}
+	a.pending = nil
+}
+
+// commit moves all the new contents in place, backing up the current files
+// until all of them are replaced so they can be restored on error.
+func (a *atomicWrite) commit() error {
+	return a.commitVerified(nil)
+}
+
+// commitVerified commits the new contents, then calls verify, if not nil,
+// while the previous files are still backed up. If verify fails, the previous
+// files are restored and its error is returned.
+func (a *atomicWrite) commitVerified(verify func() error) error {
+	if err := a.logIntents(); err != nil {
+		a.abort()
+		return err
+	}
+	var done []pendingFile
+	for _, p := range a.pending {
+		if err := p.replace(); err != nil {
+			for i := len(done) - 1; i >= 0; i-- {
+				done[i].restore()
+			}
+			a.abort()
+			a.logEnd(walAbort)
+			return wrapFileError(p.dst, err)
+		}
+		done = append(done, p)
+	}
+	if verify != nil {
+		if err := verify(); err != nil {
+			for i := len(done) - 1; i >= 0; i-- {
+				done[i].restore()
+			}
+			a.pending = nil
+			a.logEnd(walAbort)
+			return err
+		}
+	}
+	for _, p := range done {
+		if p.backup {
+			os.Remove(p.dst + backupExtension)
+		}
+		syncDir(filepath.Dir(p.dst))
+	}
+	a.pending = nil
+	a.logEnd(walCommit)
+	return nil
+}
+
+func (p *pendingFile) replace() error {
+	if exists, _ := fs.Exists(p.dst); exists {
+		if err := os.Rename(p.dst, p.dst+backupExtension); err != nil {
+			return err
+		}
+		p.backup = true
+	}
+	if err := os.Rename(p.tmp, p.dst); err != nil {
+		p.restore()
+		return err
+	}
+	return nil
+}
+
+func (p *pendingFile) restore() {
+	if p.backup {
+		_ = os.Rename(p.dst+backupExtension, p.dst)
+		return
+	}
+	os.Remove(p.dst)
+}
+
+// syncDir flushes the directory entries so that renames are durable. Errors are
+// ignored as not all platforms support syncing a directory.
+func syncDir(dir string) {
+	fd, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = fd.Sync()
+	fd.Close()
+}
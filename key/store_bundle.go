@@ -0,0 +1,213 @@
+package key
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// bundleVersion is the version of the bundle format written by ExportBundle.
+const bundleVersion = 1
+
+// Bundle gathers the objects of a node in a single self-describing TOML
+// document: its public identity, group and distributed public key, and
+// optionally its private key and share. Absent objects are nil.
+type Bundle struct {
+	Identity   *Identity
+	Group      *Group
+	DistPublic *DistPublic
+	// Pair and Share form the private section of the bundle
+	Pair  *Pair
+	Share *Share
+}
+
+// BundleTOML is the TOML representation of a bundle.
+type BundleTOML struct {
+	Version    int
+	Identity   *PublicTOML        `toml:",omitempty"`
+	Group      *GroupTOML         `toml:",omitempty"`
+	DistPublic *DistPublicTOML    `toml:",omitempty"`
+	Private    *BundlePrivateTOML `toml:",omitempty"`
+}
+
+// BundlePrivateTOML is the TOML representation of the private section of a
+// bundle.
+type BundlePrivateTOML struct {
+	Key   *PairTOML  `toml:",omitempty"`
+	Share *ShareTOML `toml:",omitempty"`
+}
+
+func (b *Bundle) TOML() interface{} {
+	btoml := &BundleTOML{Version: bundleVersion}
+	if b.Identity != nil {
+		btoml.Identity = b.Identity.TOML().(*PublicTOML)
+	}
+	if b.Group != nil {
+		btoml.Group = b.Group.TOML().(*GroupTOML)
+	}
+	if b.DistPublic != nil {
+		btoml.DistPublic = b.DistPublic.TOML().(*DistPublicTOML)
+	}
+	if b.Pair != nil || b.Share != nil {
+		btoml.Private = new(BundlePrivateTOML)
+		if b.Pair != nil {
+			btoml.Private.Key = b.Pair.TOML().(*PairTOML)
+		}
+		if b.Share != nil {
+			btoml.Private.Share = b.Share.TOML().(*ShareTOML)
+		}
+	}
+	return btoml
+}
+
+func (b *Bundle) FromTOML(i interface{}) error {
+	btoml, ok := i.(*BundleTOML)
+	if !ok {
+		return errors.New("bundle can't decode toml from non BundleTOML struct")
+	}
+	if btoml.Version != bundleVersion {
+		return fmt.Errorf("bundle: unsupported version %d", btoml.Version)
+	}
+	*b = Bundle{}
+	if btoml.Identity != nil {
+		b.Identity = new(Identity)
+		if err := b.Identity.FromTOML(btoml.Identity); err != nil {
+			return fmt.Errorf("bundle: identity: %w", err)
+		}
+	}
+	if btoml.Group != nil {
+		if len(btoml.Group.Include) > 0 {
+			return errors.New("bundle: group include directives can't be resolved")
+		}
+		b.Group = new(Group)
+		if err := b.Group.FromTOML(btoml.Group); err != nil {
+			return fmt.Errorf("bundle: group: %w", err)
+		}
+	}
+	if btoml.DistPublic != nil {
+		b.DistPublic = new(DistPublic)
+		if err := b.DistPublic.FromTOML(btoml.DistPublic); err != nil {
+			return fmt.Errorf("bundle: distributed public key: %w", err)
+		}
+	}
+	if btoml.Private == nil {
+		return nil
+	}
+	if btoml.Private.Key != nil {
+		if b.Identity == nil {
+			return errors.New("bundle: private key without its public identity")
+		}
+		b.Pair = new(Pair)
+		if err := b.Pair.FromTOML(btoml.Private.Key); err != nil {
+			return fmt.Errorf("bundle: private key: %w", err)
+		}
+		if !KeyGroup.Point().Mul(b.Pair.Key, nil).Equal(b.Identity.Key) {
+			return fmt.Errorf("bundle: public key of %s does not match the private key", b.Identity.Addr)
+		}
+		b.Pair.Public = b.Identity
+	}
+	if btoml.Private.Share != nil {
+		b.Share = new(Share)
+		if err := b.Share.FromTOML(btoml.Private.Share); err != nil {
+			return fmt.Errorf("bundle: share: %w", err)
+		}
+	}
+	return nil
+}
+
+func (b *Bundle) TOMLValue() interface{} {
+	return &BundleTOML{}
+}
+
+// ExportBundle writes the objects held by the store to w as a bundle, skipping
+// the absent ones. The private key and share are only written if withPrivate
+// is true.
+func ExportBundle(s Store, w io.Writer, withPrivate bool) error {
+	b, err := loadBundle(s, withPrivate)
+	if err != nil {
+		return err
+	}
+	return Encode(w, b)
+}
+
+// loadBundle returns the objects held by the store, the private ones only if
+// withPrivate is true.
+func loadBundle(s Store, withPrivate bool) (*Bundle, error) {
+	b := new(Bundle)
+	if pair, err := s.LoadKeyPair(); err == nil {
+		b.Identity = pair.Public
+		if withPrivate {
+			b.Pair = pair
+		}
+	} else if !errors.Is(err, ErrAbsent) {
+		return nil, err
+	}
+	if group, err := s.LoadGroup(); err == nil {
+		b.Group = group
+	} else if !errors.Is(err, ErrAbsent) {
+		return nil, err
+	}
+	if dist, err := s.LoadDistPublic(); err == nil {
+		b.DistPublic = dist
+	} else if !errors.Is(err, ErrAbsent) {
+		return nil, err
+	}
+	if !withPrivate {
+		return b, nil
+	}
+	if share, err := s.LoadShare(); err == nil {
+		b.Share = share
+	} else if !errors.Is(err, ErrAbsent) {
+		return nil, err
+	}
+	return b, nil
+}
+
+// BackupEncrypted writes all the objects held by the store, the private key
+// and share included, to w as a single bundle encrypted with the passphrase
+// using NewPassphraseCodec, whether or not the store itself is encrypted. The
+// backup can be kept anywhere and is restored with RestoreEncrypted. As in any
+// bundle, the comments annotating the nodes of the group file are not kept.
+func BackupEncrypted(s Store, w io.Writer, passphrase []byte) error {
+	b, err := loadBundle(s, true)
+	if err != nil {
+		return err
+	}
+	return encodeWith(w, b, []Codec{NewPassphraseCodec(passphrase)})
+}
+
+// RestoreEncrypted reads a backup written by BackupEncrypted and saves the
+// objects it holds to the store, replacing the stored ones. The whole backup
+// is decrypted and decoded before the store is touched, so that a wrong
+// passphrase, which fails with ErrDecrypt, or a corrupted backup leave the
+// store as it was.
+func RestoreEncrypted(s Store, r io.Reader, passphrase []byte) error {
+	data, err := decodeWith(r, []Codec{NewPassphraseCodec(passphrase)}, DefaultMaxFileSize)
+	if err != nil {
+		return err
+	}
+	b := new(Bundle)
+	if err := Decode(bytes.NewReader(data), b); err != nil {
+		return err
+	}
+	if b.Pair != nil {
+		if err := s.SaveKeyPair(b.Pair, WithOverwrite(true)); err != nil {
+			return err
+		}
+	}
+	if b.Group != nil {
+		if err := s.SaveGroup(b.Group, WithOverwrite(true)); err != nil {
+			return err
+		}
+	}
+	switch {
+	case b.Share != nil && b.DistPublic != nil:
+		return s.SaveDKGResult(b.Share, b.DistPublic, WithOverwrite(true))
+	case b.Share != nil:
+		return s.SaveShare(b.Share, WithOverwrite(true))
+	case b.DistPublic != nil:
+		return s.SaveDistPublic(b.DistPublic, WithOverwrite(true))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package key
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportBundle(t *testing.T) {
+	ps, group := BatchIdentities(3)
+	store := NewFileStore(t.TempDir(), "")
+	require.NoError(t, store.SaveKeyPair(ps[0]))
+
+	// objects not saved yet are left out
+	var buf bytes.Buffer
+	require.NoError(t, ExportBundle(store, &buf, true))
+	b := new(Bundle)
+	require.NoError(t, Decode(bytes.NewReader(buf.Bytes()), b))
+	require.True(t, b.Pair.Equal(ps[0]))
+	require.Nil(t, b.Group)
+	require.Nil(t, b.Share)
+
+	shares, dist := dealShares(3, 2)
+	group.PublicKey = dist
+	require.NoError(t, store.SaveGroup(group))
+	require.NoError(t, store.SaveDKGResult(shares[0], dist))
+
+	buf.Reset()
+	require.NoError(t, ExportBundle(store, &buf, false))
+	require.NotContains(t, buf.String(), "[Private")
+	require.NoError(t, Decode(bytes.NewReader(buf.Bytes()), b))
+	require.True(t, b.Identity.Equal(ps[0].Public))
+	require.Nil(t, b.Pair)
+	require.Nil(t, b.Share)
+	require.Equal(t, group.Hash(), b.Group.Hash())
+	require.True(t, b.DistPublic.Equal(dist))
+}
+
+func TestBundleDecodeErrors(t *testing.T) {
+	ps, _ := BatchIdentities(2)
+	other, _ := BatchIdentities(1)
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, &Bundle{Identity: ps[0].Public, Pair: ps[0]}))
+	valid := buf.String()
+
+	for name, data := range map[string]string{
+		"unknown version": strings.Replace(valid, "Version = 1", "Version = 2", 1),
+		"no version":      strings.Replace(valid, "Version = 1", "", 1),
+	} {
+		require.Error(t, Decode(strings.NewReader(data), new(Bundle)), name)
+	}
+
+	buf.Reset()
+	require.NoError(t, Encode(&buf, &Bundle{Identity: ps[1].Public, Pair: other[0]}))
+	require.Error(t, Decode(&buf, new(Bundle)))
+
+	buf.Reset()
+	require.NoError(t, Encode(&buf, &Bundle{Pair: ps[0]}))
+	require.Error(t, Decode(&buf, new(Bundle)))
+}
+
+func TestBackupEncrypted(t *testing.T) {
+	ps, group := BatchIdentities(3)
+	shares, dist := dealShares(3, 2)
+	group.PublicKey = dist
+	passphrase := []byte("correct horse battery staple")
+	// the live store is encrypted under another passphrase
+	live := encryptedStore(t, NewFileStore(t.TempDir(), ""), []byte("live passphrase"))
+	require.NoError(t, live.SaveKeyPair(ps[0]))
+	require.NoError(t, live.SaveGroup(group))
+	require.NoError(t, live.SaveDKGResult(shares[0], dist))
+
+	var backup bytes.Buffer
+	require.NoError(t, BackupEncrypted(live, &backup, passphrase))
+	require.True(t, bytes.HasPrefix(backup.Bytes(), encryptionMagic))
+	require.NotContains(t, backup.String(), ps[0].Public.Addr)
+
+	// a wrong passphrase leaves the store untouched
+	restored := NewFileStore(t.TempDir(), "")
+	err := RestoreEncrypted(restored, bytes.NewReader(backup.Bytes()), []byte("wrong"))
+	require.ErrorIs(t, err, ErrDecrypt)
+	_, err = restored.LoadKeyPair()
+	require.ErrorIs(t, err, ErrAbsent)
+	_, err = restored.LoadGroup()
+	require.ErrorIs(t, err, ErrAbsent)
+	corrupted := append([]byte{}, backup.Bytes()...)
+	corrupted[len(corrupted)-1] ^= 1
+	require.ErrorIs(t, RestoreEncrypted(restored, bytes.NewReader(corrupted), passphrase), ErrDecrypt)
+
+	require.NoError(t, RestoreEncrypted(restored, bytes.NewReader(backup.Bytes()), passphrase))
+	pair, err := restored.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, pair.Equal(ps[0]))
+	loadedGroup, err := restored.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loadedGroup.Equal(group))
+	loadedShare, err := restored.LoadShare()
+	require.NoError(t, err)
+	require.True(t, loadedShare.Share.V.Equal(shares[0].Share.V))
+	loadedDist, err := restored.LoadDistPublic()
+	require.NoError(t, err)
+	require.True(t, loadedDist.Equal(dist))
+
+	// restoring replaces the objects of a used store
+	require.NoError(t, restored.SaveShare(shares[1], WithOverwrite(true)))
+	require.NoError(t, RestoreEncrypted(restored, bytes.NewReader(backup.Bytes()), passphrase))
+	loadedShare, err = restored.LoadShare()
+	require.NoError(t, err)
+	require.Equal(t, shares[0].Share.I, loadedShare.Share.I)
+}
@@ -0,0 +1,70 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+)
+
+// groupCandidate is a group file considered by LoadGroup.
+type groupCandidate struct {
+	file  string
+	info  os.FileInfo
+	group *Group
+}
+
+// newer returns true if the candidate holds a newer group than the other one:
+// groups with a later transition time come from a later resharing, and the
+// groups of the same epoch are ordered by the modification time of their
+// files.
+func (c *groupCandidate) newer(other *groupCandidate) bool {
+	if c.group.TransitionTime != other.group.TransitionTime {
+		return c.group.TransitionTime > other.group.TransitionTime
+	}
+	return c.info.ModTime().After(other.info.ModTime())
+}
+
+// loadNewestGroup loads the group file and the candidate files and returns the
+// newest valid group. Missing files are ignored, and files which can't be
+// loaded or hold an invalid group are skipped with a warning. It fails if no
+// file holds a valid group.
+func (f *fileStore) loadNewestGroup() (*Group, error) {
+	files := []string{f.groupFile}
+	for _, name := range f.groupCandidates {
+		files = append(files, path.Join(path.Dir(f.groupFile), name))
+	}
+	var newest *groupCandidate
+	var skipped error
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		c := &groupCandidate{file: file, info: info, group: new(Group)}
+		if err == nil {
+			err = f.loadGroupFile(file, c.group)
+		}
+		if err == nil {
+			err = c.group.Valid()
+		}
+		if err != nil {
+			f.log.Warnw("", "store", "skipping invalid group candidate", "file", file, "err", err)
+			skipped = wrapFileError(file, err)
+			continue
+		}
+		if newest == nil || c.newer(newest) {
+			newest = c
+		}
+	}
+	switch {
+	case newest != nil:
+		f.log.Infow("", "store", "loaded newest group candidate", "file", newest.file,
+			"transition", newest.group.TransitionTime, "modified", newest.info.ModTime())
+		return newest.group, nil
+	case skipped != nil:
+		return nil, fmt.Errorf("store: no valid group among the candidates: %w", skipped)
+	default:
+		return nil, wrapFileError(f.groupFile, os.ErrNotExist)
+	}
+}
@@ -0,0 +1,56 @@
+package key
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreGroupCandidates(t *testing.T) {
+	store := NewFileStore(t.TempDir(), "", WithGroupCandidates("drand_group.new.toml", "drand_group.other.toml"))
+	f := store.(*fileStore)
+	newFile := path.Join(path.Dir(f.groupFile), "drand_group.new.toml")
+	otherFile := path.Join(path.Dir(f.groupFile), "drand_group.other.toml")
+	_, err := store.LoadGroup()
+	require.ErrorIs(t, err, ErrAbsent)
+
+	_, current := BatchIdentities(3)
+	require.NoError(t, store.SaveGroup(current))
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(current))
+
+	// a newer epoch wins even with an older file
+	_, next := BatchIdentities(4)
+	next.TransitionTime = current.GenesisTime + 100
+	require.NoError(t, Save(newFile, next, false))
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(newFile, past, past))
+	loaded, err = store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(next))
+
+	// invalid candidates are skipped
+	require.NoError(t, os.WriteFile(otherFile, []byte("not a group"), 0o600))
+	loaded, err = store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(next))
+
+	// within the same epoch, the most recently modified file wins
+	_, other := BatchIdentities(5)
+	other.TransitionTime = next.TransitionTime
+	require.NoError(t, Save(otherFile, other, false))
+	loaded, err = store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(other))
+
+	// no valid candidate at all
+	for _, file := range []string{f.groupFile, newFile, otherFile} {
+		require.NoError(t, os.WriteFile(file, []byte("not a group"), 0o600))
+	}
+	_, err = store.LoadGroup()
+	require.ErrorIs(t, err, ErrStoreFile)
+}
@@ -0,0 +1,86 @@
+package key
+
+import (
+	"io"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// closeState tells whether a store was closed. It is shared by the stores
+// derived from the same one, e.g. through WithCodec. A nil state is open.
+type closeState struct {
+	closed int32
+}
+
+func newCloseState() *closeState {
+	return new(closeState)
+}
+
+// close marks the store as closed. It returns false if it already was.
+func (c *closeState) close() bool {
+	return c != nil && atomic.CompareAndSwapInt32(&c.closed, 0, 1)
+}
+
+// check returns ErrClosed once the store is closed.
+func (c *closeState) check() error {
+	if c != nil && atomic.LoadInt32(&c.closed) == 1 {
+		return ErrClosed
+	}
+	return nil
+}
+
+// readOnly returns the error of the modifications of a read-only store.
+func (c *closeState) readOnly() error {
+	if err := c.check(); err != nil {
+		return err
+	}
+	return ErrReadOnly
+}
+
+// Close waits for the running read-modify-write operations, then flushes the
+// folders of the store, so that the creations and renames of the last saves
+// are durable. The content of the files is synced by every save before it
+// returns, so nothing else is pending.
+func (f *fileStore) Close() error {
+	f.lock()
+	defer f.unlock()
+	if !f.closed.close() {
+		return nil
+	}
+	f.observer.OnClose()
+	for _, file := range []string{f.privateKeyFile, f.publicKeyFile, f.shareFile, f.groupFile} {
+		syncDir(filepath.Dir(file))
+	}
+	return nil
+}
+
+func (k *keyringStore) Close() error {
+	return k.files.Close()
+}
+
+func (r *retryingStore) Close() error {
+	return r.inner.Close()
+}
+
+// Close closes the backend too if it implements io.Closer.
+func (o *objectStore) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.closed.close() {
+		return nil
+	}
+	if c, ok := o.backend.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (e *embeddedStore) Close() error {
+	e.closed.close()
+	return nil
+}
+
+func (s *stdinStore) Close() error {
+	s.closed.close()
+	return nil
+}
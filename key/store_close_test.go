@@ -0,0 +1,55 @@
+package key
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreClose(t *testing.T) {
+	store := NewFileStore(t.TempDir(), "")
+	compressed := compressedStore(t, store)
+	pair, err := Init(store, "127.0.0.1:8080")
+	require.NoError(t, err)
+	_, group := BatchIdentities(3)
+	require.NoError(t, store.SaveGroup(group))
+
+	require.NoError(t, store.Close())
+	require.NoError(t, store.Close())
+	_, err = store.LoadKeyPair()
+	require.ErrorIs(t, err, ErrClosed)
+	require.False(t, IsRetryable(err))
+	require.ErrorIs(t, store.SaveKeyPair(pair, WithOverwrite(true)), ErrClosed)
+	require.ErrorIs(t, store.SaveGroup(group), ErrClosed)
+	shares, dist := dealShares(3, 2)
+	require.ErrorIs(t, store.SaveDKGResult(shares[0], dist), ErrClosed)
+	require.ErrorIs(t, store.Reset(), ErrClosed)
+	// derived stores are closed along
+	_, err = compressed.LoadGroup()
+	require.ErrorIs(t, err, ErrClosed)
+	_, err = NewRetryingStore(store, RetryPolicy{MaxAttempts: 3}).LoadGroup()
+	require.ErrorIs(t, err, ErrClosed)
+
+	// closing did not lose anything
+	reopened := NewFileStore(store.(*fileStore).baseFolder, "")
+	loaded, err := reopened.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(group))
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, &Bundle{Group: group}))
+	stdin, err := NewStdinStore(&buf)
+	require.NoError(t, err)
+	require.ErrorIs(t, stdin.SaveGroup(group), ErrReadOnly)
+	require.NoError(t, stdin.Close())
+	_, err = stdin.LoadGroup()
+	require.ErrorIs(t, err, ErrClosed)
+	require.ErrorIs(t, stdin.SaveGroup(group), ErrClosed)
+
+	embedded := NewEmbeddedStore(fstest.MapFS{}, ".")
+	require.NoError(t, embedded.Close())
+	_, err = embedded.LoadShare()
+	require.ErrorIs(t, err, ErrClosed)
+}
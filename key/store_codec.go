@@ -0,0 +1,235 @@
+package key
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Codec transforms the serialized form of the objects of a store on their way
+// to and from its backend, e.g. to compress them.
+type Codec interface {
+	// NewWriter returns a writer transforming the data written to it before
+	// writing it to w. Closing it must flush the data without closing w.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	// NewReader returns a reader reverting the transformation of the data
+	// read from r.
+	NewReader(r io.Reader) (io.Reader, error)
+}
+
+// CodecStore is implemented by stores whose serialized objects can go through
+// codecs.
+type CodecStore interface {
+	Store
+	// WithCodec returns a store using the same backend whose objects also go
+	// through the given codec. The codec is applied before the codecs
+	// already used by the store when writing, and after them when reading.
+	WithCodec(c Codec) Store
+}
+
+// NewCompressedStore returns a store compressing all the objects with gzip
+// before handing them to the inner store, which must be a CodecStore as the
+// file, object, keyring and embedded stores are, or a retrying store around
+// one, or the error wraps ErrNoCodecs. The compression
+// happens before any other transformation of the inner store, such as an
+// encryption.
+func NewCompressedStore(inner Store) (Store, error) {
+	return withCodec(inner, gzipCodec{})
+}
+
+func withCodec(inner Store, c Codec) (Store, error) {
+	if r, ok := inner.(*retryingStore); ok {
+		wrapped, err := withCodec(r.inner, c)
+		if err != nil {
+			return nil, err
+		}
+		derived := *r
+		derived.inner = wrapped
+		return &derived, nil
+	}
+	cs, ok := inner.(CodecStore)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrNoCodecs, inner)
+	}
+	return cs.WithCodec(c), nil
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (gzipCodec) NewReader(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+// encodeWith encodes t to w through the given codecs.
+func encodeWith(w io.Writer, t Tomler, codecs []Codec) error {
+	// the first codec gets the encoded object, so it is the outermost writer
+	writers := make([]io.WriteCloser, 0, len(codecs))
+	for i := len(codecs) - 1; i >= 0; i-- {
+		cw, err := codecs[i].NewWriter(w)
+		if err != nil {
+			return err
+		}
+		writers = append(writers, cw)
+		w = cw
+	}
+	if err := Encode(w, t); err != nil {
+		return err
+	}
+	for i := len(writers) - 1; i >= 0; i-- {
+		if err := writers[i].Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeWith returns the serialized object read from r through the given
+// codecs. Both the data read from r and the decoded object are limited to max
+// bytes.
+func decodeWith(r io.Reader, codecs []Codec, max int64) ([]byte, error) {
+	r = &sizeLimitedReader{r: r, n: max}
+	for i := len(codecs) - 1; i >= 0; i-- {
+		cr, err := codecs[i].NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		r = cr
+	}
+	return readLimited(r, max)
+}
+
+func (f *fileStore) WithCodec(c Codec) Store {
+	derived := *f
+	derived.codecs = append([]Codec{c}, f.codecs...)
+	return &derived
+}
+
+func (f *fileStore) save(filePath string, t Tomler, secure bool) error {
+	codecs, err := f.codecsFor(secure)
+	if err != nil {
+		return err
+	}
+	if f.journalSaves {
+		w := &atomicWrite{closed: f.closed, wal: f.wal, codecs: codecs}
+		if err := w.add(filePath, t, secure); err != nil {
+			return err
+		}
+		return w.commit()
+	}
+	return wrapFileError(filePath, save(filePath, t, secure, codecs))
+}
+
+// saveExclusive saves the object as save does, unless the file exists, see
+// saveExclusive.
+func (f *fileStore) saveExclusive(filePath string, t Tomler, secure bool) error {
+	if err := f.closed.check(); err != nil {
+		return err
+	}
+	codecs, err := f.codecsFor(secure)
+	if err != nil {
+		return err
+	}
+	return wrapFileError(filePath, saveExclusive(filePath, t, secure, codecs))
+}
+
+func (f *fileStore) load(filePath string, t Tomler) error {
+	return wrapFileError(filePath, f.loadFile(filePath, t))
+}
+
+func (f *fileStore) loadFile(filePath string, t Tomler) error {
+	data, err := f.readData(filePath)
+	if err != nil {
+		return err
+	}
+	return loadData(filePath, data, t, false)
+}
+
+// readData returns the content of the file decoded by the codecs of the store.
+// With a read timeout, the file is read on its own goroutine, which is left
+// behind if the read does not complete in time.
+func (f *fileStore) readData(filePath string) ([]byte, error) {
+	if f.readTimeout <= 0 {
+		return f.readDecoded(filePath)
+	}
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		data, err := f.readDecoded(filePath)
+		done <- result{data, err}
+	}()
+	select {
+	case r := <-done:
+		return r.data, r.err
+	case <-f.clock.After(f.readTimeout):
+		return nil, fmt.Errorf("%w: reading %s for more than %s", ErrTimeout, filePath, f.readTimeout)
+	}
+}
+
+func (f *fileStore) readDecoded(filePath string) ([]byte, error) {
+	codecs, err := f.codecsFor(isPrivateFile(filePath))
+	if err != nil {
+		return nil, err
+	}
+	fd, err := openLimited(filePath, f.maxFileSize)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	data, err := decodeAny(fd, codecs, f.maxFileSize)
+	if err != nil {
+		return nil, fmt.Errorf("config: can't decode %s: %w", filePath, err)
+	}
+	return data, nil
+}
+
+// sizeLimitedReader reads from r until more than n bytes were read, when it
+// fails with ErrTooLarge.
+type sizeLimitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *sizeLimitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	if l.n -= int64(n); l.n < 0 {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
+
+// readLimited reads r until EOF, failing with ErrTooLarge after max bytes.
+func readLimited(r io.Reader, max int64) ([]byte, error) {
+	data, err := io.ReadAll(&sizeLimitedReader{r: r, n: max})
+	if errors.Is(err, ErrTooLarge) {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, max)
+	}
+	return data, err
+}
+
+// openLimited opens the file after checking it is not larger than max bytes.
+func openLimited(filePath string, max int64) (*os.File, error) {
+	fd, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := fd.Stat(); err == nil && info.Mode().IsRegular() && info.Size() > max {
+		fd.Close()
+		return nil, fmt.Errorf("%w: %s holds %d bytes, more than %d", ErrTooLarge, filePath, info.Size(), max)
+	}
+	return fd, nil
+}
@@ -0,0 +1,115 @@
+package key
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/drand/drand/common"
+	"github.com/stretchr/testify/require"
+)
+
+func requireGzipped(t *testing.T, filePath string) {
+	t.Helper()
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Greater(t, len(data), 2)
+	require.Equal(t, []byte{0x1f, 0x8b}, data[:2], "%s is not gzipped", filePath)
+}
+
+func compressedStore(t *testing.T, inner Store) Store {
+	t.Helper()
+	store, err := NewCompressedStore(inner)
+	require.NoError(t, err)
+	return store
+}
+
+func TestCompressedStore(t *testing.T) {
+	pairs, group := BatchIdentities(3)
+	shares, dist := dealShares(3, 2)
+	group.PublicKey = dist
+	group.Nodes[0].Comment = "run by org A"
+	base := t.TempDir()
+	inner := NewFileStore(base, "").(*fileStore)
+	store := compressedStore(t, inner)
+
+	require.NoError(t, store.SaveKeyPair(pairs[0]))
+	loadedPair, err := store.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, loadedPair.Key.Equal(pairs[0].Key))
+	require.True(t, loadedPair.Public.Equal(pairs[0].Public))
+
+	require.NoError(t, store.SaveShare(shares[0]))
+	loadedShare, err := store.LoadShare()
+	require.NoError(t, err)
+	require.True(t, loadedShare.Share.V.Equal(shares[0].Share.V))
+
+	require.NoError(t, store.SaveGroup(group))
+	loadedGroup, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loadedGroup.Equal(group))
+	require.Equal(t, "run by org A", loadedGroup.Find(group.Nodes[0].Identity).Comment)
+
+	require.NoError(t, store.SaveDistPublic(dist))
+	loadedDist, err := store.LoadDistPublic()
+	require.NoError(t, err)
+	require.True(t, loadedDist.Equal(dist))
+
+	require.NoError(t, store.SaveDKGResult(shares[1], dist, WithOverwrite(true)))
+	loadedShare, err = store.LoadShare()
+	require.NoError(t, err)
+	require.Equal(t, shares[1].Share.I, loadedShare.Share.I)
+
+	for _, f := range []string{inner.privateKeyFile, inner.publicKeyFile, inner.shareFile, inner.groupFile, inner.distKeyFile} {
+		requireGzipped(t, f)
+	}
+	// the inner store alone detects they are compressed
+	loadedGroup, err = inner.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loadedGroup.Equal(group))
+
+	// the embedded store can be wrapped as well
+	embedded := compressedStore(t, NewEmbeddedStore(os.DirFS(base), common.DefaultBeaconID))
+	loadedGroup, err = embedded.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loadedGroup.Equal(group))
+
+	_, err = NewCompressedStore(&nonCodecStore{store})
+	require.ErrorIs(t, err, ErrNoCodecs)
+}
+
+type nonCodecStore struct {
+	Store
+}
+
+func TestStoreMaxFileSize(t *testing.T) {
+	_, group := BatchIdentities(10)
+	store := NewFileStore(t.TempDir(), "", WithMaxFileSize(1024))
+	require.NoError(t, store.SaveGroup(group))
+	info, err := os.Stat(store.(*fileStore).groupFile)
+	require.NoError(t, err)
+	require.Greater(t, info.Size(), int64(1024))
+	_, err = store.LoadGroup()
+	require.ErrorIs(t, err, ErrTooLarge)
+
+	// the limit applies to the decoded object too
+	base := t.TempDir()
+	compressed := compressedStore(t, NewFileStore(base, ""))
+	require.NoError(t, compressed.SaveGroup(group))
+	info, err = os.Stat(compressed.(*fileStore).groupFile)
+	require.NoError(t, err)
+	var plain bytes.Buffer
+	require.NoError(t, Encode(&plain, group))
+	require.Less(t, info.Size(), int64(plain.Len()))
+	limited := compressedStore(t, NewFileStore(base, "", WithMaxFileSize(info.Size())))
+	_, err = limited.LoadGroup()
+	require.ErrorIs(t, err, ErrTooLarge)
+
+	// reader-based loads
+	require.ErrorIs(t, Decode(bytes.NewReader(make([]byte, DefaultMaxFileSize+1)), new(Group)), ErrTooLarge)
+	data, err := readLimited(bytes.NewReader([]byte("1234")), 4)
+	require.NoError(t, err)
+	require.Equal(t, []byte("1234"), data)
+	_, err = readLimited(bytes.NewReader([]byte("12345")), 4)
+	require.ErrorIs(t, err, ErrTooLarge)
+}
@@ -0,0 +1,155 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInconsistent is returned when the objects of a store don't agree with each
+// other, or when its private files are not protected enough.
+var ErrInconsistent = errors.New("store: inconsistent objects")
+
+// WithStartupValidation makes OpenFileStore check the consistency of the store,
+// see CheckConsistency, and the permissions of its private files before
+// returning it.
+func WithStartupValidation() StoreOption {
+	return func(f *fileStore) {
+		f.startupValidation = true
+	}
+}
+
+// OpenFileStore returns the file store as NewFileStore does. With
+// WithStartupValidation, it also refuses to open a store whose objects are not
+// consistent, so that a misconfigured node fails at once with a description of
+// all the problems found. It also fails if the saves interrupted by a crash
+// can't be completed or rolled back, which NewFileStore only logs.
+func OpenFileStore(baseFolder, beaconID string, opts ...StoreOption) (Store, error) {
+	s := NewFileStore(baseFolder, beaconID, opts...)
+	f := s.(*fileStore)
+	if err := f.wal.recover(); err != nil {
+		return nil, err
+	}
+	if !f.startupValidation {
+		return s, nil
+	}
+	problems := f.permissionProblems()
+	var inconsistent *inconsistencyError
+	if errors.As(CheckConsistency(s), &inconsistent) {
+		problems = append(problems, inconsistent.problems...)
+	}
+	if len(problems) > 0 {
+		return nil, &inconsistencyError{problems: problems}
+	}
+	return s, nil
+}
+
+type inconsistencyError struct {
+	problems []string
+}
+
+func (e *inconsistencyError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrInconsistent, strings.Join(e.problems, "; "))
+}
+
+func (e *inconsistencyError) Is(target error) bool {
+	return target == ErrInconsistent
+}
+
+// CheckConsistency checks the objects held by the store agree with each other:
+// the public key of the node is the one of its private key, the group is valid
+// and, once a DKG ran, the node is part of the group, its share matches its
+// index and the distributed public key and was dealt under the threshold of the
+// group, and the group holds that same key.
+// Missing objects are not an error, as a node misses some of them until its
+// first DKG. It returns an error wrapping ErrInconsistent and listing all the
+// problems found.
+func CheckConsistency(s Store) error {
+	var c consistencyCheck
+	pair, err := s.LoadKeyPair()
+	if !c.loaded("key pair", err) {
+		pair = nil
+	} else if !KeyGroup.Point().Mul(pair.Key, nil).Equal(pair.Public.Key) {
+		c.add("public key does not match the private key")
+	}
+	group, err := s.LoadGroup()
+	if !c.loaded("group", err) {
+		group = nil
+	} else if err := group.Valid(); err != nil {
+		c.add("invalid group: %v", err)
+	}
+	share, err := s.LoadShare()
+	if !c.loaded("share", err) {
+		share = nil
+	}
+	dist, err := s.LoadDistPublic()
+	if !c.loaded("distributed public key", err) {
+		dist = nil
+	}
+
+	switch {
+	case share != nil && (group == nil || dist == nil):
+		c.add("share stored without its group or distributed public key")
+	case share != nil:
+		if pair != nil && group.Find(pair.Public) == nil {
+			c.add("node %s holds a share but is not part of the group", pair.Public.Addr)
+		}
+		if err := share.VerifyAgainst(dist, group); err != nil {
+			c.add("share does not match the group: %v", err)
+		}
+		if err := share.VerifyThreshold(group); err != nil {
+			c.add("%v", err)
+		}
+	}
+	if group != nil && dist != nil && group.PublicKey != nil && !group.PublicKey.Equal(dist) {
+		c.add("distributed public key differs from the one of the group")
+	}
+	return c.err()
+}
+
+// consistencyCheck collects the problems found by CheckConsistency.
+type consistencyCheck struct {
+	problems []string
+}
+
+func (c *consistencyCheck) add(format string, args ...interface{}) {
+	c.problems = append(c.problems, fmt.Sprintf(format, args...))
+}
+
+// loaded returns true if the object was loaded, and records the error if it
+// could not be loaded for another reason than being absent.
+func (c *consistencyCheck) loaded(object string, err error) bool {
+	if err != nil && !errors.Is(err, ErrAbsent) {
+		c.add("loading the %s: %v", object, err)
+	}
+	return err == nil
+}
+
+func (c *consistencyCheck) err() error {
+	if len(c.problems) == 0 {
+		return nil
+	}
+	return &inconsistencyError{problems: c.problems}
+}
+
+// permissionProblems lists the private files and folders of the store that
+// other users can access.
+func (f *fileStore) permissionProblems() []string {
+	var problems []string
+	for _, file := range []string{f.privateKeyFile, f.shareFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if perm := info.Mode().Perm(); perm&0o077 != 0 {
+			problems = append(problems, fmt.Sprintf("private file %s is accessible to other users (%#o)", file, perm))
+		}
+		dir := filepath.Dir(file)
+		if info, err := os.Stat(dir); err == nil && info.Mode().Perm()&0o007 != 0 {
+			problems = append(problems, fmt.Sprintf("private folder %s is accessible to all users (%#o)", dir, info.Mode().Perm()))
+		}
+	}
+	return problems
+}
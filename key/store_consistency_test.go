@@ -0,0 +1,80 @@
+package key
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenFileStoreValidation(t *testing.T) {
+	base := t.TempDir()
+	_, err := OpenFileStore(base, "", WithStartupValidation())
+	require.NoError(t, err)
+
+	pairs, group := BatchIdentities(3)
+	shares, dist := dealShares(3, group.Threshold)
+	group.PublicKey = dist
+	store := NewFileStore(base, "")
+	require.NoError(t, store.SaveKeyPair(pairs[0]))
+	require.NoError(t, store.SaveGroup(group))
+	require.NoError(t, store.SaveDKGResult(shares[0], dist))
+	_, err = OpenFileStore(base, "", WithStartupValidation())
+	require.NoError(t, err)
+	require.NoError(t, CheckConsistency(store))
+
+	// a share of another DKG, and a private key readable by all
+	otherShares, otherDist := dealShares(3, group.Threshold)
+	require.NoError(t, store.SaveDKGResult(otherShares[1], otherDist, WithOverwrite(true)))
+	require.NoError(t, os.Chmod(store.(*fileStore).privateKeyFile, 0o644))
+
+	_, err = OpenFileStore(base, "", WithStartupValidation())
+	require.ErrorIs(t, err, ErrInconsistent)
+	for _, problem := range []string{
+		"share does not match the group",
+		"distributed public key differs from the one of the group",
+		"is accessible to other users",
+	} {
+		require.Contains(t, err.Error(), problem)
+	}
+	err = CheckConsistency(store)
+	require.ErrorIs(t, err, ErrInconsistent)
+	require.NotContains(t, err.Error(), "accessible")
+
+	// the lenient constructor still opens the store
+	_, err = OpenFileStore(base, "")
+	require.NoError(t, err)
+
+	// a share stored for a node that is not part of the group
+	store = NewFileStore(t.TempDir(), "")
+	stranger := NewKeyPair("127.0.0.1:9999")
+	require.NoError(t, store.SaveKeyPair(stranger))
+	require.NoError(t, store.SaveGroup(group))
+	require.NoError(t, store.SaveDKGResult(shares[0], dist))
+	err = CheckConsistency(store)
+	require.ErrorIs(t, err, ErrInconsistent)
+	require.Contains(t, err.Error(), "is not part of the group")
+}
+
+func TestCheckConsistencyShareThreshold(t *testing.T) {
+	pairs, group := BatchIdentities(4)
+	group.Threshold = 3
+	// a share of a configuration with a higher threshold
+	shares, dist := dealShares(4, 4)
+	group.PublicKey = dist
+	require.ErrorIs(t, shares[0].VerifyThreshold(group), ErrShareThreshold)
+
+	store := NewFileStore(t.TempDir(), "")
+	require.NoError(t, store.SaveKeyPair(pairs[0]))
+	require.NoError(t, store.SaveGroup(group))
+	require.NoError(t, store.SaveDKGResult(shares[0], dist))
+	err := CheckConsistency(store)
+	require.ErrorIs(t, err, ErrInconsistent)
+	require.Contains(t, err.Error(), "share dealt with threshold 4, the group has threshold 3")
+	require.NotContains(t, err.Error(), "share does not match the group")
+
+	group.Threshold = 4
+	require.NoError(t, shares[0].VerifyThreshold(group))
+	require.NoError(t, store.SaveGroup(group))
+	require.NoError(t, CheckConsistency(store))
+}
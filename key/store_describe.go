@@ -0,0 +1,132 @@
+package key
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ObjectDescriber is implemented by stores able to describe the objects they
+// hold without loading them, e.g. for a detailed status display.
+type ObjectDescriber interface {
+	// DescribeObject returns the metadata of the object of the given kind.
+	// It returns an error wrapping ErrAbsent if the store does not hold it.
+	DescribeObject(kind StoreKind) (ObjectInfo, error)
+}
+
+// Formats of the stored objects reported by DescribeObject.
+const (
+	FormatTOML      = "toml"
+	FormatJSON      = "json"
+	FormatGzip      = "gzip"
+	FormatEncrypted = "encrypted"
+)
+
+// ObjectInfo describes an object held by a store.
+type ObjectInfo struct {
+	Kind StoreKind
+	// Path is the file holding the object, the private one for the key pair.
+	Path string
+	// Format is the outermost format of the file: a compressed or encrypted
+	// file is not decoded to tell the format of its content.
+	Format  string
+	Size    int64
+	ModTime time.Time
+	// Fingerprint is the hash of the public object: the hash of the group, of
+	// the distributed public key, or of the public identity of the key pair.
+	// It is nil for the share, which has no public part of its own, and for a
+	// key pair whose identity is kept in the private file, as private files
+	// are never read.
+	Fingerprint []byte
+}
+
+// describeHeaderSize is the number of bytes read to tell the format of a file.
+const describeHeaderSize = 512
+
+// DescribeObject returns the metadata of the file holding the object of the
+// given kind. Private files are only inspected for their size, modification
+// time and header, their content is never decoded.
+func (f *fileStore) DescribeObject(kind StoreKind) (ObjectInfo, error) {
+	if err := f.closed.check(); err != nil {
+		return ObjectInfo{}, err
+	}
+	info := ObjectInfo{Kind: kind}
+	var public Tomler
+	switch kind {
+	case KeyPairKind:
+		info.Path = f.privateKeyFile
+		if f.separatePublicFile {
+			public = new(Identity)
+		}
+	case ShareKind:
+		info.Path = f.shareFile
+	case GroupKind:
+		info.Path = f.groupFile
+		public = new(Group)
+	case DistPublicKind:
+		info.Path = f.distKeyFile
+		public = new(DistPublic)
+	default:
+		return ObjectInfo{}, fmt.Errorf("store: unknown object kind %d", kind)
+	}
+
+	stat, err := os.Stat(info.Path)
+	if err != nil {
+		return ObjectInfo{}, wrapFileError(info.Path, err)
+	}
+	info.Size = stat.Size()
+	info.ModTime = stat.ModTime()
+	if info.Format, err = fileFormat(info.Path); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	switch p := public.(type) {
+	case *Identity:
+		if err := f.load(f.publicKeyFile, p); err != nil {
+			return ObjectInfo{}, err
+		}
+		info.Fingerprint = p.Hash()
+	case *Group:
+		if err := f.load(f.groupFile, p); err != nil {
+			return ObjectInfo{}, err
+		}
+		info.Fingerprint = p.Hash()
+	case *DistPublic:
+		if err := f.load(f.distKeyFile, p); err != nil {
+			return ObjectInfo{}, err
+		}
+		info.Fingerprint = p.Hash()
+	}
+	return info, nil
+}
+
+// fileFormat tells the outermost format of the file from its header.
+func fileFormat(filePath string) (string, error) {
+	fd, err := os.Open(filePath)
+	if err != nil {
+		return "", wrapFileError(filePath, err)
+	}
+	defer fd.Close()
+	header := make([]byte, describeHeaderSize)
+	n, err := io.ReadFull(fd, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", wrapFileError(filePath, err)
+	}
+	header = header[:n]
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return FormatGzip, nil
+	case bytes.HasPrefix(header, encryptionMagic):
+		return FormatEncrypted, nil
+	case isJSON(header):
+		return FormatJSON, nil
+	case bytes.IndexByte(header, 0) < 0:
+		// the header may end in the middle of a rune, so it is not checked
+		// as UTF-8
+		return FormatTOML, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownFormat, filePath)
+	}
+}
@@ -0,0 +1,56 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreDescribeObject(t *testing.T) {
+	store := NewFileStore(t.TempDir(), "")
+	describer := store.(ObjectDescriber)
+	for _, kind := range []StoreKind{KeyPairKind, ShareKind, GroupKind, DistPublicKind} {
+		_, err := describer.DescribeObject(kind)
+		require.ErrorIs(t, err, ErrAbsent)
+	}
+
+	pair, err := Init(store, "127.0.0.1:8080")
+	require.NoError(t, err)
+	info, err := describer.DescribeObject(KeyPairKind)
+	require.NoError(t, err)
+	require.Equal(t, KeyPairKind, info.Kind)
+	require.Equal(t, store.(*fileStore).privateKeyFile, info.Path)
+	require.Equal(t, FormatTOML, info.Format)
+	require.NotZero(t, info.Size)
+	require.False(t, info.ModTime.IsZero())
+	require.Equal(t, pair.Public.Hash(), info.Fingerprint)
+
+	// the share is never read, so it can't be fingerprinted
+	shares, dist := dealShares(3, 2)
+	require.NoError(t, store.SaveDKGResult(shares[0], dist))
+	info, err = describer.DescribeObject(ShareKind)
+	require.NoError(t, err)
+	require.Equal(t, FormatTOML, info.Format)
+	require.Nil(t, info.Fingerprint)
+	info, err = describer.DescribeObject(DistPublicKind)
+	require.NoError(t, err)
+	require.Equal(t, dist.Hash(), info.Fingerprint)
+
+	compressed := compressedStore(t, store)
+	_, group := BatchIdentities(3)
+	require.NoError(t, compressed.SaveGroup(group))
+	info, err = compressed.(ObjectDescriber).DescribeObject(GroupKind)
+	require.NoError(t, err)
+	require.Equal(t, FormatGzip, info.Format)
+	require.Equal(t, group.Hash(), info.Fingerprint)
+
+	encrypted := NewFileStore(t.TempDir(), "").(CodecStore).WithCodec(NewPassphraseCodec([]byte("secret")))
+	_, err = Init(encrypted, "127.0.0.1:8080")
+	require.NoError(t, err)
+	info, err = encrypted.(ObjectDescriber).DescribeObject(KeyPairKind)
+	require.NoError(t, err)
+	require.Equal(t, FormatEncrypted, info.Format)
+
+	_, err = describer.DescribeObject(StoreKind(42))
+	require.Error(t, err)
+}
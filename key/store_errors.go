@@ -0,0 +1,90 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// The errors returned by the stores wrap one of the following sentinels, so
+// that callers can tell the failures apart with errors.Is whatever the context
+// added to them.
+var (
+	// ErrAbsent is returned when loading an object the store does not hold.
+	ErrAbsent = errors.New("store: object not stored")
+	// ErrStoreFile is returned when a file of the store can't be read,
+	// decoded, written or deleted.
+	ErrStoreFile = errors.New("store: file error")
+	// ErrReadOnly is returned when trying to modify a read-only store.
+	ErrReadOnly = errors.New("store: read-only store")
+	// ErrExists is returned when a save would replace an existing object
+	// without being allowed to.
+	ErrExists = errors.New("store: object already exists")
+	// ErrConflict is returned when a conditional write is rejected because the
+	// stored object is not the one the caller expected.
+	ErrConflict = errors.New("store: stored object differs from the expected one")
+	// ErrBadSignature is returned when a signature does not verify.
+	ErrBadSignature = errors.New("store: invalid signature")
+	// ErrCorrupted is returned when an object read back after being saved
+	// differs from the saved one, or can't be read back at all.
+	ErrCorrupted = errors.New("store: object read back differs from the saved one")
+	// ErrTimeout is returned when reading a file takes longer than the read
+	// timeout of the store, see WithReadTimeout.
+	ErrTimeout = errors.New("store: read timed out")
+	// ErrTooLarge is returned when loading a file or a stream larger than the
+	// maximum size of the loaded objects, see WithMaxFileSize.
+	ErrTooLarge = errors.New("store: object too large")
+	// ErrUnknownFormat is returned when loading a file whose format is none
+	// of the ones written by the stores: TOML or JSON text, possibly
+	// compressed or encrypted.
+	ErrUnknownFormat = errors.New("store: unknown object format")
+	// ErrSealed is returned when accessing a private object of a store sealed
+	// until a threshold of key holders unseal it, see WithSealing.
+	ErrSealed = errors.New("store: private objects are sealed")
+	// ErrClosed is returned by the operations of a closed store.
+	ErrClosed = errors.New("store: store closed")
+	// ErrNoCodecs is returned when wrapping a store which does not support
+	// codecs, see CodecStore, into a compressed or encrypted one.
+	ErrNoCodecs = errors.New("store: codecs not supported")
+)
+
+var storeErrors = []error{ErrAbsent, ErrStoreFile, ErrReadOnly, ErrExists, ErrConflict, ErrBadSignature, ErrCorrupted, ErrTimeout, ErrTooLarge, ErrUnknownFormat, ErrSealed, ErrClosed, ErrNoCodecs}
+
+// fileError is the error of an operation on a file of a store. It matches
+// ErrAbsent if the file is missing and ErrStoreFile otherwise, while the error
+// it wraps can still be matched too.
+type fileError struct {
+	kind error
+	path string
+	err  error
+}
+
+// wrapFileError wraps the error of an operation on the file at filePath into a
+// fileError, unless it is nil or already wraps one of the store errors.
+func wrapFileError(filePath string, err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, storeErr := range storeErrors {
+		if errors.Is(err, storeErr) {
+			return err
+		}
+	}
+	kind := ErrStoreFile
+	if errors.Is(err, os.ErrNotExist) {
+		kind = ErrAbsent
+	}
+	return &fileError{kind: kind, path: filePath, err: err}
+}
+
+func (e *fileError) Error() string {
+	return fmt.Sprintf("%v: %s: %v", e.kind, e.path, e.err)
+}
+
+func (e *fileError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *fileError) Unwrap() error {
+	return e.err
+}
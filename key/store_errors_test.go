@@ -0,0 +1,46 @@
+package key
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreErrors(t *testing.T) {
+	store := NewFileStore(t.TempDir(), "").(*fileStore)
+
+	_, err := store.LoadDistPublic()
+	require.ErrorIs(t, err, ErrAbsent)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.NotErrorIs(t, err, ErrStoreFile)
+	require.Contains(t, err.Error(), store.distKeyFile)
+
+	// wrapping keeps the sentinels reachable
+	wrapped := fmt.Errorf("loading beacon: %w", err)
+	require.ErrorIs(t, wrapped, ErrAbsent)
+
+	identity := make([]byte, 48)
+	identity[0] = 0xc0
+	require.NoError(t, os.WriteFile(store.distKeyFile,
+		[]byte(fmt.Sprintf("Coefficients = [%q]\n", hex.EncodeToString(identity))), 0o600))
+	_, err = store.LoadDistPublic()
+	require.ErrorIs(t, err, ErrStoreFile)
+	require.ErrorIs(t, err, ErrInvalidPoint)
+	require.NotErrorIs(t, err, ErrAbsent)
+
+	// errors already wrapping a store error are left as they are
+	pairs, _ := BatchIdentities(1)
+	require.NoError(t, store.SaveKeyPair(pairs[0]))
+	err = store.SaveKeyPair(pairs[0])
+	require.ErrorIs(t, err, ErrExists)
+	require.NotErrorIs(t, err, ErrStoreFile)
+
+	// a folder in place of a file can't be written
+	require.NoError(t, os.Mkdir(store.groupFile, 0o700))
+	require.NoError(t, os.WriteFile(store.groupFile+"/x", nil, 0o600))
+	_, group := BatchIdentities(3)
+	require.ErrorIs(t, store.SaveGroup(group), ErrStoreFile)
+}
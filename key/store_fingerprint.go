@@ -0,0 +1,49 @@
+package key
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+)
+
+// PublicFingerprint returns a hash covering the public configuration held by
+// the store: its group, its distributed public key and the public identity of
+// the node. Secrets are never part of it. The identity of the node is covered
+// through its entry in the group, which must match it exactly, so that all the
+// nodes of a network configured identically report the same fingerprint.
+func PublicFingerprint(s Store) ([]byte, error) {
+	pair, err := s.LoadKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	group, err := s.LoadGroup()
+	if err != nil {
+		return nil, err
+	}
+	dist, err := s.LoadDistPublic()
+	if err != nil {
+		return nil, err
+	}
+	if group.Find(pair.Public) == nil {
+		return nil, fmt.Errorf("fingerprint: identity %s not found in the group", pair.Public.Address())
+	}
+
+	h := hashFunc()
+	_, _ = h.Write(group.Hash())
+	// fields not covered by the group hash
+	_ = binary.Write(h, binary.LittleEndian, int64(group.Period))
+	_ = binary.Write(h, binary.LittleEndian, int64(group.CatchupPeriod))
+	_, _ = h.Write([]byte(group.Scheme.ID))
+	_, _ = h.Write(group.GetGenesisSeed())
+	_, _ = h.Write(dist.Hash())
+	return h.Sum(nil), nil
+}
+
+// PublicFingerprintHex returns the hex encoded PublicFingerprint of the store.
+func PublicFingerprintHex(s Store) (string, error) {
+	f, err := PublicFingerprint(s)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(f), nil
+}
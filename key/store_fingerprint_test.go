@@ -0,0 +1,37 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublicFingerprint(t *testing.T) {
+	pairs, group := BatchIdentities(3)
+	outsider, _ := BatchIdentities(1)
+	newStore := func(p *Pair) Store {
+		s := NewFileStore(t.TempDir(), "")
+		require.NoError(t, s.SaveKeyPair(p))
+		require.NoError(t, s.SaveGroup(group))
+		require.NoError(t, s.SaveDistPublic(group.PublicKey))
+		return s
+	}
+
+	s0, s1 := newStore(pairs[0]), newStore(pairs[1])
+	f0, err := PublicFingerprintHex(s0)
+	require.NoError(t, err)
+	f1, err := PublicFingerprintHex(s1)
+	require.NoError(t, err)
+	require.Equal(t, f0, f1)
+
+	// a different distributed key changes the fingerprint
+	_, dist := dealShares(3, 2)
+	require.NoError(t, s1.SaveDistPublic(dist))
+	f1, err = PublicFingerprintHex(s1)
+	require.NoError(t, err)
+	require.NotEqual(t, f0, f1)
+
+	// a node must be part of the group it holds
+	_, err = PublicFingerprint(newStore(outsider[0]))
+	require.Error(t, err)
+}
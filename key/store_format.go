@@ -0,0 +1,100 @@
+package key
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+)
+
+// gzipMagic starts all the data compressed with gzip.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// maxFormatLayers bounds the number of compression and encryption layers
+// peeled off by sniffFormat.
+const maxFormatLayers = 4
+
+// decodeAny returns the serialized object read from r through the given
+// codecs. If the codecs can't decode the data, e.g. because the file was
+// written by a store configured differently, the format of the data is
+// detected instead, see sniffFormat. Both the data read from r and the decoded
+// object are limited to max bytes.
+func decodeAny(r io.Reader, codecs []Codec, max int64) ([]byte, error) {
+	raw, err := readLimited(r, max)
+	if err != nil {
+		return nil, err
+	}
+	data, err := decodeWith(bytes.NewReader(raw), codecs, max)
+	if err == nil && isText(data) {
+		return data, nil
+	}
+	return sniffFormat(raw, codecs, max)
+}
+
+// sniffFormat decodes data of unknown format, telling the formats apart by
+// their header: gzip compressed data is decompressed, data encrypted by a
+// passphrase codec is decrypted with the passphrase codec among the given
+// ones, and text is returned as is, to be decoded as TOML or JSON by
+// decodeValue. Other data fails with ErrUnknownFormat.
+func sniffFormat(data []byte, codecs []Codec, max int64) ([]byte, error) {
+	for i := 0; i < maxFormatLayers; i++ {
+		var codec Codec
+		switch {
+		case bytes.HasPrefix(data, gzipMagic):
+			codec = gzipCodec{}
+		case bytes.HasPrefix(data, encryptionMagic):
+			if codec = passphraseCodecOf(codecs); codec == nil {
+				return nil, fmt.Errorf("%w: data is encrypted but no passphrase is set", ErrDecrypt)
+			}
+		case isText(data):
+			return data, nil
+		default:
+			return nil, ErrUnknownFormat
+		}
+		r, err := codec.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = readLimited(r, max); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("%w: more than %d layers of compression or encryption", ErrUnknownFormat, maxFormatLayers)
+}
+
+// passphraseCodecOf returns the first passphrase codec of the list, if any.
+func passphraseCodecOf(codecs []Codec) Codec {
+	for _, c := range codecs {
+		if pc, ok := c.(*passphraseCodec); ok {
+			return pc
+		}
+	}
+	return nil
+}
+
+// isText returns true if the data can be a TOML or JSON document, i.e. valid
+// UTF-8 without any NUL byte.
+func isText(data []byte) bool {
+	return utf8.Valid(data) && bytes.IndexByte(data, 0) < 0
+}
+
+// isJSON returns true if the text is a JSON object rather than a TOML
+// document, which can't start with a brace.
+func isJSON(data []byte) bool {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+// decodeValue decodes the TOML or JSON document into the TOML value of an
+// object. JSON documents use the field names of the TOML form. It returns
+// true for JSON documents, which hold no comments.
+func decodeValue(data []byte, value interface{}) (bool, error) {
+	if isJSON(data) {
+		return true, json.Unmarshal(data, value)
+	}
+	_, err := toml.Decode(string(data), value)
+	return false, err
+}
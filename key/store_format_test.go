@@ -0,0 +1,62 @@
+package key
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreFormatDetection(t *testing.T) {
+	pairs, group := BatchIdentities(3)
+	_, dist := dealShares(3, 2)
+	group.PublicKey = dist
+	passphrase := []byte("correct horse battery staple")
+	base := t.TempDir()
+	plain := NewFileStore(base, "")
+	groupFile := plain.(*fileStore).groupFile
+	encrypted := encryptedStore(t, plain, passphrase)
+
+	requireGroup := func(s Store) {
+		t.Helper()
+		loaded, err := s.LoadGroup()
+		require.NoError(t, err)
+		require.True(t, loaded.Equal(group))
+	}
+
+	// TOML and JSON text
+	require.NoError(t, plain.SaveGroup(group))
+	requireGroup(plain)
+	requireGroup(encrypted)
+	buff, err := json.Marshal(group.TOML())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(groupFile, buff, 0o600))
+	requireGroup(plain)
+	requireGroup(compressedStore(t, plain))
+
+	// written by stores configured differently
+	require.NoError(t, compressedStore(t, plain).SaveGroup(group))
+	requireGroup(plain)
+	requireGroup(encrypted)
+	require.NoError(t, compressedStore(t, encrypted).SaveGroup(group))
+	requireGroup(encrypted)
+	_, err = plain.LoadGroup()
+	require.ErrorIs(t, err, ErrDecrypt)
+	_, err = encryptedStore(t, plain, []byte("wrong")).LoadGroup()
+	require.ErrorIs(t, err, ErrDecrypt)
+
+	require.NoError(t, os.WriteFile(groupFile, []byte{0x00, 0x01, 0x02, 0xff}, 0o600))
+	_, err = plain.LoadGroup()
+	require.ErrorIs(t, err, ErrUnknownFormat)
+	_, err = encrypted.LoadGroup()
+	require.ErrorIs(t, err, ErrUnknownFormat)
+
+	// Decode reads JSON as well
+	buff, err = json.Marshal(pairs[0].Public.TOML())
+	require.NoError(t, err)
+	id := new(Identity)
+	require.NoError(t, Decode(bytes.NewReader(buff), id))
+	require.True(t, id.Equal(pairs[0].Public))
+}
@@ -0,0 +1,163 @@
+package key
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/drand/drand/fs"
+)
+
+// GroupArchive is implemented by stores keeping all the groups they saved
+// under content-addressed names, see WithContentAddressedGroups.
+type GroupArchive interface {
+	// LoadGroupByHash loads the group with the given hex encoded hash, saved
+	// at some point by the store. It returns an error wrapping ErrAbsent if
+	// the store never saved such a group.
+	LoadGroupByHash(hash string) (*Group, error)
+}
+
+// EpochPruner is implemented by stores able to delete the archived groups no
+// longer needed to verify recent rounds, see WithContentAddressedGroups.
+type EpochPruner interface {
+	// PruneEpochs deletes the archived groups of the epochs entirely before
+	// the given round, i.e. the groups superseded by a resharing taking
+	// effect at or before that round. The groups needed to verify that round
+	// and the following ones, and the active group, are always kept.
+	PruneEpochs(keepFromRound uint64) error
+}
+
+// archivedGroupFile returns the path of the archived group with the given hex
+// encoded hash.
+func (f *fileStore) archivedGroupFile(hash string) string {
+	return filepath.Join(filepath.Dir(f.groupFile), hash+".toml")
+}
+
+// archiveGroup moves the group file just written to its content-addressed
+// name, unless a group with the same hash is already archived, and replaces
+// the group file with a symbolic link to it. It does nothing without
+// WithContentAddressedGroups.
+func (f *fileStore) archiveGroup() error {
+	if !f.contentAddressedGroups {
+		return nil
+	}
+	g := new(Group)
+	if err := f.load(f.groupFile, g); err != nil {
+		return err
+	}
+	archived := f.archivedGroupFile(hex.EncodeToString(g.Hash()))
+	name := filepath.Base(archived)
+	if exists, _ := fs.Exists(archived); !exists {
+		if err := fs.CopyFile(f.groupFile, archived+tmpExtension); err != nil {
+			return wrapFileError(archived, err)
+		}
+		if err := os.Rename(archived+tmpExtension, archived); err != nil {
+			return wrapFileError(archived, err)
+		}
+	}
+	link := f.groupFile + tmpExtension
+	os.Remove(link)
+	if err := os.Symlink(name, link); err != nil {
+		return wrapFileError(f.groupFile, err)
+	}
+	return wrapFileError(f.groupFile, os.Rename(link, f.groupFile))
+}
+
+func (f *fileStore) LoadGroupByHash(hash string) (*Group, error) {
+	if h, err := hex.DecodeString(hash); err != nil || len(h) != len(new(Group).Hash()) {
+		return nil, fmt.Errorf("store: invalid group hash %q", hash)
+	}
+	file := f.archivedGroupFile(hash)
+	g := new(Group)
+	if err := f.load(file, g); err != nil {
+		return nil, err
+	}
+	if f.distPublicReference && g.DistPublicHash() != nil {
+		// the group read back is only complete if it references the current
+		// distributed public key
+		d := new(DistPublic)
+		if err := f.load(f.distKeyFile, d); err == nil && bytes.Equal(d.Hash(), g.DistPublicHash()) {
+			if err := g.ResolveDistPublic(d); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if hex.EncodeToString(g.Hash()) != hash {
+		return nil, fmt.Errorf("%w: %s holds a group of hash %x", ErrCorrupted, file, g.Hash())
+	}
+	return g, nil
+}
+
+// epochStart returns the first round of the epoch of the group: the round of
+// its transition time, or the first round for the group of the genesis.
+func epochStart(g *Group) uint64 {
+	if g.TransitionTime == 0 {
+		return 1
+	}
+	return g.RoundAt(time.Unix(g.TransitionTime, 0))
+}
+
+// PruneEpochs deletes the archived groups whose epoch starts before the epoch
+// of keepFromRound, the latest one starting at or before it. The groups of the
+// same epoch, as saved before and after its DKG, are kept or deleted together.
+// The distributed public keys are held by the archived groups, or referenced
+// by the active group with WithDistPublicReference, so there are no other
+// files to delete.
+func (f *fileStore) PruneEpochs(keepFromRound uint64) error {
+	if !f.contentAddressedGroups {
+		return errors.New("store: groups are not archived, see WithContentAddressedGroups")
+	}
+	f.lock()
+	defer f.unlock()
+	active, err := os.Readlink(f.groupFile)
+	if err != nil {
+		return wrapFileError(f.groupFile, err)
+	}
+	dir := filepath.Dir(f.groupFile)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return wrapFileError(dir, err)
+	}
+	hashLen := len(new(Group).Hash())
+	starts := make(map[string]uint64)
+	for _, e := range entries {
+		hash := strings.TrimSuffix(e.Name(), ".toml")
+		if h, err := hex.DecodeString(hash); err != nil || len(h) != hashLen || e.Name() == hash {
+			continue
+		}
+		g := new(Group)
+		if err := f.load(filepath.Join(dir, e.Name()), g); err != nil {
+			return err
+		}
+		starts[e.Name()] = epochStart(g)
+	}
+
+	// the epoch of keepFromRound starts at the latest start before it
+	var epochs []uint64
+	for _, start := range starts {
+		epochs = append(epochs, start)
+	}
+	sort.Slice(epochs, func(i, j int) bool { return epochs[i] < epochs[j] })
+	var keepFrom uint64
+	for _, start := range epochs {
+		if start <= keepFromRound {
+			keepFrom = start
+		}
+	}
+	for name, start := range starts {
+		if start >= keepFrom || name == active {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return wrapFileError(filepath.Join(dir, name), err)
+		}
+		f.log.Infow("", "store", "pruned archived group", "file", name, "epoch_start", start)
+	}
+	return nil
+}
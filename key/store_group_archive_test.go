@@ -0,0 +1,134 @@
+package key
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreContentAddressedGroups(t *testing.T) {
+	_, first := BatchIdentities(3)
+	_, second := BatchIdentities(4)
+	store := NewFileStore(t.TempDir(), "", WithContentAddressedGroups())
+	f := store.(*fileStore)
+	archive := store.(GroupArchive)
+	hashOf := func(g *Group) string { return hex.EncodeToString(g.Hash()) }
+	requireLinked := func(g *Group) {
+		t.Helper()
+		target, err := os.Readlink(f.groupFile)
+		require.NoError(t, err)
+		require.Equal(t, hashOf(g)+".toml", target)
+		loaded, err := store.LoadGroup()
+		require.NoError(t, err)
+		require.True(t, loaded.Equal(g))
+	}
+
+	require.NoError(t, store.SaveGroup(first))
+	requireLinked(first)
+	require.NoError(t, store.SaveGroup(second))
+	requireLinked(second)
+	for _, g := range []*Group{first, second} {
+		loaded, err := archive.LoadGroupByHash(hashOf(g))
+		require.NoError(t, err)
+		require.True(t, loaded.Equal(g))
+	}
+
+	// saving a group again reuses its archive
+	require.NoError(t, store.SaveGroup(first))
+	requireLinked(first)
+	entries, err := os.ReadDir(filepath.Dir(f.groupFile))
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	// the other writes of the group are archived too
+	_, dist := dealShares(4, second.Threshold)
+	require.NoError(t, store.(GroupSetter).SetGroup(second))
+	require.NoError(t, store.SaveDistPublic(dist))
+	withKey := second.Copy()
+	withKey.PublicKey = dist
+	requireLinked(withKey)
+	loaded, err := archive.LoadGroupByHash(hashOf(second))
+	require.NoError(t, err)
+	require.True(t, loaded.PublicKey.Equal(second.PublicKey))
+
+	_, err = archive.LoadGroupByHash(hex.EncodeToString(make([]byte, 32)))
+	require.ErrorIs(t, err, ErrAbsent)
+	_, err = archive.LoadGroupByHash("../key/drand_id")
+	require.Error(t, err)
+
+	// without the option, the group file is a plain file
+	plain := NewFileStore(t.TempDir(), "")
+	require.NoError(t, plain.SaveGroup(first))
+	info, err := os.Lstat(plain.(*fileStore).groupFile)
+	require.NoError(t, err)
+	require.True(t, info.Mode().IsRegular())
+}
+
+func TestStorePruneEpochs(t *testing.T) {
+	store := NewFileStore(t.TempDir(), "", WithContentAddressedGroups())
+	f := store.(*fileStore)
+	pruner := store.(EpochPruner)
+	genesis := time.Now().Add(-time.Hour).Unix()
+	epoch := func(transitionRound int64) *Group {
+		_, g := BatchIdentities(3)
+		g.GenesisTime = genesis
+		g.Period = 30 * time.Second
+		if transitionRound > 1 {
+			g.TransitionTime = genesis + (transitionRound-1)*30
+		} else {
+		}
+		return g
+	}
+	archived := func() []string {
+		t.Helper()
+		entries, err := os.ReadDir(filepath.Dir(f.groupFile))
+		require.NoError(t, err)
+		var names []string
+		for _, e := range entries {
+			if e.Name() != filepath.Base(f.groupFile) {
+				names = append(names, e.Name())
+			}
+		}
+		sort.Strings(names)
+		return names
+	}
+	nameOf := func(g *Group) string { return hex.EncodeToString(g.Hash()) + ".toml" }
+	namesOf := func(groups ...*Group) []string {
+		var names []string
+		for _, g := range groups {
+			names = append(names, nameOf(g))
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	first, firstDKG, second, third := epoch(1), epoch(1), epoch(11), epoch(21)
+	for _, g := range []*Group{first, firstDKG, second, third} {
+		require.NoError(t, store.SaveGroup(g))
+	}
+	require.Equal(t, namesOf(first, firstDKG, second, third), archived())
+
+	// round 5 is still verified by the first epoch
+	require.NoError(t, pruner.PruneEpochs(5))
+	require.Len(t, archived(), 4)
+	require.NoError(t, pruner.PruneEpochs(15))
+	require.Equal(t, namesOf(second, third), archived())
+
+	// the active group is never pruned, even from an earlier epoch
+	require.NoError(t, store.SaveGroup(second))
+	require.NoError(t, pruner.PruneEpochs(1000))
+	require.Equal(t, namesOf(second, third), archived())
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(second))
+	require.NoError(t, store.SaveGroup(third))
+	require.NoError(t, pruner.PruneEpochs(1000))
+	require.Equal(t, namesOf(third), archived())
+
+	require.Error(t, NewFileStore(t.TempDir(), "").(EpochPruner).PruneEpochs(1))
+}
@@ -0,0 +1,266 @@
+package key
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	kyber "github.com/drand/kyber"
+)
+
+// ExportIdentity writes the public identity of the key pair held by the store
+// to w, in the format of the public key files, so that it can be sent to the
+// coordinator assembling the group. Only the public part of the pair is
+// written.
+func ExportIdentity(s Store, w io.Writer) error {
+	pair, err := s.LoadKeyPair()
+	if err != nil {
+		return err
+	}
+	if pair.Public == nil {
+		return fmt.Errorf("export identity: key pair has no public identity")
+	}
+	return Encode(w, pair.Public)
+}
+
+// ImportIdentity reads an identity written by ExportIdentity from r. The
+// identity must be signed by its own key, proving that the sender holds the
+// corresponding private key.
+func ImportIdentity(r io.Reader) (*Identity, error) {
+	id := new(Identity)
+	if err := Decode(r, id); err != nil {
+		return nil, err
+	}
+	if err := id.ValidSignature(); err != nil {
+		return nil, fmt.Errorf("%w: identity %s is not self-signed: %v", ErrBadSignature, id.Addr, err)
+	}
+	return id, nil
+}
+
+// portablePair is the TOML form of a whole key pair, private and public parts
+// together, as exported by ExportEncryptedKeyPair.
+type portablePair struct {
+	pair *Pair
+}
+
+// PortablePairTOML is the TOML representation of an exported key pair.
+type PortablePairTOML struct {
+	Private *PairTOML
+	Public  *PublicTOML
+}
+
+func (p *portablePair) TOML() interface{} {
+	return &PortablePairTOML{Private: p.pair.TOML().(*PairTOML), Public: p.pair.Public.TOML().(*PublicTOML)}
+}
+
+func (p *portablePair) FromTOML(i interface{}) error {
+	ptoml, ok := i.(*PortablePairTOML)
+	if !ok || ptoml.Private == nil || ptoml.Public == nil {
+		return errors.New("exported key pair can't decode from incomplete PortablePairTOML struct")
+	}
+	p.pair = new(Pair)
+	if err := p.pair.FromTOML(ptoml.Private); err != nil {
+		return err
+	}
+	return p.pair.Public.FromTOML(ptoml.Public)
+}
+
+func (p *portablePair) TOMLValue() interface{} {
+	return &PortablePairTOML{}
+}
+
+// ExportEncryptedKeyPair writes the key pair held by the store to w, encrypted
+// with the passphrase using NewPassphraseCodec, so that the identity of the
+// node can be moved to another host.
+func ExportEncryptedKeyPair(s Store, w io.Writer, passphrase []byte) error {
+	pair, err := s.LoadKeyPair()
+	if err != nil {
+		return err
+	}
+	return encodeWith(w, &portablePair{pair: pair}, []Codec{NewPassphraseCodec(passphrase)})
+}
+
+// ImportEncryptedKeyPair reads a key pair written by ExportEncryptedKeyPair and
+// checks its public key matches its private key. It fails with ErrDecrypt if
+// the passphrase is not the one used to export it.
+func ImportEncryptedKeyPair(r io.Reader, passphrase []byte) (*Pair, error) {
+	data, err := decodeWith(r, []Codec{NewPassphraseCodec(passphrase)}, DefaultMaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+	p := new(portablePair)
+	if err := Decode(bytes.NewReader(data), p); err != nil {
+		return nil, err
+	}
+	if !KeyGroup.Point().Mul(p.pair.Key, nil).Equal(p.pair.Public.Key) {
+		return nil, fmt.Errorf("exported key pair of %s: public key does not match the private key", p.pair.Public.Addr)
+	}
+	return p.pair, nil
+}
+
+// ownershipDomain separates the messages signed to prove the ownership of a
+// key from the other messages signed with it, such as its self-signature.
+const ownershipDomain = "drand-ownership-proof-v1"
+
+// ownershipMessage returns the message signed to prove the ownership of the
+// key in answer to the challenge.
+func ownershipMessage(key kyber.Point, challenge []byte) ([]byte, error) {
+	buff, err := key.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	_, _ = h.Write([]byte(ownershipDomain))
+	_, _ = h.Write(buff)
+	_, _ = h.Write(challenge)
+	return h.Sum(nil), nil
+}
+
+// ProveOwnership signs the challenge with the private key held by the store,
+// proving to a verifier, e.g. the coordinator of an enrollment, that the node
+// controls the key of its identity without revealing it. The proof is checked
+// with VerifyOwnership. The challenge should be random and chosen by the
+// verifier, so that proofs can't be replayed.
+func ProveOwnership(s Store, challenge []byte) ([]byte, error) {
+	if len(challenge) == 0 {
+		return nil, errors.New("prove ownership: empty challenge")
+	}
+	pair, err := s.LoadKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	msg, err := ownershipMessage(pair.Public.Key, challenge)
+	if err != nil {
+		return nil, err
+	}
+	return AuthScheme.Sign(pair.Key, msg)
+}
+
+// VerifyOwnership checks the proof returned by ProveOwnership for the
+// challenge was made with the private key of the identity. It returns an error
+// wrapping ErrBadSignature if it was not.
+func VerifyOwnership(id *Identity, challenge, proof []byte) error {
+	if len(challenge) == 0 {
+		return errors.New("verify ownership: empty challenge")
+	}
+	msg, err := ownershipMessage(id.Key, challenge)
+	if err != nil {
+		return err
+	}
+	if err := AuthScheme.Verify(id.Key, msg, proof); err != nil {
+		return fmt.Errorf("%w: ownership proof of %s: %v", ErrBadSignature, id.Addr, err)
+	}
+	return nil
+}
+
+// contributionDomain separates the messages signed over a contribution to a
+// group setup from the other messages signed with the key.
+const contributionDomain = "drand-contribution-v1"
+
+// contributionMessage returns the message signed over the identity when
+// contributing it to a group setup. Unlike the self-signature of the
+// identity, it covers its address and TLS setting too.
+func contributionMessage(id *Identity) ([]byte, error) {
+	buff, err := id.Key.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	_, _ = h.Write([]byte(contributionDomain))
+	_, _ = h.Write(buff)
+	_ = binary.Write(h, binary.BigEndian, uint32(len(id.Addr)))
+	_, _ = h.Write([]byte(id.Addr))
+	if id.TLS {
+		_, _ = h.Write([]byte{1})
+	} else {
+		_, _ = h.Write([]byte{0})
+	}
+	return h.Sum(nil), nil
+}
+
+// ContributionTOML is the TOML representation of a signed contribution to a
+// group setup, see SignedContribution.
+type ContributionTOML struct {
+	Identity  *PublicTOML
+	Signature string
+}
+
+// contribution is an identity signed as a whole by its own key.
+type contribution struct {
+	id        *Identity
+	signature []byte
+}
+
+func (c *contribution) TOML() interface{} {
+	return &ContributionTOML{Identity: c.id.TOML().(*PublicTOML), Signature: hex.EncodeToString(c.signature)}
+}
+
+func (c *contribution) FromTOML(i interface{}) error {
+	ctoml, ok := i.(*ContributionTOML)
+	if !ok || ctoml.Identity == nil {
+		return errors.New("contribution can't decode from incomplete ContributionTOML struct")
+	}
+	c.id = new(Identity)
+	if err := c.id.FromTOML(ctoml.Identity); err != nil {
+		return err
+	}
+	var err error
+	c.signature, err = hex.DecodeString(ctoml.Signature)
+	return err
+}
+
+func (c *contribution) TOMLValue() interface{} {
+	return &ContributionTOML{}
+}
+
+// SignedContribution returns the contribution of the node to a group setup:
+// the public identity of the key pair held by the store, signed as a whole,
+// address and TLS setting included, with its private key. The coordinator of
+// the setup checks it with VerifyContribution before including the node, so
+// that it can't be tricked into including a key the node does not control or
+// an address the node did not declare.
+func SignedContribution(s Store) ([]byte, error) {
+	pair, err := s.LoadKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	msg, err := contributionMessage(pair.Public)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := AuthScheme.Sign(pair.Key, msg)
+	if err != nil {
+		return nil, err
+	}
+	var buff bytes.Buffer
+	if err := Encode(&buff, &contribution{id: pair.Public, signature: signature}); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
+
+// VerifyContribution decodes a contribution returned by SignedContribution and
+// returns its identity once it checked both the signature of the contribution
+// and the self-signature of the identity are valid for its public key. It
+// returns an error wrapping ErrBadSignature if they are not.
+func VerifyContribution(data []byte) (*Identity, error) {
+	c := new(contribution)
+	if err := Decode(bytes.NewReader(data), c); err != nil {
+		return nil, err
+	}
+	msg, err := contributionMessage(c.id)
+	if err != nil {
+		return nil, err
+	}
+	if err := AuthScheme.Verify(c.id.Key, msg, c.signature); err != nil {
+		return nil, fmt.Errorf("%w: contribution of %s: %v", ErrBadSignature, c.id.Addr, err)
+	}
+	if err := c.id.ValidSignature(); err != nil {
+		return nil, fmt.Errorf("%w: identity %s is not self-signed: %v", ErrBadSignature, c.id.Addr, err)
+	}
+	return c.id, nil
+}
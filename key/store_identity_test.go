@@ -0,0 +1,129 @@
+package key
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreExportIdentity(t *testing.T) {
+	store := NewFileStore(t.TempDir(), "")
+	var buf bytes.Buffer
+	require.Error(t, ExportIdentity(store, &buf))
+
+	pair, err := Init(store, "127.0.0.1:8080")
+	require.NoError(t, err)
+	require.NoError(t, ExportIdentity(store, &buf))
+	require.NotContains(t, buf.String(), ScalarToString(pair.Key))
+
+	id, err := ImportIdentity(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.True(t, id.Equal(pair.Public))
+	require.Equal(t, pair.Public.Signature, id.Signature)
+
+	// an identity that is not self-signed is refused
+	pair.Public.Signature[0] ^= 0xff
+	buf.Reset()
+	require.NoError(t, Encode(&buf, pair.Public))
+	_, err = ImportIdentity(&buf)
+	require.ErrorIs(t, err, ErrBadSignature)
+}
+
+func TestStoreExportEncryptedKeyPair(t *testing.T) {
+	store := NewFileStore(t.TempDir(), "")
+	pair, err := Init(store, "127.0.0.1:8080")
+	require.NoError(t, err)
+	passphrase := []byte("correct horse battery staple")
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportEncryptedKeyPair(store, &buf, passphrase))
+	require.NotContains(t, buf.String(), ScalarToString(pair.Key))
+	require.NotContains(t, buf.String(), pair.Public.Addr)
+	exported := buf.Bytes()
+
+	imported, err := ImportEncryptedKeyPair(bytes.NewReader(exported), passphrase)
+	require.NoError(t, err)
+	require.True(t, imported.Equal(pair))
+
+	_, err = ImportEncryptedKeyPair(bytes.NewReader(exported), []byte("wrong"))
+	require.ErrorIs(t, err, ErrDecrypt)
+
+	// the header is authenticated along with the key pair
+	tampered := append([]byte{}, exported...)
+	tampered[len(encryptionMagic)+4]++
+	_, err = ImportEncryptedKeyPair(bytes.NewReader(tampered), passphrase)
+	require.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = ImportEncryptedKeyPair(bytes.NewReader(exported[:20]), passphrase)
+	require.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestStoreProveOwnership(t *testing.T) {
+	store := NewFileStore(t.TempDir(), "")
+	pair, err := Init(store, "127.0.0.1:8080")
+	require.NoError(t, err)
+	challenge := []byte("enrollment challenge 42")
+
+	proof, err := ProveOwnership(store, challenge)
+	require.NoError(t, err)
+	require.NoError(t, VerifyOwnership(pair.Public, challenge, proof))
+
+	// the proof is bound to the challenge and to the key
+	require.ErrorIs(t, VerifyOwnership(pair.Public, []byte("another challenge"), proof), ErrBadSignature)
+	other := NewKeyPair("127.0.0.1:8081")
+	require.ErrorIs(t, VerifyOwnership(other.Public, challenge, proof), ErrBadSignature)
+	otherStore := NewFileStore(t.TempDir(), "")
+	require.NoError(t, otherStore.SaveKeyPair(other))
+	wrongProof, err := ProveOwnership(otherStore, challenge)
+	require.NoError(t, err)
+	require.ErrorIs(t, VerifyOwnership(pair.Public, challenge, wrongProof), ErrBadSignature)
+
+	// a proof can't be used as the self-signature of the identity
+	selfSigned, err := ProveOwnership(store, pair.Public.Hash())
+	require.NoError(t, err)
+	require.NotEqual(t, pair.Public.Signature, selfSigned)
+
+	_, err = ProveOwnership(store, nil)
+	require.Error(t, err)
+	_, err = ProveOwnership(NewFileStore(t.TempDir(), ""), challenge)
+	require.ErrorIs(t, err, ErrAbsent)
+}
+
+func TestStoreSignedContribution(t *testing.T) {
+	store := NewFileStore(t.TempDir(), "")
+	pair, err := Init(store, "127.0.0.1:8080")
+	require.NoError(t, err)
+
+	data, err := SignedContribution(store)
+	require.NoError(t, err)
+	id, err := VerifyContribution(data)
+	require.NoError(t, err)
+	require.True(t, id.Equal(pair.Public))
+
+	tampered := func(edit func(c *contribution)) []byte {
+		c := new(contribution)
+		require.NoError(t, Decode(bytes.NewReader(data), c))
+		edit(c)
+		var buff bytes.Buffer
+		require.NoError(t, Encode(&buff, c))
+		return buff.Bytes()
+	}
+	// the signature covers the address and TLS setting
+	_, err = VerifyContribution(tampered(func(c *contribution) { c.id.Addr = "127.0.0.1:9999" }))
+	require.ErrorIs(t, err, ErrBadSignature)
+	_, err = VerifyContribution(tampered(func(c *contribution) { c.id.TLS = !c.id.TLS }))
+	require.ErrorIs(t, err, ErrBadSignature)
+	// a self-signed key the node does not control
+	other := NewKeyPair("127.0.0.1:8080")
+	_, err = VerifyContribution(tampered(func(c *contribution) { c.id = other.Public }))
+	require.ErrorIs(t, err, ErrBadSignature)
+	// an identity without its self-signature
+	_, err = VerifyContribution(tampered(func(c *contribution) { c.id.Signature = nil }))
+	require.ErrorIs(t, err, ErrBadSignature)
+
+	_, err = VerifyContribution([]byte("garbage"))
+	require.Error(t, err)
+	_, err = SignedContribution(NewFileStore(t.TempDir(), ""))
+	require.ErrorIs(t, err, ErrAbsent)
+}
@@ -0,0 +1,237 @@
+package key
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+
+	"github.com/drand/drand/common/scheme"
+	"github.com/drand/kyber/util/random"
+)
+
+// InitOption is a function that applies a specific setting to Init.
+type InitOption func(*initConfig)
+
+type initConfig struct {
+	tls          bool
+	extraEntropy io.Reader
+}
+
+// WithTLS sets whether the node is reachable over TLS, which is the default.
+func WithTLS(tls bool) InitOption {
+	return func(c *initConfig) {
+		c.tls = tls
+	}
+}
+
+// WithExtraEntropy mixes entropy read from r, e.g. a hardware RNG or a seed
+// derived during a ceremony, into the one of crypto/rand when generating the
+// key pair. It never replaces crypto/rand: both are read and hashed together to
+// seed the generation, so the key is as unpredictable as the best of the two
+// sources, and a weak or even constant r can't make it weaker than without it.
+// Init fails if either source can't provide enough bytes.
+func WithExtraEntropy(r io.Reader) InitOption {
+	return func(c *initConfig) {
+		c.extraEntropy = r
+	}
+}
+
+// systemEntropy is the source of entropy always used to generate key pairs, a
+// variable so tests can stub it.
+var systemEntropy io.Reader = rand.Reader
+
+// entropyBytes is the number of bytes read from each source of entropy.
+const entropyBytes = 32
+
+// mixedEntropy returns a random stream seeded by the hash of entropy read from
+// crypto/rand and from extra.
+func mixedEntropy(extra io.Reader) (cipher.Stream, error) {
+	system := make([]byte, entropyBytes)
+	if _, err := io.ReadFull(systemEntropy, system); err != nil {
+		return nil, fmt.Errorf("init: reading crypto/rand entropy: %w", err)
+	}
+	supplied := make([]byte, entropyBytes)
+	if _, err := io.ReadFull(extra, supplied); err != nil {
+		return nil, fmt.Errorf("init: reading extra entropy: %w", err)
+	}
+	return random.New(bytes.NewReader(system), bytes.NewReader(supplied)), nil
+}
+
+// Init sets up a fresh node reachable at the given "host:port" address: it
+// generates a self-signed key pair and saves it in the store, whose folder layout
+// is created along the way. It refuses to run if the store already holds any
+// object, returning an error wrapping ErrExists, so that existing keys are
+// never destroyed.
+func Init(s Store, addr string, opts ...InitOption) (*Pair, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("init: invalid address %q: %w", addr, err)
+	}
+	c := &initConfig{tls: true}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if kind, ok := firstStoredKind(s); ok {
+		return nil, fmt.Errorf("%w: the store already holds a %s", ErrExists, kind)
+	}
+	pair, err := c.generate(addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.SaveKeyPair(pair); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// generate returns a new self-signed key pair for the address.
+func (c *initConfig) generate(addr string) (*Pair, error) {
+	stream := random.New(systemEntropy)
+	if c.extraEntropy != nil {
+		var err error
+		if stream, err = mixedEntropy(c.extraEntropy); err != nil {
+			return nil, err
+		}
+	}
+	pair := newKeyPair(addr, stream)
+	if c.tls {
+		pair.Public.TLS = true
+		pair.SelfSign()
+	}
+	return pair, nil
+}
+
+// KeyPairInitializer is implemented by stores able to load or create the key
+// pair of the node in one call, e.g. at each start of a daemon.
+type KeyPairInitializer interface {
+	// LoadOrInitKeyPair returns the stored key pair and false or, if the
+	// store holds none, generates a key pair for the "host:port" address as
+	// Init does, saves it and returns it along with true. Calling it again
+	// returns the saved pair: an existing key pair is never replaced.
+	LoadOrInitKeyPair(addr string, opts ...InitOption) (*Pair, bool, error)
+}
+
+// keyPairWait is how long LoadOrInitKeyPair waits for the public key of a key
+// pair being saved by another process.
+const keyPairWait = 2 * time.Second
+
+// LoadOrInitKeyPair holds the store lock from the load to the save, so that
+// concurrent calls generate a single key pair. Across processes, the private
+// key file is created exclusively: a key pair saved in between by another
+// process is not replaced, it is loaded and returned instead. The address is
+// only used when generating the key pair, the stored one is returned as is.
+func (f *fileStore) LoadOrInitKeyPair(addr string, opts ...InitOption) (*Pair, bool, error) {
+	f.lock()
+	defer f.unlock()
+	pair, err := f.LoadKeyPair()
+	if err == nil {
+		return pair, false, nil
+	}
+	if !errors.Is(err, ErrAbsent) {
+		return nil, false, err
+	}
+
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, false, fmt.Errorf("init: invalid address %q: %w", addr, err)
+	}
+	c := &initConfig{tls: true}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if pair, err = c.generate(addr); err != nil {
+		return nil, false, err
+	}
+	if err = f.saveKeyPair(pair); errors.Is(err, ErrExists) {
+		pair, err = f.loadSavedKeyPair()
+		return pair, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return pair, true, nil
+}
+
+// loadSavedKeyPair loads a key pair another process just created, waiting for
+// its public key file to be in place too.
+func (f *fileStore) loadSavedKeyPair() (*Pair, error) {
+	deadline := time.Now().Add(keyPairWait)
+	for {
+		pair, err := f.LoadKeyPair()
+		if !errors.Is(err, ErrAbsent) || time.Now().After(deadline) {
+			return pair, err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// firstStoredKind returns the kind of the first object found in the store, if
+// any.
+func firstStoredKind(s Store) (StoreKind, bool) {
+	if p, err := s.LoadKeyPair(); err == nil && p != nil {
+		return KeyPairKind, true
+	}
+	if sh, err := s.LoadShare(); err == nil && sh != nil {
+		return ShareKind, true
+	}
+	if g, err := s.LoadGroup(); err == nil && g != nil {
+		return GroupKind, true
+	}
+	if d, err := s.LoadDistPublic(); err == nil && d != nil {
+		return DistPublicKind, true
+	}
+	return 0, false
+}
+
+// GroupParams are the parameters of the group of a new network, see InitGroup.
+type GroupParams struct {
+	Threshold int
+	Period    time.Duration
+	// CatchupPeriod is the period of the rounds emitted to catch up, zero to
+	// emit them as fast as possible
+	CatchupPeriod time.Duration
+	GenesisTime   time.Time
+	// SchemeID is the ID of the scheme of the network, the default one if
+	// empty
+	SchemeID string
+	BeaconID string
+	// Clock is the clock the genesis time is checked against, the real clock
+	// if nil
+	Clock clock.Clock
+}
+
+// InitGroup assembles the group of a new network from the identities of its
+// nodes, before the DKG runs: the nodes get their canonical indices, see
+// AssignIndices, and the group holds no distributed public key yet. The
+// period and the genesis time are checked as by SetPeriod and SetGenesis, and
+// the threshold must be valid for the number of nodes. The group is ready to
+// be saved with SaveGroup.
+func InitGroup(identities []*Identity, params GroupParams) (*Group, error) {
+	sch, err := scheme.GetSchemeByIDWithDefault(params.SchemeID)
+	if err != nil {
+		return nil, fmt.Errorf("group: %w", err)
+	}
+	if params.CatchupPeriod < 0 {
+		return nil, fmt.Errorf("group: negative catchup period %s", params.CatchupPeriod)
+	}
+	g := NewGroup(identities, params.Threshold, 0, 0, params.CatchupPeriod, sch, params.BeaconID)
+	if err := g.SetPeriod(params.Period); err != nil {
+		return nil, err
+	}
+	clk := params.Clock
+	if clk == nil {
+		clk = clock.NewRealClock()
+	}
+	if err := g.SetGenesis(params.GenesisTime, clk.Now()); err != nil {
+		return nil, err
+	}
+	if err := g.Valid(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
@@ -0,0 +1,196 @@
+package key
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/drand/drand/common/scheme"
+	clock "github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreInit(t *testing.T) {
+	store := NewFileStore(t.TempDir(), "")
+	_, err := Init(store, "127.0.0.1")
+	require.Error(t, err)
+
+	pair, err := Init(store, "127.0.0.1:8080")
+	require.NoError(t, err)
+	require.True(t, pair.Public.IsTLS())
+	require.NoError(t, pair.Public.ValidSignature())
+	loaded, err := store.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, loaded.Public.Equal(pair.Public))
+
+	// an initialized store is never initialized again
+	_, err = Init(store, "127.0.0.1:8080")
+	require.ErrorIs(t, err, ErrExists)
+	loaded, err = store.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, loaded.Key.Equal(pair.Key))
+
+	// nor is a store holding any other object
+	_, group := BatchIdentities(3)
+	store = NewFileStore(t.TempDir(), "")
+	require.NoError(t, store.SaveGroup(group))
+	_, err = Init(store, "127.0.0.1:8080")
+	require.ErrorIs(t, err, ErrExists)
+
+	pair, err = Init(NewFileStore(t.TempDir(), ""), "127.0.0.1:8080", WithTLS(false))
+	require.NoError(t, err)
+	require.False(t, pair.Public.IsTLS())
+}
+
+func TestStoreInitExtraEntropy(t *testing.T) {
+	stub := bytes.Repeat([]byte{0x42}, 4*entropyBytes)
+	defer func(r io.Reader) { systemEntropy = r }(systemEntropy)
+	initWith := func(extra []byte) *Pair {
+		systemEntropy = bytes.NewReader(stub)
+		pair, err := Init(NewFileStore(t.TempDir(), ""), "127.0.0.1:8080",
+			WithExtraEntropy(bytes.NewReader(extra)))
+		require.NoError(t, err)
+		require.NoError(t, pair.Public.ValidSignature())
+		return pair
+	}
+
+	a := initWith(bytes.Repeat([]byte{1}, entropyBytes))
+	b := initWith(bytes.Repeat([]byte{2}, entropyBytes))
+	require.False(t, a.Key.Equal(b.Key))
+	require.True(t, a.Key.Equal(initWith(bytes.Repeat([]byte{1}, entropyBytes)).Key))
+
+	// crypto/rand is still mixed in: changing it changes the key
+	stub = bytes.Repeat([]byte{0x43}, 4*entropyBytes)
+	require.False(t, a.Key.Equal(initWith(bytes.Repeat([]byte{1}, entropyBytes)).Key))
+
+	systemEntropy = bytes.NewReader(stub)
+	_, err := Init(NewFileStore(t.TempDir(), ""), "127.0.0.1:8080", WithExtraEntropy(bytes.NewReader([]byte{1})))
+	require.Error(t, err)
+}
+
+func TestInitGroup(t *testing.T) {
+	pairs, _ := BatchIdentities(5)
+	ids := make([]*Identity, len(pairs))
+	for i, p := range pairs {
+		ids[i] = p.Public
+	}
+	genesis := time.Now().Add(time.Minute)
+	params := GroupParams{Threshold: 3, Period: 30 * time.Second, GenesisTime: genesis, BeaconID: "fresh"}
+	group, err := InitGroup(ids, params)
+	require.NoError(t, err)
+	require.Equal(t, 5, group.Len())
+	for i, a := range AssignIndices(ids) {
+		require.Equal(t, Index(i), a.Index)
+		require.True(t, group.Node(a.Index).Identity.Equal(a.Identity))
+	}
+	require.Equal(t, genesis.Unix(), group.GenesisTime)
+	require.Equal(t, scheme.DefaultSchemeID, group.Scheme.ID)
+	require.Equal(t, "fresh", group.ID)
+	require.Nil(t, group.PublicKey)
+
+	store := NewFileStore(t.TempDir(), "fresh")
+	require.NoError(t, store.SaveGroup(group))
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(group))
+
+	for _, bad := range []func(p *GroupParams){
+		func(p *GroupParams) { p.Threshold = 2 },
+		func(p *GroupParams) { p.Threshold = 6 },
+		func(p *GroupParams) { p.Period = 0 },
+		func(p *GroupParams) { p.Period = -time.Second },
+		func(p *GroupParams) { p.CatchupPeriod = -time.Second },
+		func(p *GroupParams) { p.GenesisTime = time.Time{} },
+		func(p *GroupParams) { p.SchemeID = "unknown" },
+	} {
+		p := params
+		bad(&p)
+		_, err := InitGroup(ids, p)
+		require.Error(t, err)
+	}
+	_, err = InitGroup(append(ids, ids[0]), params)
+	require.Error(t, err)
+
+	// the genesis time is checked against the given clock
+	p := params
+	p.Clock = clock.NewFakeClockAt(genesis.Add(time.Duration(MaxPastGenesisRounds+1) * p.Period))
+	_, err = InitGroup(ids, p)
+	require.Error(t, err)
+	p.Clock = clock.NewFakeClockAt(genesis.Add(10 * p.Period))
+	_, err = InitGroup(ids, p)
+	require.NoError(t, err)
+}
+
+func TestStoreLoadOrInitKeyPair(t *testing.T) {
+	store := NewFileStore(t.TempDir(), "")
+	initializer := store.(KeyPairInitializer)
+	_, _, err := initializer.LoadOrInitKeyPair("127.0.0.1")
+	require.Error(t, err)
+
+	const starts = 8
+	pairs := make([]*Pair, starts)
+	created := make([]bool, starts)
+	errs := make([]error, starts)
+	var wg sync.WaitGroup
+	for i := 0; i < starts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pairs[i], created[i], errs[i] = initializer.LoadOrInitKeyPair("127.0.0.1:8080")
+		}(i)
+	}
+	wg.Wait()
+	generated := 0
+	for i := range pairs {
+		require.NoError(t, errs[i])
+		require.True(t, pairs[i].Equal(pairs[0]))
+		if created[i] {
+			generated++
+		}
+	}
+	require.Equal(t, 1, generated)
+
+	// the stored pair is returned as is, whatever the address
+	pair, fresh, err := initializer.LoadOrInitKeyPair("127.0.0.2:8080")
+	require.NoError(t, err)
+	require.False(t, fresh)
+	require.True(t, pair.Equal(pairs[0]))
+	require.Equal(t, "127.0.0.1:8080", pair.Public.Addr)
+}
+
+func TestStoreLoadOrInitKeyPairStores(t *testing.T) {
+	// each store stands for a process starting on the same folder
+	folder := t.TempDir()
+	const starts = 8
+	pairs := make([]*Pair, starts)
+	created := make([]bool, starts)
+	errs := make([]error, starts)
+	var wg sync.WaitGroup
+	for i := 0; i < starts; i++ {
+		initializer := NewFileStore(folder, "").(KeyPairInitializer)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pairs[i], created[i], errs[i] = initializer.LoadOrInitKeyPair("127.0.0.1:8080")
+		}(i)
+	}
+	wg.Wait()
+	generated := 0
+	for i := range pairs {
+		require.NoError(t, errs[i])
+		require.True(t, pairs[i].Equal(pairs[0]))
+		if created[i] {
+			generated++
+		}
+	}
+	require.Equal(t, 1, generated)
+	loaded, err := NewFileStore(folder, "").LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(pairs[0]))
+
+	// nor does a plain save replace the key of another store
+	other := NewFileStore(folder, "")
+	require.ErrorIs(t, other.SaveKeyPair(NewKeyPair("127.0.0.1:8081")), ErrExists)
+}
@@ -0,0 +1,247 @@
+package key
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/drand/drand/common"
+	"github.com/drand/drand/fs"
+)
+
+// migrationBackupExtension is appended, along with the name of the migration,
+// to the path of the copy of a file made before a migration modifies it.
+const migrationBackupExtension = ".pre-"
+
+// MigrationChange describes a file changed by a migration.
+type MigrationChange struct {
+	// Migration is the name of the migration
+	Migration string
+	// File is the path of the file created or modified
+	File string
+	// Backup is the path of the copy of the file made before modifying it,
+	// empty if the file was created
+	Backup string
+}
+
+// MigrationReport lists the changes made by Migrate, in order. It is empty if
+// the store was already up to date.
+type MigrationReport struct {
+	Changes []MigrationChange
+}
+
+// Migrator is implemented by stores able to bring the objects written by
+// previous versions of drand up to date.
+type Migrator interface {
+	// Migrate applies, in order, all the migrations the store needs and
+	// reports the changes made. Running it again on a migrated store changes
+	// nothing. Every file is backed up before being modified. Migrations not
+	// yet started when ctx is done are not applied.
+	Migrate(ctx context.Context) (MigrationReport, error)
+}
+
+// migration brings one aspect of a file store up to date, adding its changes
+// to the report. It must do nothing on a store already migrated.
+type migration struct {
+	name  string
+	apply func(f *fileStore, r *migrationRun) error
+}
+
+// migrations are applied in this order.
+var migrations = []migration{
+	{name: "legacy-layout", apply: migrateLegacyLayout},
+	{name: "self-signed-key", apply: migrateSelfSignedKey},
+	{name: "group-fields", apply: migrateGroupFields},
+}
+
+type migrationRun struct {
+	migration string
+	report    *MigrationReport
+}
+
+// backup copies the file before it is modified by the migration.
+func (r *migrationRun) backup(filePath string) (string, error) {
+	backup := filePath + migrationBackupExtension + r.migration
+	if err := fs.CopyFile(filePath, backup); err != nil {
+		return "", wrapFileError(backup, err)
+	}
+	return backup, nil
+}
+
+func (r *migrationRun) changed(filePath, backup string) {
+	r.report.Changes = append(r.report.Changes, MigrationChange{Migration: r.migration, File: filePath, Backup: backup})
+}
+
+func (f *fileStore) Migrate(ctx context.Context) (MigrationReport, error) {
+	f.lock()
+	defer f.unlock()
+	var report MigrationReport
+	for _, m := range migrations {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if err := m.apply(f, &migrationRun{migration: m.name, report: &report}); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// migrateLegacyLayout copies the files of a single-beacon drand, kept right
+// under the configuration folder, to the store of the default beacon if it
+// does not hold them yet. Legacy files are plain TOML, so they are only copied
+// to stores without codecs.
+func migrateLegacyLayout(f *fileStore, r *migrationRun) error {
+	if f.beaconID != common.DefaultBeaconID || path.Base(f.baseFolder) != common.MultiBeaconFolder || len(f.codecs) > 0 {
+		return nil
+	}
+	legacy := path.Dir(f.baseFolder)
+	for _, file := range []struct{ src, dst string }{
+		{path.Join(legacy, KeyFolderName, keyFileName+privateExtension), f.privateKeyFile},
+		{path.Join(legacy, KeyFolderName, keyFileName+publicExtension), f.publicKeyFile},
+		{path.Join(legacy, GroupFolderName, groupFileName), f.groupFile},
+		{path.Join(legacy, GroupFolderName, shareFileName), f.shareFile},
+		{path.Join(legacy, GroupFolderName, distKeyFileName), f.distKeyFile},
+	} {
+		src, dst := file.src, file.dst
+		if srcExists, _ := fs.Exists(src); !srcExists {
+			continue
+		}
+		if dstExists, _ := fs.Exists(dst); dstExists {
+			continue
+		}
+		if err := fs.CopyFile(src, dst); err != nil {
+			return wrapFileError(dst, err)
+		}
+		r.changed(dst, "")
+	}
+	return nil
+}
+
+// migrateSelfSignedKey signs the public identity of the node with its key, as
+// identities created by drand versions before v1.0 are not signed.
+func migrateSelfSignedKey(f *fileStore, r *migrationRun) error {
+	if exists, _ := fs.Exists(f.publicKeyFile); !exists {
+		return nil
+	}
+	pair := new(Pair)
+	if err := f.load(f.privateKeyFile, pair); err != nil {
+		return err
+	}
+	if err := f.load(f.publicKeyFile, pair.Public); err != nil {
+		return err
+	}
+	if pair.Public.ValidSignature() == nil {
+		return nil
+	}
+	pair.SelfSign()
+	return r.rewrite(f, f.publicKeyFile, pair.Public)
+}
+
+// migrateGroupFields rewrites the group files lacking the fields written by
+// the current version, the scheme and the genesis seed, so that they don't
+// depend on the defaults of the version loading them. Groups including
+// fragments are left untouched, as rewriting them would inline the fragments.
+func migrateGroupFields(f *fileStore, r *migrationRun) error {
+	if exists, _ := fs.Exists(f.groupFile); !exists {
+		return nil
+	}
+	data, err := f.readFile(f.groupFile)
+	if err != nil {
+		return err
+	}
+	raw := new(GroupTOML)
+	if _, err := toml.Decode(string(data), raw); err != nil {
+		return wrapFileError(f.groupFile, err)
+	}
+	if len(raw.Include) > 0 || (raw.SchemeID != "" && raw.GenesisSeed != "") {
+		return nil
+	}
+	g := new(Group)
+	if err := f.load(f.groupFile, g); err != nil {
+		return err
+	}
+	return r.rewrite(f, f.groupFile, g)
+}
+
+// rewrite backs up the file then replaces it atomically with the object.
+func (r *migrationRun) rewrite(f *fileStore, filePath string, t Tomler) error {
+	backup, err := r.backup(filePath)
+	if err != nil {
+		return err
+	}
+	w := &atomicWrite{closed: f.closed, wal: f.wal, codecs: f.codecs}
+	if err := w.add(filePath, t, false); err != nil {
+		return err
+	}
+	if err := w.commit(); err != nil {
+		return err
+	}
+	r.changed(filePath, backup)
+	return nil
+}
+
+// readFile returns the serialized object held in the file, once decoded by
+// the codecs of the store.
+func (f *fileStore) readFile(filePath string) ([]byte, error) {
+	data, err := f.readData(filePath)
+	return data, wrapFileError(filePath, err)
+}
+
+// legacyKeyTOML is the combined key file of the early drand versions, holding
+// the private key along with the whole public identity in a Public table.
+type legacyKeyTOML struct {
+	Key    string
+	Public PublicTOML
+}
+
+// ImportCombinedKeyFile reads the key pair from a combined key file of the
+// early drand versions, which kept the private key and the public identity in
+// the same file, e.g.
+//
+//	Key = "<hex encoded private scalar>"
+//	[Public]
+//	Address = "127.0.0.1:8080"
+//	Key = "<hex encoded public point>"
+//	TLS = true
+//
+// The public key must be the one of the private key. Identities of these
+// versions are not signed, so the pair is self-signed unless it already holds
+// a valid signature. The pair is ready to be saved with SaveKeyPair. Files of
+// another format, such as the private key files of the current layout, are
+// rejected with an error wrapping ErrUnknownFormat.
+func ImportCombinedKeyFile(filePath string) (*Pair, error) {
+	fd, err := openLimited(filePath, DefaultMaxFileSize)
+	if err != nil {
+		return nil, wrapFileError(filePath, err)
+	}
+	defer fd.Close()
+	data, err := readLimited(fd, DefaultMaxFileSize)
+	if err != nil {
+		return nil, wrapFileError(filePath, err)
+	}
+	var legacy legacyKeyTOML
+	md, err := toml.Decode(string(data), &legacy)
+	if err != nil || !md.IsDefined("Key") || !md.IsDefined("Public", "Key") {
+		return nil, fmt.Errorf("%w: %s is not a legacy combined key file, which holds a Key and a Public table",
+			ErrUnknownFormat, filePath)
+	}
+
+	p := new(Pair)
+	if p.Key, err = StringToScalar(KeyGroup, legacy.Key); err != nil {
+		return nil, fmt.Errorf("%w: %s: decoding private key: %v", ErrStoreFile, filePath, err)
+	}
+	p.Public = new(Identity)
+	if err := p.Public.FromTOML(&legacy.Public); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrStoreFile, filePath, err)
+	}
+	if !p.Public.Key.Equal(KeyGroup.Point().Mul(p.Key, nil)) {
+		return nil, fmt.Errorf("%w: %s: the public key is not the one of the private key", ErrStoreFile, filePath)
+	}
+	if p.Public.ValidSignature() != nil {
+		p.SelfSign()
+	}
+	return p, nil
+}
@@ -0,0 +1,107 @@
+package key
+
+import (
+	"context"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/drand/drand/common"
+)
+
+func TestStoreMigrate(t *testing.T) {
+	// files of a single-beacon drand, written by an old version: the identity
+	// is not signed and the group lacks its scheme and genesis seed
+	config := t.TempDir()
+	pair := NewKeyPair("127.0.0.1:8080")
+	pair.Public.Signature = nil
+	_, group := BatchIdentities(3)
+	legacyKey := path.Join(config, KeyFolderName)
+	legacyGroup := path.Join(config, GroupFolderName)
+	require.NoError(t, os.MkdirAll(legacyKey, 0o700))
+	require.NoError(t, os.MkdirAll(legacyGroup, 0o700))
+	require.NoError(t, Save(path.Join(legacyKey, keyFileName+privateExtension), pair, true))
+	require.NoError(t, Save(path.Join(legacyKey, keyFileName+publicExtension), pair.Public, false))
+	groupPath := path.Join(legacyGroup, groupFileName)
+	require.NoError(t, Save(groupPath, group, false))
+	data, err := os.ReadFile(groupPath)
+	require.NoError(t, err)
+	data = regexp.MustCompile(`(?m)^(SchemeID|GenesisSeed) = .*\n`).ReplaceAll(data, nil)
+	require.NoError(t, os.WriteFile(groupPath, data, 0o600))
+
+	store := NewFileStore(path.Join(config, common.MultiBeaconFolder), "").(*fileStore)
+	report, err := Migrator(store).Migrate(context.Background())
+	require.NoError(t, err)
+
+	var migrationsApplied []string
+	for _, c := range report.Changes {
+		migrationsApplied = append(migrationsApplied, c.Migration)
+		if c.Backup != "" {
+			_, err := os.Stat(c.Backup)
+			require.NoError(t, err, c.Backup)
+		}
+	}
+	require.Equal(t, []string{
+		"legacy-layout", "legacy-layout", "legacy-layout", "self-signed-key", "group-fields",
+	}, migrationsApplied)
+	require.Equal(t, store.publicKeyFile+".pre-self-signed-key", report.Changes[3].Backup)
+
+	loaded, err := store.LoadKeyPair()
+	require.NoError(t, err)
+	require.NoError(t, loaded.Public.ValidSignature())
+	require.True(t, loaded.Key.Equal(pair.Key))
+	loadedGroup, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.Equal(t, group.Hash(), loadedGroup.Hash())
+	data, err = os.ReadFile(store.groupFile)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "SchemeID")
+	require.Contains(t, string(data), "GenesisSeed")
+
+	// migrating again changes nothing
+	report, err = store.Migrate(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, report.Changes)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = store.Migrate(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestImportCombinedKeyFile(t *testing.T) {
+	const fixture = "testdata/legacy_combined_key.toml"
+	pair, err := ImportCombinedKeyFile(fixture)
+	require.NoError(t, err)
+	require.Equal(t, "6af6c349ac7a1e66a6dde790b4784641100753955aa37975bd90434393eb9d4b", ScalarToString(pair.Key))
+	require.Equal(t, "127.0.0.1:8080", pair.Public.Address())
+	require.True(t, pair.Public.IsTLS())
+	require.NoError(t, pair.Public.ValidSignature())
+
+	store := NewFileStore(t.TempDir(), "")
+	require.NoError(t, store.SaveKeyPair(pair))
+	loaded, err := store.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(pair))
+
+	// the private key file of the split layout is not a combined one
+	_, err = ImportCombinedKeyFile(store.(*fileStore).privateKeyFile)
+	require.ErrorIs(t, err, ErrUnknownFormat)
+	_, err = ImportCombinedKeyFile(store.(*fileStore).publicKeyFile)
+	require.ErrorIs(t, err, ErrUnknownFormat)
+	_, err = ImportCombinedKeyFile(path.Join(t.TempDir(), "missing"))
+	require.ErrorIs(t, err, ErrAbsent)
+
+	data, err := os.ReadFile(fixture)
+	require.NoError(t, err)
+	other := NewKeyPair("127.0.0.1:8080")
+	mismatch := strings.Replace(string(data), "842966d3", PointToString(other.Public.Key)[:8], 1)
+	mismatched := path.Join(t.TempDir(), "mismatched.toml")
+	require.NoError(t, os.WriteFile(mismatched, []byte(mismatch), 0o600))
+	_, err = ImportCombinedKeyFile(mismatched)
+	require.Error(t, err)
+}
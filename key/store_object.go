@@ -0,0 +1,286 @@
+package key
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/drand/drand/common"
+)
+
+// ObjectBackend keeps opaque named objects, such as the objects of a bucket of
+// a cloud object storage. It only sees the serialized objects of the store, as
+// transformed by its codecs.
+type ObjectBackend interface {
+	// PutObject stores the data under the given name, replacing the object
+	// stored under that name if any.
+	PutObject(name string, data []byte) error
+	// GetObject returns the data stored under the given name. It returns an
+	// error wrapping ErrAbsent if there is none.
+	GetObject(name string) ([]byte, error)
+	// DeleteObject deletes the object stored under the given name, if any.
+	DeleteObject(name string) error
+}
+
+// objectStore is a Store keeping its objects in an ObjectBackend, under the
+// same names as the files of the file store relative to its base folder.
+type objectStore struct {
+	// mu serializes the read-modify-write operations of this store, and is
+	// shared with the stores derived from it through WithCodec
+	mu             *sync.Mutex
+	backend        ObjectBackend
+	privateKeyName string
+	publicKeyName  string
+	shareName      string
+	distKeyName    string
+	groupName      string
+	// codecs transform the serialized objects, the first one being applied
+	// first when writing
+	codecs []Codec
+	// closed is shared with the stores derived from this store
+	closed *closeState
+}
+
+// NewObjectStore returns a store keeping the objects of the beacon in the
+// given backend. It is a CodecStore, so the objects can be encrypted before
+// they reach the backend with NewEncryptedStore.
+func NewObjectStore(backend ObjectBackend, beaconID string) Store {
+	if beaconID == "" {
+		beaconID = common.DefaultBeaconID
+	}
+	keyFolder := path.Join(beaconID, KeyFolderName)
+	groupFolder := path.Join(beaconID, GroupFolderName)
+	return &objectStore{
+		mu:             new(sync.Mutex),
+		backend:        backend,
+		privateKeyName: path.Join(keyFolder, keyFileName) + privateExtension,
+		publicKeyName:  path.Join(keyFolder, keyFileName) + publicExtension,
+		groupName:      path.Join(groupFolder, groupFileName),
+		shareName:      path.Join(groupFolder, shareFileName),
+		distKeyName:    path.Join(groupFolder, distKeyFileName),
+		closed:         newCloseState(),
+	}
+}
+
+func (o *objectStore) WithCodec(c Codec) Store {
+	derived := *o
+	derived.codecs = append([]Codec{c}, o.codecs...)
+	return &derived
+}
+
+func (o *objectStore) encode(t Tomler) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeWith(&buf, t, o.codecs); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (o *objectStore) put(name string, t Tomler) error {
+	if err := o.closed.check(); err != nil {
+		return err
+	}
+	data, err := o.encode(t)
+	if err != nil {
+		return err
+	}
+	return o.backend.PutObject(name, data)
+}
+
+func (o *objectStore) load(name string, t Tomler) error {
+	if err := o.closed.check(); err != nil {
+		return err
+	}
+	data, err := o.backend.GetObject(name)
+	if err != nil {
+		return err
+	}
+	if data, err = decodeAny(bytes.NewReader(data), o.codecs, DefaultMaxFileSize); err != nil {
+		return fmt.Errorf("config: can't decode object %s: %w", name, err)
+	}
+	if err := Decode(bytes.NewReader(data), t); err != nil {
+		return fmt.Errorf("%w: decoding object %s: %v", ErrStoreFile, name, err)
+	}
+	return nil
+}
+
+// exists returns true if an object is stored under the name.
+func (o *objectStore) exists(name string) (bool, error) {
+	if err := o.closed.check(); err != nil {
+		return false, err
+	}
+	_, err := o.backend.GetObject(name)
+	if errors.Is(err, ErrAbsent) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// checkOverwrite returns an error wrapping ErrExists if an object is stored
+// under the name and the save is not allowed to replace it.
+func (o *objectStore) checkOverwrite(name string, c saveConfig) error {
+	if c.overwrite {
+		return nil
+	}
+	exists, err := o.exists(name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%w: object %s", ErrExists, name)
+	}
+	return nil
+}
+
+func (o *objectStore) SaveKeyPair(p *Pair, opts ...SaveOption) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.checkOverwrite(o.privateKeyName, newSaveConfig(false, opts)); err != nil {
+		return err
+	}
+	if err := o.put(o.privateKeyName, p); err != nil {
+		return err
+	}
+	return o.put(o.publicKeyName, p.Public)
+}
+
+func (o *objectStore) LoadKeyPair() (*Pair, error) {
+	p := new(Pair)
+	if err := o.load(o.privateKeyName, p); err != nil {
+		return nil, err
+	}
+	return p, o.load(o.publicKeyName, p.Public)
+}
+
+func (o *objectStore) SaveShare(share *Share, opts ...SaveOption) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.checkOverwrite(o.shareName, newSaveConfig(false, opts)); err != nil {
+		return err
+	}
+	return o.put(o.shareName, share)
+}
+
+func (o *objectStore) LoadShare() (*Share, error) {
+	s := new(Share)
+	return s, o.load(o.shareName, s)
+}
+
+// SaveDistPublic saves the distributed public key then updates the one
+// embedded in the stored group, if any. Unlike with the file store, both
+// objects can't be replaced atomically.
+func (o *objectStore) SaveDistPublic(d *DistPublic, opts ...SaveOption) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.checkOverwrite(o.distKeyName, newSaveConfig(true, opts)); err != nil {
+		return err
+	}
+	if err := o.put(o.distKeyName, d); err != nil {
+		return err
+	}
+	group := new(Group)
+	if err := o.load(o.groupName, group); errors.Is(err, ErrAbsent) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if group.PublicKey != nil && group.PublicKey.Equal(d) {
+		return nil
+	}
+	group.setDistPublic(d)
+	return o.put(o.groupName, group)
+}
+
+func (o *objectStore) LoadDistPublic() (*DistPublic, error) {
+	d := new(DistPublic)
+	if err := o.load(o.distKeyName, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// SaveDKGResult saves the share then the distributed public key. If the
+// distributed public key can't be saved, the previous share is put back.
+func (o *objectStore) SaveDKGResult(share *Share, d *DistPublic, opts ...SaveOption) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.checkOverwrite(o.shareName, newSaveConfig(false, opts)); err != nil {
+		return err
+	}
+	shareData, err := o.encode(share)
+	if err != nil {
+		return err
+	}
+	distData, err := o.encode(d)
+	if err != nil {
+		return err
+	}
+	previous, err := o.backend.GetObject(o.shareName)
+	if err != nil && !errors.Is(err, ErrAbsent) {
+		return err
+	}
+	if err := o.backend.PutObject(o.shareName, shareData); err != nil {
+		return err
+	}
+	if err := o.backend.PutObject(o.distKeyName, distData); err != nil {
+		if previous == nil {
+			_ = o.backend.DeleteObject(o.shareName)
+		} else {
+			_ = o.backend.PutObject(o.shareName, previous)
+		}
+		return err
+	}
+	return nil
+}
+
+func (o *objectStore) SaveGroup(g *Group, opts ...SaveOption) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.checkOverwrite(o.groupName, newSaveConfig(true, opts)); err != nil {
+		return err
+	}
+	return o.put(o.groupName, g)
+}
+
+func (o *objectStore) LoadGroup() (*Group, error) {
+	g := new(Group)
+	return g, o.load(o.groupName, g)
+}
+
+// CompareAndSwapGroup saves the new group if the stored group has the same
+// hash as the expected one. The comparison and the save are serialized with
+// the other saves of this store only, not with other clients of the backend.
+func (o *objectStore) CompareAndSwapGroup(expected, newGroup *Group) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	current := new(Group)
+	err := o.load(o.groupName, current)
+	switch {
+	case errors.Is(err, ErrAbsent) && expected != nil:
+		return fmt.Errorf("%w: no group stored", ErrConflict)
+	case errors.Is(err, ErrAbsent):
+	case err != nil:
+		return err
+	case expected == nil:
+		return fmt.Errorf("%w: a group is already stored", ErrConflict)
+	case !bytes.Equal(current.Hash(), expected.Hash()):
+		return fmt.Errorf("%w: group hash %x", ErrConflict, current.Hash())
+	}
+	return o.put(o.groupName, newGroup)
+}
+
+// Reset deletes the objects deleted by the file store: the share, the
+// distributed public key and the group.
+func (o *objectStore) Reset(...ResetOption) error {
+	if err := o.closed.check(); err != nil {
+		return err
+	}
+	for _, name := range []string{o.distKeyName, o.shareName, o.groupName} {
+		if err := o.backend.DeleteObject(name); err != nil {
+			return fmt.Errorf("drand: err deleting object %s: %w", name, err)
+		}
+	}
+	return nil
+}
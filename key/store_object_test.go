@@ -0,0 +1,117 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memoryObjects is an ObjectBackend keeping the objects in memory.
+type memoryObjects struct {
+	sync.Mutex
+	objects map[string][]byte
+	// failPut makes the puts of that object fail
+	failPut string
+}
+
+func (m *memoryObjects) PutObject(name string, data []byte) error {
+	m.Lock()
+	defer m.Unlock()
+	if name == m.failPut {
+		return errors.New("backend unavailable")
+	}
+	if m.objects == nil {
+		m.objects = make(map[string][]byte)
+	}
+	m.objects[name] = append([]byte{}, data...)
+	return nil
+}
+
+func (m *memoryObjects) GetObject(name string) ([]byte, error) {
+	m.Lock()
+	defer m.Unlock()
+	data, ok := m.objects[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrAbsent, name)
+	}
+	return data, nil
+}
+
+func (m *memoryObjects) DeleteObject(name string) error {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.objects, name)
+	return nil
+}
+
+func TestObjectStore(t *testing.T) {
+	backend := new(memoryObjects)
+	store := NewObjectStore(backend, "")
+	ps, group := BatchIdentities(3)
+	shares, dist := dealShares(3, 2)
+
+	_, err := store.LoadKeyPair()
+	require.ErrorIs(t, err, ErrAbsent)
+	require.NoError(t, store.SaveKeyPair(ps[0]))
+	require.ErrorIs(t, store.SaveKeyPair(ps[1]), ErrExists)
+	pair, err := store.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, pair.Equal(ps[0]))
+	require.Contains(t, backend.objects, "default/key/drand_id.private")
+
+	group.PublicKey = nil
+	require.NoError(t, store.CompareAndSwapGroup(nil, group))
+	require.ErrorIs(t, store.CompareAndSwapGroup(nil, group), ErrConflict)
+	// saving the distributed public key updates the group
+	require.NoError(t, store.SaveDistPublic(dist))
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.PublicKey.Equal(dist))
+
+	require.NoError(t, store.SaveDKGResult(shares[0], dist))
+	require.ErrorIs(t, store.SaveDKGResult(shares[1], dist), ErrExists)
+	// a failed save puts the previous share back
+	backend.failPut = "default/groups/dist_key.public"
+	require.Error(t, store.SaveDKGResult(shares[1], dist, WithOverwrite(true)))
+	backend.failPut = ""
+	share, err := store.LoadShare()
+	require.NoError(t, err)
+	require.True(t, share.Share.V.Equal(shares[0].Share.V))
+	require.NoError(t, CheckConsistency(store))
+
+	require.NoError(t, store.Reset())
+	_, err = store.LoadShare()
+	require.ErrorIs(t, err, ErrAbsent)
+	_, err = store.LoadGroup()
+	require.ErrorIs(t, err, ErrAbsent)
+	_, err = store.LoadKeyPair()
+	require.NoError(t, err)
+}
+
+func TestObjectStoreConcurrentSaves(t *testing.T) {
+	store := NewObjectStore(new(memoryObjects), "")
+	pairs, _ := BatchIdentities(8)
+	errs := make(chan error, len(pairs))
+	var wg sync.WaitGroup
+	for _, p := range pairs {
+		wg.Add(1)
+		go func(p *Pair) {
+			defer wg.Done()
+			errs <- store.SaveKeyPair(p)
+		}(p)
+	}
+	wg.Wait()
+	close(errs)
+	saved := 0
+	for err := range errs {
+		if err == nil {
+			saved++
+		} else {
+			require.ErrorIs(t, err, ErrExists)
+		}
+	}
+	require.Equal(t, 1, saved)
+}
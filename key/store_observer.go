@@ -0,0 +1,69 @@
+package key
+
+// StoreObserver is notified of the lifecycle events of a file store, e.g. to
+// build tracing, auditing or debugging on top of it, see WithObserver. Like
+// the hooks, it only ever receives public metadata: the kind of the objects
+// and the errors, never their content. The calls are made synchronously, some
+// of them while the store lock is held, so they must return quickly and never
+// block nor call back into the store; slow work belongs to another goroutine.
+// Embedding NopObserver provides the events not of interest.
+type StoreObserver interface {
+	// OnOpen is called once the store of the beacon is set up.
+	OnOpen(beaconID string)
+	// OnLoad is called after each load of an object, err being the error
+	// returned by the load, if any.
+	OnLoad(kind StoreKind, err error)
+	// OnSave is called after each save of an object, err being the error
+	// returned by the save, if any. Saving the result of a DKG is reported as
+	// the save of the share then of the distributed public key.
+	OnSave(kind StoreKind, err error)
+	// OnDelete is called after the deletion of an object by Reset.
+	OnDelete(kind StoreKind, err error)
+	// OnLockAcquired is called once the store lock, which serializes the
+	// read-modify-write operations, is acquired.
+	OnLockAcquired()
+	// OnLockReleased is called once the store lock is released.
+	OnLockReleased()
+	// OnSeal is called when a store configured with WithSealing is sealed
+	// or, with sealed false, unsealed.
+	OnSeal(sealed bool)
+	// OnClose is called when the store is closed.
+	OnClose()
+}
+
+// NopObserver is a StoreObserver ignoring all the events. It is the default
+// observer of the stores.
+type NopObserver struct{}
+
+func (NopObserver) OnOpen(string)             {}
+func (NopObserver) OnLoad(StoreKind, error)   {}
+func (NopObserver) OnSave(StoreKind, error)   {}
+func (NopObserver) OnDelete(StoreKind, error) {}
+func (NopObserver) OnLockAcquired()           {}
+func (NopObserver) OnLockReleased()           {}
+func (NopObserver) OnSeal(bool)               {}
+func (NopObserver) OnClose()                  {}
+
+// WithObserver sets the observer notified of the lifecycle events of the
+// store. The observer is shared with the stores derived through WithCodec. A
+// nil observer restores the default NopObserver.
+func WithObserver(o StoreObserver) StoreOption {
+	return func(f *fileStore) {
+		if o == nil {
+			o = NopObserver{}
+		}
+		f.observer = o
+	}
+}
+
+// lock acquires the store lock.
+func (f *fileStore) lock() {
+	f.mu.Lock()
+	f.observer.OnLockAcquired()
+}
+
+// unlock releases the store lock.
+func (f *fileStore) unlock() {
+	f.mu.Unlock()
+	f.observer.OnLockReleased()
+}
@@ -0,0 +1,69 @@
+package key
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingObserver struct {
+	NopObserver
+	events   []string
+	acquired int
+	released int
+}
+
+func (r *recordingObserver) OnOpen(beaconID string) {
+	r.events = append(r.events, "open "+beaconID)
+}
+
+func (r *recordingObserver) OnLoad(kind StoreKind, err error) {
+	r.events = append(r.events, fmt.Sprintf("load %s %t", kind, err == nil))
+}
+
+func (r *recordingObserver) OnSave(kind StoreKind, err error) {
+	r.events = append(r.events, fmt.Sprintf("save %s %t", kind, err == nil))
+}
+
+func (r *recordingObserver) OnDelete(kind StoreKind, err error) {
+	r.events = append(r.events, fmt.Sprintf("delete %s %t", kind, err == nil))
+}
+
+func (r *recordingObserver) OnLockAcquired() { r.acquired++ }
+func (r *recordingObserver) OnLockReleased() { r.released++ }
+
+func (r *recordingObserver) OnClose() {
+	r.events = append(r.events, "close")
+}
+
+func TestStoreObserver(t *testing.T) {
+	observer := new(recordingObserver)
+	store := NewFileStore(t.TempDir(), "beacon", WithObserver(observer))
+	_, group := BatchIdentities(3)
+	require.NoError(t, store.SaveGroup(group))
+	_, err := store.LoadGroup()
+	require.NoError(t, err)
+	_, err = store.LoadShare()
+	require.Error(t, err)
+	require.NoError(t, store.Reset())
+	require.NoError(t, store.Close())
+
+	require.Equal(t, []string{
+		"open beacon",
+		"save group true",
+		"load group true",
+		"load share false",
+		"delete distpublic true",
+		"delete share true",
+		"delete group true",
+		"close",
+	}, observer.events)
+	// SaveGroup and Close
+	require.Equal(t, 2, observer.acquired)
+	require.Equal(t, observer.acquired, observer.released)
+
+	// the default observer ignores everything
+	store = NewFileStore(t.TempDir(), "", WithObserver(nil))
+	require.NoError(t, store.SaveGroup(group))
+}
@@ -0,0 +1,299 @@
+package key
+
+import (
+	"fmt"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+
+	"github.com/drand/drand/fs"
+	"github.com/drand/drand/log"
+)
+
+// StoreOption is a function that applies a specific setting to a file store.
+type StoreOption func(*fileStore)
+
+// WithLogger sets the logger used by the store to report non fatal issues.
+func WithLogger(l log.Logger) StoreOption {
+	return func(f *fileStore) {
+		f.log = l
+	}
+}
+
+// WithPrivateBase sets the base folder under which the private objects, the
+// private key and the share, are kept, e.g. on an encrypted mount. It defaults
+// to the base folder of the store.
+func WithPrivateBase(folder string) StoreOption {
+	return func(f *fileStore) {
+		f.privateBase = folder
+	}
+}
+
+// WithPublicBase sets the base folder under which the public objects, the
+// public key, the group and the distributed public key, are kept, e.g. on a
+// mount shared with clients. It defaults to the base folder of the store.
+func WithPublicBase(folder string) StoreOption {
+	return func(f *fileStore) {
+		f.publicBase = folder
+	}
+}
+
+// WithClock sets the clock of the store: it timestamps the records of the
+// write-ahead log, times the reads out, see WithReadTimeout, paces WatchGroup
+// and gives the local time CheckClockSkew checks. The genesis time of a new
+// group is checked against GroupParams.Clock instead. It defaults to the real
+// clock; tests can use a fake one.
+func WithClock(c clock.Clock) StoreOption {
+	return func(f *fileStore) {
+		f.clock = c
+	}
+}
+
+// WithVerifyAfterWrite sets whether the store reads every object back once it
+// is saved and checks it is equal to the saved one, turning storage or
+// encoding errors into an error of the save instead of a failure at the next
+// load. It doubles the cost of saving and is disabled by default.
+func WithVerifyAfterWrite(verify bool) StoreOption {
+	return func(f *fileStore) {
+		f.verifyAfterWrite = verify
+	}
+}
+
+// WithoutSeparatePublicFile makes the store keep the public identity of the
+// node in the private key file instead of writing a separate public key file,
+// sparing a write where disk writes are scarce. The public key is derived from
+// the private key when loading the key pair. Key pairs saved with a separate
+// public file can still be loaded.
+func WithoutSeparatePublicFile() StoreOption {
+	return func(f *fileStore) {
+		f.separatePublicFile = false
+	}
+}
+
+// WithDistPublicReference makes the store write groups referencing their
+// distributed public key by hash, the key being kept in its own file only.
+// LoadGroup then loads the distributed public key file and checks its hash is
+// the one declared by the group, failing with an error wrapping
+// ErrDistPublicHash otherwise.
+func WithDistPublicReference() StoreOption {
+	return func(f *fileStore) {
+		f.distPublicReference = true
+	}
+}
+
+// WithReadTimeout bounds the time taken by each file read of the loads, so that
+// a stalled mount, e.g. over NFS, turns into an error wrapping ErrTimeout
+// instead of blocking the caller forever. A timed out read is abandoned: its
+// goroutine leaks if the read never returns, which is acceptable for a failing
+// mount. A timeout is transient for NewRetryingStore, which retries the load
+// as long as its context allows. Zero, the default, disables the timeout.
+func WithReadTimeout(d time.Duration) StoreOption {
+	return func(f *fileStore) {
+		f.readTimeout = d
+	}
+}
+
+// WithGroupCandidates makes LoadGroup consider, along with the group file, the
+// files of the given names in the groups folder, such as "drand_group.new.toml"
+// dropped in by an operator during a transition. LoadGroup loads the newest
+// valid group among them, see the file store's LoadGroup, and skips the
+// invalid ones with a warning. Saves still write the group file.
+func WithGroupCandidates(names ...string) StoreOption {
+	return func(f *fileStore) {
+		f.groupCandidates = names
+	}
+}
+
+// DefaultMaxFileSize is the maximum size of the files and streams decoded by
+// default, large enough for groups of tens of thousands of nodes.
+const DefaultMaxFileSize = 8 << 20
+
+// WithMaxFileSize sets the maximum size, in bytes, of the files loaded by the
+// store, before and after being decoded by its codecs. Larger files are
+// rejected with ErrTooLarge before being parsed, so that a corrupted or
+// malicious file can't exhaust the memory of the node. It defaults to
+// DefaultMaxFileSize. The group fragments resolved through include directives
+// are always bounded by DefaultMaxFileSize.
+func WithMaxFileSize(n int64) StoreOption {
+	return func(f *fileStore) {
+		f.maxFileSize = n
+	}
+}
+
+// WithShareHistory makes the store keep the last n shares replaced by a save,
+// so that a node can recover its previous share if a resharing yields a bad
+// one. The replaced shares are kept next to the active one, the most recent in
+// dist_key.1.private, and the older ones are securely deleted. It defaults to
+// zero, keeping no history.
+func WithShareHistory(n int) StoreOption {
+	return func(f *fileStore) {
+		f.shareHistory = n
+	}
+}
+
+// WithMinimalGroupDiffs makes the saves of the group rewrite only the entries
+// of the group file that changed: the fields of the group, the tables of the
+// nodes, matched by public key, and the distributed public key are kept byte
+// for byte, along with the comments above them, when they hold the same
+// content. Editing a node with UpdateNode then changes only its table, keeping
+// the diffs of a group file tracked in git minimal. A fresh group file is
+// written as EncodeGroup does.
+func WithMinimalGroupDiffs() StoreOption {
+	return func(f *fileStore) {
+		f.minimalGroupDiffs = true
+	}
+}
+
+// DefaultWatchInterval is the interval at which WatchGroup checks the group
+// file by default.
+const DefaultWatchInterval = time.Second
+
+// WithSealing seals the private objects of the store, the key pair and the
+// shares, with the key split among holders by SplitSealKey, given its public
+// commitment and threshold. The store starts sealed: saving or loading a
+// private object fails with ErrSealed until a threshold of key holders give
+// their key shares to Unseal, see SealedStore, while the public objects are
+// accessed as usual. Private objects are then encrypted as with
+// NewPassphraseCodec, under the reconstructed key, and private files written
+// before the store was sealed can still be read.
+func WithSealing(public []byte, threshold int) StoreOption {
+	return func(f *fileStore) {
+		f.seal = &sealState{public: public, threshold: threshold}
+	}
+}
+
+// WithContentAddressedGroups makes the store keep every group it saves in the
+// groups folder under its hash, as <hash>.toml, the group file becoming a
+// symbolic link to the archived active group. Loads of the active group go
+// through the link as usual, while older groups are loaded with
+// LoadGroupByHash, see GroupArchive. As the names only depend on the content,
+// the archives of several nodes can be merged on a shared backend without
+// duplicates.
+func WithContentAddressedGroups() StoreOption {
+	return func(f *fileStore) {
+		f.contentAddressedGroups = true
+	}
+}
+
+// WithWriteAheadLog makes every save of the store go through its write-ahead
+// log, kept in the beacon folder, and not only the saves replacing several
+// files together such as SaveDKGResult: before replacing any file, a save
+// appends an intent record holding the kind of object, the hash of the new
+// content and the time, then a commit record once the files are in place.
+// Saves then always write aside and rename, including those of the key pair
+// and the share. Opening the store completes a save interrupted by a crash if
+// the new contents are intact, or rolls it back otherwise. The log only holds
+// hashes, never the secrets, and is compacted once it grows past a few hundred
+// kilobytes, keeping only the writes still in progress.
+func WithWriteAheadLog() StoreOption {
+	return func(f *fileStore) {
+		f.journalSaves = true
+	}
+}
+
+// WithWatchInterval sets the interval at which WatchGroup checks the group
+// file for changes. It must be positive, WatchGroup returns an error
+// otherwise.
+func WithWatchInterval(d time.Duration) StoreOption {
+	return func(f *fileStore) {
+		f.watchInterval = d
+	}
+}
+
+// DefaultMaxClockSkew is the maximum clock skew tolerated by default by
+// CheckClockSkew.
+const DefaultMaxClockSkew = 5 * time.Second
+
+// WithMaxClockSkew sets the maximum clock skew tolerated by CheckClockSkew.
+func WithMaxClockSkew(d time.Duration) StoreOption {
+	return func(f *fileStore) {
+		f.maxClockSkew = d
+	}
+}
+
+// Hooks are callbacks invoked by the store around the saving of the objects,
+// letting operators trigger external workflows such as notifying a key
+// management system. Hooks only ever receive public metadata, never the
+// secrets. All hooks are optional and run synchronously.
+type Hooks struct {
+	// BeforeSave is called before saving any object. A non-nil error vetoes
+	// the save, which then returns that error.
+	BeforeSave func(kind StoreKind, metadata string) error
+	// OnKeyPairSaved is called with the address of the node once its key pair
+	// has been saved.
+	OnKeyPairSaved func(addr string) error
+	// OnShareSaved is called with the hex encoded hash of the stored group, if
+	// any, once the private share has been saved.
+	OnShareSaved func(groupHash string) error
+	// OnGroupSaved is called with the hex encoded hash of the group once it has
+	// been saved.
+	OnGroupSaved func(groupHash string) error
+}
+
+// WithHooks sets the hooks invoked by the store when saving objects. Errors
+// returned by the On* hooks are logged but do not fail the save.
+func WithHooks(h Hooks) StoreOption {
+	return func(f *fileStore) {
+		f.hooks = h
+	}
+}
+
+func (f *fileStore) beforeSave(kind StoreKind, metadata string) error {
+	if err := f.closed.check(); err != nil {
+		return err
+	}
+	if f.hooks.BeforeSave == nil {
+		return nil
+	}
+	return f.hooks.BeforeSave(kind, metadata)
+}
+
+func (f *fileStore) afterSave(kind StoreKind, hook func(string) error, metadata string) {
+	if hook == nil {
+		return
+	}
+	if err := hook(metadata); err != nil {
+		f.log.Warnw("", "store", "hook failed", "kind", kind.String(), "err", err)
+	}
+}
+
+// SaveOption is a function that applies a specific setting to a single save.
+type SaveOption func(*saveConfig)
+
+type saveConfig struct {
+	overwrite bool
+}
+
+// WithOverwrite sets whether a save can replace an existing object. By default,
+// private objects (key pair and share) are never replaced, as they can't be
+// recovered once lost, while public ones (group and distributed public key)
+// are.
+func WithOverwrite(overwrite bool) SaveOption {
+	return func(c *saveConfig) {
+		c.overwrite = overwrite
+	}
+}
+
+func newSaveConfig(overwrite bool, opts []SaveOption) saveConfig {
+	c := saveConfig{overwrite: overwrite}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// checkOverwrite returns an error wrapping ErrExists if the file exists and
+// the save is not allowed to replace it.
+func checkOverwrite(filePath string, c saveConfig) error {
+	if c.overwrite {
+		return nil
+	}
+	exists, err := fs.Exists(filePath)
+	if err != nil {
+		return wrapFileError(filePath, err)
+	}
+	if exists {
+		return fmt.Errorf("%w: %s", ErrExists, filePath)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package key
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/drand/drand/fs"
+)
+
+// PendingGroupStore is implemented by stores able to hold the group of a
+// running DKG aside from the active group, so that a crash during the DKG
+// leaves the active group untouched.
+type PendingGroupStore interface {
+	// SavePendingGroup saves the prospective group, replacing the pending
+	// group if any.
+	SavePendingGroup(g *Group) error
+	// LoadPendingGroup loads the pending group. It returns an error wrapping
+	// ErrAbsent if there is none.
+	LoadPendingGroup() (*Group, error)
+	// PromotePendingGroup makes the pending group the active one, in a
+	// single step: on error, both are left as they were. It returns an error
+	// wrapping ErrAbsent if there is no pending group.
+	PromotePendingGroup() error
+	// DiscardPendingGroup deletes the pending group, if any, e.g. once the
+	// DKG failed.
+	DiscardPendingGroup() error
+}
+
+func (f *fileStore) SavePendingGroup(g *Group) error {
+	f.lock()
+	defer f.unlock()
+	w := &atomicWrite{closed: f.closed, wal: f.wal, codecs: f.codecs}
+	if err := w.add(f.pendingGroupFile, f.storedGroup(g), false); err != nil {
+		return err
+	}
+	return w.commit()
+}
+
+func (f *fileStore) LoadPendingGroup() (*Group, error) {
+	g := new(Group)
+	if err := f.load(f.pendingGroupFile, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// PromotePendingGroup renames the pending group file over the group file, once
+// it is checked to hold a group, which atomically replaces the active group.
+// The save hooks of the group are run as for SaveGroup.
+func (f *fileStore) PromotePendingGroup() error {
+	f.lock()
+	defer f.unlock()
+	g := new(Group)
+	if err := f.load(f.pendingGroupFile, g); err != nil {
+		return fmt.Errorf("store: promoting pending group: %w", err)
+	}
+	hash := hex.EncodeToString(g.Hash())
+	if err := f.beforeSave(GroupKind, hash); err != nil {
+		return err
+	}
+	if err := os.Rename(f.pendingGroupFile, f.groupFile); err != nil {
+		return wrapFileError(f.groupFile, err)
+	}
+	f.afterSave(GroupKind, f.hooks.OnGroupSaved, hash)
+	return nil
+}
+
+func (f *fileStore) DiscardPendingGroup() error {
+	f.lock()
+	defer f.unlock()
+	if err := f.closed.check(); err != nil {
+		return err
+	}
+	if exists, _ := fs.Exists(f.pendingGroupFile); !exists {
+		return nil
+	}
+	return wrapFileError(f.pendingGroupFile, os.Remove(f.pendingGroupFile))
+}
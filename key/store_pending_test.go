@@ -0,0 +1,50 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorePendingGroup(t *testing.T) {
+	store := NewFileStore(t.TempDir(), "")
+	pending := store.(PendingGroupStore)
+	_, err := pending.LoadPendingGroup()
+	require.ErrorIs(t, err, ErrAbsent)
+	require.ErrorIs(t, pending.PromotePendingGroup(), ErrAbsent)
+	require.NoError(t, pending.DiscardPendingGroup())
+
+	_, active := BatchIdentities(3)
+	require.NoError(t, store.SaveGroup(active))
+	_, next := BatchIdentities(4)
+
+	// discard path: the active group is untouched
+	require.NoError(t, pending.SavePendingGroup(next))
+	loaded, err := pending.LoadPendingGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(next))
+	require.NoError(t, pending.DiscardPendingGroup())
+	_, err = pending.LoadPendingGroup()
+	require.ErrorIs(t, err, ErrAbsent)
+	loaded, err = store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(active))
+
+	// promote path
+	var promoted string
+	store = NewFileStore(store.(*fileStore).baseFolder, "", WithHooks(Hooks{
+		OnGroupSaved: func(hash string) error {
+			promoted = hash
+			return nil
+		},
+	}))
+	pending = store.(PendingGroupStore)
+	require.NoError(t, pending.SavePendingGroup(next))
+	require.NoError(t, pending.PromotePendingGroup())
+	loaded, err = store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(next))
+	require.NotEmpty(t, promoted)
+	_, err = pending.LoadPendingGroup()
+	require.ErrorIs(t, err, ErrAbsent)
+}
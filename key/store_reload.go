@@ -0,0 +1,78 @@
+package key
+
+import (
+	"sync"
+)
+
+// InPlaceReloader is implemented by stores able to reload an object into an
+// existing struct, for the callers holding pointers to it in many places, e.g.
+// when WatchGroup notifies a change of the group file.
+//
+// Each reload replaces the content of the struct while holding the write lock
+// returned by ReloadLock for that struct. Code reading a struct which may be
+// reloaded concurrently must hold its read lock, as in:
+//
+//	l := key.ReloadLock(group)
+//	l.RLock()
+//	threshold := group.Threshold
+//	l.RUnlock()
+//
+// On error, the struct is left untouched.
+type InPlaceReloader interface {
+	// ReloadInto loads the group and copies it into g.
+	ReloadInto(g *Group) error
+	// ReloadShareInto loads the share and copies it into s.
+	ReloadShareInto(s *Share) error
+	// ReloadDistPublicInto loads the distributed public key and copies it
+	// into d.
+	ReloadDistPublicInto(d *DistPublic) error
+}
+
+// reloadLocks holds the lock of each struct reloaded in place, by pointer.
+var reloadLocks sync.Map
+
+// ReloadLock returns the lock guarding the in-place reloads of the struct
+// pointed to by obj, see InPlaceReloader. The same lock is returned for the
+// same pointer for the lifetime of the process.
+func ReloadLock(obj interface{}) *sync.RWMutex {
+	l, _ := reloadLocks.LoadOrStore(obj, new(sync.RWMutex))
+	return l.(*sync.RWMutex)
+}
+
+// ReloadInto loads the group before taking the lock, so that readers are only
+// blocked while the struct is copied.
+func (f *fileStore) ReloadInto(g *Group) error {
+	loaded, err := f.LoadGroup()
+	if err != nil {
+		return err
+	}
+	l := ReloadLock(g)
+	l.Lock()
+	defer l.Unlock()
+	*g = *loaded
+	return nil
+}
+
+func (f *fileStore) ReloadShareInto(s *Share) error {
+	loaded, err := f.LoadShare()
+	if err != nil {
+		return err
+	}
+	l := ReloadLock(s)
+	l.Lock()
+	defer l.Unlock()
+	*s = *loaded
+	return nil
+}
+
+func (f *fileStore) ReloadDistPublicInto(d *DistPublic) error {
+	loaded, err := f.LoadDistPublic()
+	if err != nil {
+		return err
+	}
+	l := ReloadLock(d)
+	l.Lock()
+	defer l.Unlock()
+	*d = *loaded
+	return nil
+}
@@ -0,0 +1,59 @@
+package key
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreReloadInto(t *testing.T) {
+	store := NewFileStore(t.TempDir(), "")
+	reloader := store.(InPlaceReloader)
+	_, first := BatchIdentities(3)
+	shares, dist := dealShares(3, 2)
+	require.NoError(t, store.SaveGroup(first))
+	require.NoError(t, store.SaveDKGResult(shares[0], dist))
+
+	held, err := store.LoadGroup()
+	require.NoError(t, err)
+	heldShare, err := store.LoadShare()
+	require.NoError(t, err)
+	heldDist, err := store.LoadDistPublic()
+	require.NoError(t, err)
+	alias := held
+
+	_, second := BatchIdentities(5)
+	newShares, newDist := dealShares(3, 2)
+	require.NoError(t, store.SaveGroup(second))
+	require.NoError(t, store.SaveDKGResult(newShares[1], newDist, WithOverwrite(true)))
+
+	// readers hold the read lock while the group is reloaded
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				l := ReloadLock(alias)
+				l.RLock()
+				_ = alias.Len()
+				l.RUnlock()
+			}
+		}()
+	}
+	require.NoError(t, reloader.ReloadInto(held))
+	wg.Wait()
+	require.True(t, alias.Equal(second))
+
+	require.NoError(t, reloader.ReloadShareInto(heldShare))
+	require.True(t, heldShare.Equal(newShares[1]))
+	require.NoError(t, reloader.ReloadDistPublicInto(heldDist))
+	require.True(t, heldDist.Equal(newDist))
+
+	// a failed reload leaves the struct untouched
+	require.NoError(t, store.Reset())
+	require.ErrorIs(t, reloader.ReloadInto(held), ErrAbsent)
+	require.True(t, held.Equal(second))
+	require.Same(t, ReloadLock(held), ReloadLock(alias))
+}
@@ -0,0 +1,40 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/drand/drand/fs"
+)
+
+// ReshareTracker is implemented by stores able to tell when the network last
+// reshared, e.g. for a monitoring endpoint to alert on overdue reshares.
+type ReshareTracker interface {
+	// LastReshareTime returns the time of the last resharing of the node. It
+	// returns an error wrapping ErrAbsent if the node never reshared.
+	LastReshareTime() (time.Time, error)
+}
+
+// LastReshareTime returns the transition time of the newest group, the one
+// LoadGroup returns, which is set once the network reshared. Without such a
+// group, it falls back to the modification time of the share file, provided
+// the share history shows it replaced another share, see WithShareHistory.
+func (f *fileStore) LastReshareTime() (time.Time, error) {
+	g, err := f.LoadGroup()
+	switch {
+	case err == nil && g.TransitionTime != 0:
+		return time.Unix(g.TransitionTime, 0), nil
+	case err != nil && !errors.Is(err, ErrAbsent):
+		return time.Time{}, err
+	}
+	if replaced, _ := fs.Exists(f.shareHistoryFile(1)); f.shareHistory <= 0 || !replaced {
+		return time.Time{}, fmt.Errorf("%w: no reshare recorded", ErrAbsent)
+	}
+	info, err := os.Stat(f.shareFile)
+	if err != nil {
+		return time.Time{}, wrapFileError(f.shareFile, err)
+	}
+	return info.ModTime(), nil
+}
@@ -0,0 +1,49 @@
+package key
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreLastReshareTime(t *testing.T) {
+	store := NewFileStore(t.TempDir(), "", WithShareHistory(2), WithGroupCandidates("next.toml"))
+	f := store.(*fileStore)
+	tracker := store.(ReshareTracker)
+	_, err := tracker.LastReshareTime()
+	require.ErrorIs(t, err, ErrAbsent)
+
+	_, group := BatchIdentities(3)
+	shares, dist := dealShares(3, 2)
+	group.PublicKey = dist
+	require.NoError(t, store.SaveGroup(group))
+	require.NoError(t, store.SaveShare(shares[0]))
+	_, err = tracker.LastReshareTime()
+	require.ErrorIs(t, err, ErrAbsent)
+
+	// without a transition time, a replaced share tells when it happened
+	require.NoError(t, store.SaveShare(shares[1], WithOverwrite(true)))
+	info, err := os.Stat(f.shareFile)
+	require.NoError(t, err)
+	last, err := tracker.LastReshareTime()
+	require.NoError(t, err)
+	require.True(t, last.Equal(info.ModTime()))
+
+	transition := time.Now().Add(-time.Hour).Truncate(time.Second)
+	group.TransitionTime = transition.Unix()
+	require.NoError(t, store.SaveGroup(group, WithOverwrite(true)))
+	last, err = tracker.LastReshareTime()
+	require.NoError(t, err)
+	require.True(t, last.Equal(transition))
+
+	// the newest epoch wins
+	next := group.Copy()
+	next.TransitionTime = transition.Add(time.Minute).Unix()
+	require.NoError(t, Save(filepath.Join(filepath.Dir(f.groupFile), "next.toml"), next, false))
+	last, err = tracker.LastReshareTime()
+	require.NoError(t, err)
+	require.Equal(t, next.TransitionTime, last.Unix())
+}
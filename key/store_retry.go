@@ -0,0 +1,174 @@
+package key
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RetryPolicy controls how a store returned by NewRetryingStore retries the
+// operations failing with a transient error.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of an operation, the
+	// first one included. Values below 2 disable the retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It doubles after each
+	// failed attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two attempts. Zero means no cap.
+	MaxDelay time.Duration
+	// RetrySaves enables the retries of the saves and of Reset, which can be
+	// repeated safely. CompareAndSwapGroup is never retried as a first
+	// attempt may have succeeded without its caller knowing.
+	RetrySaves bool
+	// Retryable reports whether an operation failing with the given error
+	// can be retried. It defaults to IsRetryable.
+	Retryable func(error) bool
+}
+
+// IsRetryable returns false for the errors no retry can fix, such as ErrAbsent
+// or a cancelled context, and true for all the others.
+func IsRetryable(err error) bool {
+	for _, permanent := range []error{
+		ErrAbsent, ErrExists, ErrReadOnly, ErrConflict, ErrBadSignature, ErrCorrupted, ErrUnknownFormat, ErrSealed, ErrClosed,
+		context.Canceled, context.DeadlineExceeded,
+	} {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContextStore is implemented by stores whose operations can be bounded by a
+// context.
+type ContextStore interface {
+	Store
+	// WithContext returns a store whose operations give up once ctx is done.
+	WithContext(ctx context.Context) Store
+}
+
+// NewRetryingStore returns a store retrying the operations of the inner store
+// that fail with a transient error, e.g. due to a network issue with a remote
+// backend, waiting longer after each failure as set by the policy. The retries
+// can be bounded by a deadline through its WithContext method: no attempt
+// starts once the context is done.
+func NewRetryingStore(inner Store, policy RetryPolicy) Store {
+	if policy.Retryable == nil {
+		policy.Retryable = IsRetryable
+	}
+	return &retryingStore{inner: inner, policy: policy, ctx: context.Background()}
+}
+
+type retryingStore struct {
+	inner  Store
+	policy RetryPolicy
+	ctx    context.Context
+}
+
+func (r *retryingStore) WithContext(ctx context.Context) Store {
+	derived := *r
+	derived.ctx = ctx
+	return &derived
+}
+
+// do runs op until it succeeds, fails with an error that can't be retried or
+// the policy or the context stop the retries, and returns its last error.
+func (r *retryingStore) do(op func() error) error {
+	delay := r.policy.BaseDelay
+	for attempt := 1; ; attempt++ {
+		if err := r.ctx.Err(); err != nil {
+			return err
+		}
+		err := op()
+		if err == nil || attempt >= r.policy.MaxAttempts || !r.policy.Retryable(err) {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-r.ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("store: giving up after %d attempts (%v): %w", attempt, r.ctx.Err(), err)
+		case <-timer.C:
+		}
+		delay *= 2
+		if r.policy.MaxDelay > 0 && delay > r.policy.MaxDelay {
+			delay = r.policy.MaxDelay
+		}
+	}
+}
+
+// save runs op as do does if the policy allows retrying the saves.
+func (r *retryingStore) save(op func() error) error {
+	if !r.policy.RetrySaves {
+		if err := r.ctx.Err(); err != nil {
+			return err
+		}
+		return op()
+	}
+	return r.do(op)
+}
+
+func (r *retryingStore) SaveKeyPair(p *Pair, opts ...SaveOption) error {
+	return r.save(func() error { return r.inner.SaveKeyPair(p, opts...) })
+}
+
+func (r *retryingStore) LoadKeyPair() (p *Pair, err error) {
+	err = r.do(func() error {
+		p, err = r.inner.LoadKeyPair()
+		return err
+	})
+	return p, err
+}
+
+func (r *retryingStore) SaveShare(share *Share, opts ...SaveOption) error {
+	return r.save(func() error { return r.inner.SaveShare(share, opts...) })
+}
+
+func (r *retryingStore) LoadShare() (s *Share, err error) {
+	err = r.do(func() error {
+		s, err = r.inner.LoadShare()
+		return err
+	})
+	return s, err
+}
+
+func (r *retryingStore) SaveDistPublic(d *DistPublic, opts ...SaveOption) error {
+	return r.save(func() error { return r.inner.SaveDistPublic(d, opts...) })
+}
+
+func (r *retryingStore) LoadDistPublic() (d *DistPublic, err error) {
+	err = r.do(func() error {
+		d, err = r.inner.LoadDistPublic()
+		return err
+	})
+	return d, err
+}
+
+func (r *retryingStore) SaveDKGResult(share *Share, d *DistPublic, opts ...SaveOption) error {
+	return r.save(func() error { return r.inner.SaveDKGResult(share, d, opts...) })
+}
+
+func (r *retryingStore) SaveGroup(g *Group, opts ...SaveOption) error {
+	return r.save(func() error { return r.inner.SaveGroup(g, opts...) })
+}
+
+func (r *retryingStore) LoadGroup() (g *Group, err error) {
+	err = r.do(func() error {
+		g, err = r.inner.LoadGroup()
+		return err
+	})
+	return g, err
+}
+
+func (r *retryingStore) CompareAndSwapGroup(expected, newGroup *Group) error {
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	return r.inner.CompareAndSwapGroup(expected, newGroup)
+}
+
+func (r *retryingStore) Reset(opts ...ResetOption) error {
+	return r.save(func() error { return r.inner.Reset(opts...) })
+}
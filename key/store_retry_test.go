@@ -0,0 +1,88 @@
+package key
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errFlaky = errors.New("connection reset")
+
+// flakyStore fails the loads and the saves of groups while failures is
+// positive.
+type flakyStore struct {
+	Store
+	failures int
+	calls    int
+}
+
+func (f *flakyStore) fail() error {
+	f.calls++
+	if f.failures > 0 {
+		f.failures--
+		return errFlaky
+	}
+	return nil
+}
+
+func (f *flakyStore) LoadGroup() (*Group, error) {
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return f.Store.LoadGroup()
+}
+
+func (f *flakyStore) SaveGroup(g *Group, opts ...SaveOption) error {
+	if err := f.fail(); err != nil {
+		return err
+	}
+	return f.Store.SaveGroup(g, opts...)
+}
+
+func TestRetryingStore(t *testing.T) {
+	_, group := BatchIdentities(3)
+	inner := &flakyStore{Store: NewFileStore(t.TempDir(), "")}
+	policy := RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond}
+	store := NewRetryingStore(inner, policy)
+
+	// saves are not retried unless asked to
+	inner.failures = 1
+	require.ErrorIs(t, store.SaveGroup(group), errFlaky)
+	require.Equal(t, 1, inner.calls)
+	policy.RetrySaves = true
+	store = NewRetryingStore(inner, policy)
+	inner.failures, inner.calls = 1, 0
+	require.NoError(t, store.SaveGroup(group))
+	require.Equal(t, 2, inner.calls)
+
+	inner.failures, inner.calls = 3, 0
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.Equal(t, group.Hash(), loaded.Hash())
+	require.Equal(t, 4, inner.calls)
+
+	inner.failures, inner.calls = 4, 0
+	_, err = store.LoadGroup()
+	require.ErrorIs(t, err, errFlaky)
+	require.Equal(t, 4, inner.calls)
+
+	// missing objects are reported at once
+	inner.failures = 0
+	_, err = store.LoadShare()
+	require.ErrorIs(t, err, ErrAbsent)
+
+	// the context bounds the retries
+	policy.BaseDelay = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	store = NewRetryingStore(inner, policy).(ContextStore).WithContext(ctx)
+	inner.failures, inner.calls = 2, 0
+	_, err = store.LoadGroup()
+	require.ErrorIs(t, err, errFlaky)
+	require.Equal(t, 1, inner.calls)
+	_, err = store.LoadGroup()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
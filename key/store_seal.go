@@ -0,0 +1,137 @@
+package key
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/drand/kyber/share"
+	"github.com/drand/kyber/util/random"
+)
+
+// SplitSealKey generates the key sealing the private objects of a store, see
+// WithSealing, and splits it with Shamir's secret sharing among the given
+// number of holders, so that any threshold of them can unseal the store while
+// fewer learn nothing about the key. It returns the public commitment to the
+// key, to configure the store with, and the key share of each holder.
+func SplitSealKey(holders, threshold int) (public []byte, keyShares [][]byte, err error) {
+	if threshold < 1 || threshold > holders {
+		return nil, nil, fmt.Errorf("store: invalid threshold %d for %d key holders", threshold, holders)
+	}
+	poly := share.NewPriPoly(KeyGroup, threshold, nil, random.New())
+	if public, err = KeyGroup.Point().Mul(poly.Secret(), nil).MarshalBinary(); err != nil {
+		return nil, nil, err
+	}
+	for _, s := range poly.Shares(holders) {
+		v, err := s.V.MarshalBinary()
+		if err != nil {
+			return nil, nil, err
+		}
+		keyShare := make([]byte, 4, 4+len(v))
+		binary.BigEndian.PutUint32(keyShare, uint32(s.I))
+		keyShares = append(keyShares, append(keyShare, v...))
+	}
+	return public, keyShares, nil
+}
+
+// SealedStore is implemented by stores whose private objects stay sealed until
+// a threshold of key holders unseal them, see WithSealing.
+type SealedStore interface {
+	// Unseal reconstructs the sealing key from the given key shares, as
+	// returned by SplitSealKey, and gives access to the private objects. It
+	// fails with an error wrapping ErrSealed if there are fewer shares than
+	// the threshold or if they don't reconstruct the key the store is sealed
+	// with, in which case the store stays sealed.
+	Unseal(keyShares [][]byte) error
+	// Seal forgets the sealing key, so that private objects can't be accessed
+	// until the store is unsealed again.
+	Seal()
+}
+
+// sealState holds the sealing key of a store once unsealed. It is shared by
+// the stores derived from the one configured with WithSealing.
+type sealState struct {
+	// public is the commitment to the sealing key
+	public    []byte
+	threshold int
+
+	mu sync.RWMutex
+	// codec encrypts the private objects, nil while sealed
+	codec Codec
+}
+
+func (f *fileStore) Unseal(keyShares [][]byte) error {
+	if f.seal == nil {
+		return errors.New("store: not configured with sealing")
+	}
+	if len(keyShares) < f.seal.threshold {
+		return fmt.Errorf("%w: %d key shares given, %d needed", ErrSealed, len(keyShares), f.seal.threshold)
+	}
+	shares := make([]*share.PriShare, 0, len(keyShares))
+	for i, ks := range keyShares {
+		if len(ks) <= 4 {
+			return fmt.Errorf("%w: key share %d is truncated", ErrSealed, i)
+		}
+		v := KeyGroup.Scalar()
+		if err := v.UnmarshalBinary(ks[4:]); err != nil {
+			return fmt.Errorf("%w: key share %d: %v", ErrSealed, i, err)
+		}
+		shares = append(shares, &share.PriShare{I: int(binary.BigEndian.Uint32(ks)), V: v})
+	}
+	secret, err := share.RecoverSecret(KeyGroup, shares, f.seal.threshold, len(shares))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSealed, err)
+	}
+	public, err := KeyGroup.Point().Mul(secret, nil).MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if string(public) != string(f.seal.public) {
+		return fmt.Errorf("%w: the key shares don't reconstruct the sealing key", ErrSealed)
+	}
+	passphrase, err := secret.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	f.seal.mu.Lock()
+	f.seal.codec = NewPassphraseCodec(passphrase)
+	f.seal.mu.Unlock()
+	f.observer.OnSeal(false)
+	return nil
+}
+
+func (f *fileStore) Seal() {
+	if f.seal == nil {
+		return
+	}
+	f.seal.mu.Lock()
+	f.seal.codec = nil
+	f.seal.mu.Unlock()
+	f.observer.OnSeal(true)
+}
+
+// codecsFor returns the codecs applied to a file of the store, secure for the
+// private objects: those also go through the sealing codec, if any, and
+// can't be accessed while the store is sealed. As all the reads and writes of
+// files get their codecs from it, it fails once the store is closed.
+func (f *fileStore) codecsFor(secure bool) ([]Codec, error) {
+	if err := f.closed.check(); err != nil {
+		return nil, err
+	}
+	if !secure || f.seal == nil {
+		return f.codecs, nil
+	}
+	f.seal.mu.RLock()
+	defer f.seal.mu.RUnlock()
+	if f.seal.codec == nil {
+		return nil, fmt.Errorf("%w: can't access the private objects", ErrSealed)
+	}
+	return append(f.codecs[:len(f.codecs):len(f.codecs)], f.seal.codec), nil
+}
+
+// isPrivateFile returns true for the files holding private objects.
+func isPrivateFile(filePath string) bool {
+	return strings.HasSuffix(filePath, privateExtension)
+}
@@ -0,0 +1,76 @@
+package key
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreSealing(t *testing.T) {
+	pairs, group := BatchIdentities(3)
+	shares, dist := dealShares(3, group.Threshold)
+	group.PublicKey = dist
+	public, keyShares, err := SplitSealKey(5, 3)
+	require.NoError(t, err)
+	require.Len(t, keyShares, 5)
+	base := t.TempDir()
+	store := NewFileStore(base, "", WithSealing(public, 3))
+	sealed := store.(SealedStore)
+
+	// public objects work normally while sealed
+	require.NoError(t, store.SaveGroup(group))
+	require.NoError(t, store.SaveDistPublic(dist))
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(group))
+	require.ErrorIs(t, store.SaveKeyPair(pairs[0]), ErrSealed)
+	require.NoFileExists(t, store.(*fileStore).privateKeyFile)
+	require.ErrorIs(t, store.SaveDKGResult(shares[0], dist), ErrSealed)
+	require.NoFileExists(t, store.(*fileStore).shareFile)
+	_, err = store.LoadKeyPair()
+	require.ErrorIs(t, err, ErrSealed)
+
+	// insufficient or foreign key shares
+	require.ErrorIs(t, sealed.Unseal(keyShares[:2]), ErrSealed)
+	_, otherShares, err := SplitSealKey(5, 3)
+	require.NoError(t, err)
+	require.ErrorIs(t, sealed.Unseal(otherShares[:3]), ErrSealed)
+	require.ErrorIs(t, sealed.Unseal([][]byte{keyShares[0], keyShares[1], otherShares[2]}), ErrSealed)
+	_, err = store.LoadShare()
+	require.ErrorIs(t, err, ErrSealed)
+
+	require.NoError(t, sealed.Unseal(keyShares[1:4]))
+	require.NoError(t, store.SaveKeyPair(pairs[0]))
+	require.NoError(t, store.SaveDKGResult(shares[0], dist))
+	loadedShare, err := store.LoadShare()
+	require.NoError(t, err)
+	require.True(t, loadedShare.Share.V.Equal(shares[0].Share.V))
+	for _, file := range []string{store.(*fileStore).privateKeyFile, store.(*fileStore).shareFile} {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(data, encryptionMagic), file)
+	}
+	// the distributed public key saved along the share is not sealed
+	loadedDist, err := NewFileStore(base, "").LoadDistPublic()
+	require.NoError(t, err)
+	require.True(t, loadedDist.Equal(dist))
+	_, err = NewFileStore(base, "").LoadShare()
+	require.Error(t, err)
+
+	sealed.Seal()
+	_, err = store.LoadShare()
+	require.ErrorIs(t, err, ErrSealed)
+
+	// any threshold of holders unseal the store
+	reopened := NewFileStore(base, "", WithSealing(public, 3))
+	require.NoError(t, reopened.(SealedStore).Unseal([][]byte{keyShares[4], keyShares[0], keyShares[2]}))
+	loadedPair, err := reopened.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, loadedPair.Key.Equal(pairs[0].Key))
+
+	_, _, err = SplitSealKey(2, 3)
+	require.Error(t, err)
+	require.Error(t, NewFileStore(base, "").(SealedStore).Unseal(keyShares))
+}
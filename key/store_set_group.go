@@ -0,0 +1,66 @@
+package key
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// GroupSetter is implemented by stores able to replace their group safely.
+type GroupSetter interface {
+	// SetGroup checks the group is valid, replaces the stored group with it
+	// atomically and reads it back. If the group read back is not the saved
+	// one, the previous group is restored and an error wrapping ErrCorrupted
+	// is returned, so that a corrupt group is never left in the store. It is
+	// the recommended way to update the group of a running node.
+	SetGroup(g *Group) error
+}
+
+// SetGroup holds the store lock from the validation of the group until it is
+// verified on disk, so that no other save can interleave.
+func (f *fileStore) SetGroup(g *Group) (err error) {
+	defer func() { f.observer.OnSave(GroupKind, err) }()
+	f.lock()
+	defer f.unlock()
+	if err := g.Valid(); err != nil {
+		return fmt.Errorf("store: refusing to set an invalid group: %w", err)
+	}
+	hash := hex.EncodeToString(g.Hash())
+	if err := f.beforeSave(GroupKind, hash); err != nil {
+		return err
+	}
+
+	w := &atomicWrite{closed: f.closed, wal: f.wal, codecs: f.codecs}
+	if f.distPublicReference && g.PublicKey != nil {
+		if err := w.add(f.distKeyFile, g.PublicKey, false); err != nil {
+			return err
+		}
+	}
+	if err := w.add(f.groupFile, f.groupFileObject(g), false); err != nil {
+		return err
+	}
+	if err := w.commitVerified(func() error { return f.checkGroupFile(g) }); err != nil {
+		return err
+	}
+	if err := f.archiveGroup(); err != nil {
+		return err
+	}
+	f.afterSave(GroupKind, f.hooks.OnGroupSaved, hash)
+	return nil
+}
+
+// checkGroupFile reads the group file back, regardless of the group
+// candidates of the store, and checks it holds the given group.
+func (f *fileStore) checkGroupFile(g *Group) error {
+	loaded := new(Group)
+	err := f.load(f.groupFile, loaded)
+	if err == nil && f.distPublicReference && loaded.DistPublicHash() != nil {
+		err = f.resolveDistPublic(loaded)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: reading back the group: %v", ErrCorrupted, err)
+	}
+	if !loaded.Equal(g) {
+		return fmt.Errorf("%w: group read back differs from the saved one", ErrCorrupted)
+	}
+	return nil
+}
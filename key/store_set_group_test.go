@@ -0,0 +1,69 @@
+package key
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreSetGroup(t *testing.T) {
+	_, group := BatchIdentities(3)
+	var saved []string
+	store := NewFileStore(t.TempDir(), "", WithHooks(Hooks{OnGroupSaved: func(hash string) error {
+		saved = append(saved, hash)
+		return nil
+	}}))
+	f := store.(*fileStore)
+	corrupt := store.(CodecStore).WithCodec(rewriteCodec{old: "127.0.0.1", new: "127.0.0.2"}).(GroupSetter)
+
+	// a failed verification leaves no group behind
+	require.ErrorIs(t, corrupt.SetGroup(group), ErrCorrupted)
+	_, err := store.LoadGroup()
+	require.ErrorIs(t, err, ErrAbsent)
+
+	require.NoError(t, store.(GroupSetter).SetGroup(group))
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(group))
+	require.Len(t, saved, 1)
+	before, err := os.ReadFile(f.groupFile)
+	require.NoError(t, err)
+
+	// a failed verification restores the previous group
+	_, next := BatchIdentities(4)
+	require.ErrorIs(t, corrupt.SetGroup(next), ErrCorrupted)
+	after, err := os.ReadFile(f.groupFile)
+	require.NoError(t, err)
+	require.Equal(t, before, after)
+	require.NoFileExists(t, f.groupFile+backupExtension)
+	require.NoFileExists(t, f.groupFile+tmpExtension)
+
+	// invalid groups are refused before touching the file
+	invalid := next.Copy()
+	invalid.Threshold = 0
+	require.Error(t, store.(GroupSetter).SetGroup(invalid))
+	after, err = os.ReadFile(f.groupFile)
+	require.NoError(t, err)
+	require.Equal(t, before, after)
+	require.Len(t, saved, 1)
+
+	require.NoError(t, store.(GroupSetter).SetGroup(next))
+	loaded, err = store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(next))
+}
+
+func TestStoreSetGroupDistPublicReference(t *testing.T) {
+	_, group := BatchIdentities(3)
+	_, dist := dealShares(3, 2)
+	group.PublicKey = dist
+	store := NewFileStore(t.TempDir(), "", WithDistPublicReference())
+	require.NoError(t, store.(GroupSetter).SetGroup(group))
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(group))
+	loadedDist, err := store.LoadDistPublic()
+	require.NoError(t, err)
+	require.True(t, loadedDist.Equal(dist))
+}
@@ -0,0 +1,106 @@
+package key
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/drand/drand/fs"
+)
+
+// ShareHistoryStore is implemented by stores keeping the shares replaced by
+// the latest saves, see WithShareHistory.
+type ShareHistoryStore interface {
+	// LoadShareAt loads the share saved n saves ago: 0 is the active share,
+	// 1 the one it replaced, and so on. It returns an error wrapping
+	// ErrAbsent if the store does not keep that share.
+	LoadShareAt(n int) (*Share, error)
+}
+
+// shareHistoryFile returns the path of the share saved n saves ago.
+func (f *fileStore) shareHistoryFile(n int) string {
+	return strings.TrimSuffix(f.shareFile, privateExtension) + "." + strconv.Itoa(n) + privateExtension
+}
+
+func (f *fileStore) LoadShareAt(n int) (*Share, error) {
+	switch {
+	case n == 0:
+		return f.LoadShare()
+	case n < 0 || n > f.shareHistory:
+		return nil, fmt.Errorf("%w: the store keeps %d previous shares", ErrAbsent, f.shareHistory)
+	}
+	s := new(Share)
+	if err := f.load(f.shareHistoryFile(n), s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// rotateShares moves the active share into the history before it is replaced,
+// shifting the older shares and deleting the ones beyond the history size.
+func (f *fileStore) rotateShares() error {
+	if exists, _ := fs.Exists(f.shareFile); !exists || f.shareHistory <= 0 {
+		return nil
+	}
+	for n := f.shareHistory; ; n++ {
+		file := f.shareHistoryFile(n)
+		if exists, _ := fs.Exists(file); !exists {
+			break
+		}
+		if err := secureDelete(file); err != nil {
+			return wrapFileError(file, err)
+		}
+	}
+	for n := f.shareHistory - 1; n >= 1; n-- {
+		file := f.shareHistoryFile(n)
+		if exists, _ := fs.Exists(file); !exists {
+			continue
+		}
+		if err := os.Rename(file, f.shareHistoryFile(n+1)); err != nil {
+			return wrapFileError(file, err)
+		}
+	}
+	return wrapFileError(f.shareHistoryFile(1), fs.CopyFile(f.shareFile, f.shareHistoryFile(1)))
+}
+
+// deleteShareHistory securely deletes all the previous shares.
+func (f *fileStore) deleteShareHistory() error {
+	for n := 1; ; n++ {
+		file := f.shareHistoryFile(n)
+		exists, _ := fs.Exists(file)
+		if !exists && n > f.shareHistory {
+			return nil
+		}
+		if !exists {
+			continue
+		}
+		if err := secureDelete(file); err != nil {
+			return wrapFileError(file, err)
+		}
+	}
+}
+
+// secureDelete overwrites the file with zeros before removing it, so that the
+// private material it held does not linger on disk.
+func secureDelete(file string) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	fd, err := os.OpenFile(file, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	_, err = fd.Write(make([]byte, info.Size()))
+	if err == nil {
+		err = fd.Sync()
+	}
+	if cerr := fd.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(file)
+}
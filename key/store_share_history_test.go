@@ -0,0 +1,59 @@
+package key
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreShareHistory(t *testing.T) {
+	base := t.TempDir()
+	store := NewFileStore(base, "", WithShareHistory(2))
+	history := store.(ShareHistoryStore)
+	f := store.(*fileStore)
+
+	var saved []*Share
+	for i := 0; i < 4; i++ {
+		shares, dist := dealShares(3, 2)
+		saved = append(saved, shares[0])
+		if i%2 == 0 {
+			require.NoError(t, store.SaveShare(shares[0], WithOverwrite(true)))
+		} else {
+			require.NoError(t, store.SaveDKGResult(shares[0], dist, WithOverwrite(true)))
+		}
+	}
+	for n := 0; n <= 2; n++ {
+		s, err := history.LoadShareAt(n)
+		require.NoError(t, err)
+		require.True(t, s.Equal(saved[3-n]), "share %d", n)
+	}
+	_, err := history.LoadShareAt(3)
+	require.ErrorIs(t, err, ErrAbsent)
+	_, err = os.Stat(f.shareHistoryFile(3))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	info, err := os.Stat(f.shareHistoryFile(1))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// shrinking the history deletes the older shares at the next save
+	store = NewFileStore(base, "", WithShareHistory(1))
+	shares, _ := dealShares(3, 2)
+	require.NoError(t, store.SaveShare(shares[0], WithOverwrite(true)))
+	s, err := store.(ShareHistoryStore).LoadShareAt(1)
+	require.NoError(t, err)
+	require.True(t, s.Equal(saved[3]))
+	_, err = os.Stat(f.shareHistoryFile(2))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, store.Reset())
+	_, err = os.Stat(f.shareHistoryFile(1))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// no history by default
+	store = NewFileStore(t.TempDir(), "")
+	require.NoError(t, store.SaveShare(shares[0]))
+	require.NoError(t, store.SaveShare(saved[0], WithOverwrite(true)))
+	_, err = store.(ShareHistoryStore).LoadShareAt(1)
+	require.ErrorIs(t, err, ErrAbsent)
+}
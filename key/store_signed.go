@@ -0,0 +1,148 @@
+package key
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/drand/drand/common/scheme"
+	kyber "github.com/drand/kyber"
+)
+
+const groupSignatureExtension = ".sig"
+
+// Signer signs messages, such as the digest of a group, with a coordinator key.
+// Signatures are verified with AuthScheme.
+type Signer interface {
+	Sign(msg []byte) ([]byte, error)
+}
+
+// Sign signs the message with the private key of the pair, using AuthScheme.
+func (p *Pair) Sign(msg []byte) ([]byte, error) {
+	return AuthScheme.Sign(p.Key, msg)
+}
+
+// SignedGroupStore is implemented by stores able to keep a group along a
+// detached signature of a coordinator.
+type SignedGroupStore interface {
+	// SaveSignedGroup saves the group and the signature by the signer of a
+	// digest covering all the fields of the group.
+	SaveSignedGroup(g *Group, signer Signer) error
+	// LoadSignedGroup loads the group and verifies its signature with the
+	// given coordinator public key. It returns an error wrapping
+	// ErrBadSignature if the group is not signed by that key. Unsigned groups
+	// can still be loaded with LoadGroup.
+	LoadSignedGroup(verifier kyber.Point) (*Group, error)
+}
+
+// groupSignature is the detached signature of a group, kept next to it.
+type groupSignature struct {
+	// hash is the digest of the group, see signedDigest
+	hash      []byte
+	signature []byte
+}
+
+// GroupSignatureTOML is the TOML-able version of a group signature
+type GroupSignatureTOML struct {
+	Hash      string
+	Signature string
+}
+
+func (s *groupSignature) TOML() interface{} {
+	return &GroupSignatureTOML{
+		Hash:      hex.EncodeToString(s.hash),
+		Signature: hex.EncodeToString(s.signature),
+	}
+}
+
+func (s *groupSignature) FromTOML(i interface{}) error {
+	st, ok := i.(*GroupSignatureTOML)
+	if !ok {
+		return errors.New("group signature can't decode from non GroupSignatureTOML struct")
+	}
+	var err error
+	if s.hash, err = hex.DecodeString(st.Hash); err != nil {
+		return err
+	}
+	s.signature, err = hex.DecodeString(st.Signature)
+	return err
+}
+
+func (s *groupSignature) TOMLValue() interface{} {
+	return &GroupSignatureTOML{}
+}
+
+// signedDigest returns the digest of the group signed by SaveSignedGroup.
+// Unlike the hash of the group, it covers all of its fields: the wire encoding
+// of the group holds all of them but the metadata, hashed after it.
+func signedDigest(g *Group) ([]byte, error) {
+	stored := sortedByIndex(g.Copy())
+	stored.GetGenesisSeed()
+	if stored.Scheme.ID == "" {
+		// an unset scheme is written as the default one
+		var err error
+		if stored.Scheme, err = scheme.GetSchemeByIDWithDefault(""); err != nil {
+			return nil, err
+		}
+	}
+	wire, err := stored.MarshalWire()
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := json.Marshal(g.metadata)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	_, _ = h.Write(wire)
+	_, _ = h.Write(metadata)
+	return h.Sum(nil), nil
+}
+
+// SaveSignedGroup writes the group and its signature together.
+func (f *fileStore) SaveSignedGroup(g *Group, signer Signer) error {
+	digest, err := signedDigest(g)
+	if err != nil {
+		return err
+	}
+	signature, err := signer.Sign(digest)
+	if err != nil {
+		return fmt.Errorf("store: signing group: %w", err)
+	}
+
+	f.lock()
+	defer f.unlock()
+	return f.saveGroup(g, &groupSignature{hash: digest, signature: signature})
+}
+
+func (f *fileStore) LoadSignedGroup(verifier kyber.Point) (*Group, error) {
+	f.lock()
+	defer f.unlock()
+	g := new(Group)
+	if err := f.loadGroupFile(f.groupFile, g); err != nil {
+		return nil, err
+	}
+	if f.distPublicReference && g.DistPublicHash() != nil {
+		if err := f.resolveDistPublic(g); err != nil {
+			return nil, err
+		}
+	}
+	sig := new(groupSignature)
+	if err := f.load(f.groupSigFile, sig); err != nil {
+		return nil, fmt.Errorf("%w: loading group signature: %v", ErrBadSignature, err)
+	}
+	digest, err := signedDigest(g)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(digest, sig.hash) {
+		return nil, fmt.Errorf("%w: group changed since it was signed", ErrBadSignature)
+	}
+	if err := AuthScheme.Verify(verifier, digest, sig.signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadSignature, err)
+	}
+	return g, nil
+}
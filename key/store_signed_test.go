@@ -0,0 +1,63 @@
+package key
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drand/drand/common/scheme"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreSignedGroup(t *testing.T) {
+	_, group := BatchIdentities(3)
+	coordinator, other := NewKeyPair("127.0.0.1:9000"), NewKeyPair("127.0.0.1:9001")
+	store := NewFileStore(t.TempDir(), "").(*fileStore)
+
+	// nothing signed yet
+	require.NoError(t, store.SaveGroup(group))
+	_, err := store.LoadSignedGroup(coordinator.Public.Key)
+	require.ErrorIs(t, err, ErrBadSignature)
+
+	require.NoError(t, store.SaveSignedGroup(group, coordinator))
+	loaded, err := store.LoadSignedGroup(coordinator.Public.Key)
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(group))
+	_, err = store.LoadSignedGroup(other.Public.Key)
+	require.ErrorIs(t, err, ErrBadSignature)
+
+	// the group is still readable without verification
+	loaded, err = store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(group))
+
+	// a group modified after the signature is rejected, including the
+	// fields not covered by its hash
+	modified, err := group.WithThreshold(3)
+	require.NoError(t, err)
+	require.NoError(t, store.SaveGroup(modified))
+	_, err = store.LoadSignedGroup(coordinator.Public.Key)
+	require.ErrorIs(t, err, ErrBadSignature)
+	for name, tamper := range map[string]func(*Group){
+		"period":   func(g *Group) { g.Period += time.Second },
+		"catchup":  func(g *Group) { g.CatchupPeriod += time.Second },
+		"scheme":   func(g *Group) { g.Scheme, _ = scheme.GetSchemeByID(scheme.UnchainedSchemeID) },
+		"seed":     func(g *Group) { g.GenesisSeed = []byte("another seed") },
+		"metadata": func(g *Group) { g.metadata = map[string]string{"operator": "someone"} },
+	} {
+		require.NoError(t, store.SaveSignedGroup(group, coordinator))
+		tampered := group.Copy()
+		tamper(tampered)
+		require.Equal(t, group.Hash(), tampered.Hash(), name)
+		require.NoError(t, store.writeGroup(tampered, nil), name)
+		_, err = store.LoadSignedGroup(coordinator.Public.Key)
+		require.ErrorIs(t, err, ErrBadSignature, name)
+	}
+}
+
+func TestStoreSignedGroupObserver(t *testing.T) {
+	_, group := BatchIdentities(3)
+	obs := new(recordingObserver)
+	store := NewFileStore(t.TempDir(), "", WithObserver(obs)).(*fileStore)
+	require.NoError(t, store.SaveSignedGroup(group, NewKeyPair("127.0.0.1:9000")))
+	require.Contains(t, obs.events, "save group true")
+}
@@ -0,0 +1,98 @@
+package key
+
+import (
+	"fmt"
+	"io"
+)
+
+// stdinStore is a read-only Store serving the objects of a bundle read once
+// at its creation, so that a node can run without writing anything to disk.
+type stdinStore struct {
+	bundle Bundle
+	closed *closeState
+}
+
+// NewStdinStore reads a bundle written by ExportBundle from r, usually the
+// standard input of the process, and returns a read-only store holding its
+// objects in memory. Objects absent from the bundle fail to load with
+// ErrAbsent, and all save operations return ErrReadOnly. The objects returned
+// by the loads are shared, callers must not modify them.
+func NewStdinStore(r io.Reader) (Store, error) {
+	s := &stdinStore{closed: newCloseState()}
+	if err := Decode(r, &s.bundle); err != nil {
+		return nil, fmt.Errorf("stdin store: %w", err)
+	}
+	return s, nil
+}
+
+func (s *stdinStore) absent(object string) error {
+	return fmt.Errorf("%w: no %s in the bundle", ErrAbsent, object)
+}
+
+func (s *stdinStore) LoadKeyPair() (*Pair, error) {
+	if err := s.closed.check(); err != nil {
+		return nil, err
+	}
+	if s.bundle.Pair == nil {
+		return nil, s.absent("private key")
+	}
+	return s.bundle.Pair, nil
+}
+
+func (s *stdinStore) LoadShare() (*Share, error) {
+	if err := s.closed.check(); err != nil {
+		return nil, err
+	}
+	if s.bundle.Share == nil {
+		return nil, s.absent("share")
+	}
+	return s.bundle.Share, nil
+}
+
+func (s *stdinStore) LoadGroup() (*Group, error) {
+	if err := s.closed.check(); err != nil {
+		return nil, err
+	}
+	if s.bundle.Group == nil {
+		return nil, s.absent("group")
+	}
+	return s.bundle.Group, nil
+}
+
+func (s *stdinStore) LoadDistPublic() (*DistPublic, error) {
+	if err := s.closed.check(); err != nil {
+		return nil, err
+	}
+	if s.bundle.DistPublic == nil {
+		return nil, s.absent("distributed public key")
+	}
+	return s.bundle.DistPublic, nil
+}
+
+func (s *stdinStore) SaveDistPublic(*DistPublic, ...SaveOption) error {
+	return s.closed.readOnly()
+}
+
+func (s *stdinStore) SaveDKGResult(*Share, *DistPublic, ...SaveOption) error {
+	return s.closed.readOnly()
+}
+
+func (s *stdinStore) SaveKeyPair(*Pair, ...SaveOption) error {
+	return s.closed.readOnly()
+}
+
+func (s *stdinStore) SaveShare(*Share, ...SaveOption) error {
+	return s.closed.readOnly()
+}
+
+func (s *stdinStore) SaveGroup(*Group, ...SaveOption) error {
+	return s.closed.readOnly()
+}
+
+func (s *stdinStore) CompareAndSwapGroup(_, _ *Group) error {
+	return s.closed.readOnly()
+}
+
+func (s *stdinStore) Reset(...ResetOption) error {
+	return s.closed.readOnly()
+}
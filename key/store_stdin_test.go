@@ -0,0 +1,63 @@
+package key
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdinStore(t *testing.T) {
+	ps, group := BatchIdentities(3)
+	shares, dist := dealShares(3, 2)
+	group.PublicKey = dist
+	index, err := group.NodeIndex(ps[0].Public.Key)
+	require.NoError(t, err)
+	share := shares[index]
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, &Bundle{Identity: ps[0].Public, Group: group, DistPublic: dist, Pair: ps[0], Share: share}))
+	store, err := NewStdinStore(&buf)
+	require.NoError(t, err)
+
+	pair, err := store.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, pair.Equal(ps[0]))
+	loadedShare, err := store.LoadShare()
+	require.NoError(t, err)
+	require.True(t, loadedShare.Share.V.Equal(share.Share.V))
+	loadedGroup, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.Equal(t, group.Hash(), loadedGroup.Hash())
+	loadedDist, err := store.LoadDistPublic()
+	require.NoError(t, err)
+	require.True(t, loadedDist.Equal(dist))
+	require.NoError(t, CheckConsistency(store))
+
+	require.ErrorIs(t, store.SaveGroup(group), ErrReadOnly)
+	require.ErrorIs(t, store.SaveKeyPair(ps[1]), ErrReadOnly)
+	require.ErrorIs(t, store.SaveDKGResult(share, dist), ErrReadOnly)
+	require.ErrorIs(t, store.CompareAndSwapGroup(group, group), ErrReadOnly)
+	require.ErrorIs(t, store.Reset(), ErrReadOnly)
+}
+
+func TestStdinStorePublicOnly(t *testing.T) {
+	ps, group := BatchIdentities(3)
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, &Bundle{Identity: ps[0].Public, Group: group}))
+	store, err := NewStdinStore(&buf)
+	require.NoError(t, err)
+
+	_, err = store.LoadKeyPair()
+	require.ErrorIs(t, err, ErrAbsent)
+	_, err = store.LoadShare()
+	require.ErrorIs(t, err, ErrAbsent)
+	_, err = store.LoadDistPublic()
+	require.ErrorIs(t, err, ErrAbsent)
+	_, err = store.LoadGroup()
+	require.NoError(t, err)
+
+	_, err = NewStdinStore(strings.NewReader("not a bundle"))
+	require.Error(t, err)
+}
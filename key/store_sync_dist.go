@@ -0,0 +1,152 @@
+package key
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// DistPublicSyncer is implemented by stores able to repair a distributed
+// public key lagging behind in one of the places they store it.
+type DistPublicSyncer interface {
+	// SyncDistPublic loads the distributed public key embedded in the group
+	// and the standalone one and, if they differ, replaces the stale one with
+	// the authoritative one atomically, logging what it changed. It does
+	// nothing if they agree. It is the repair counterpart of the check made by
+	// CheckConsistency, typically needed after an out-of-band resharing.
+	SyncDistPublic() error
+}
+
+// SyncDistPublic takes the key matching the commitments of the stored share as
+// authoritative, since the share always comes from the latest DKG. Without a
+// share matching either key, the most recently modified file wins, as for the
+// group candidates of the same epoch.
+func (f *fileStore) SyncDistPublic() error {
+	f.lock()
+	defer f.unlock()
+
+	group := new(Group)
+	if err := f.load(f.groupFile, group); err != nil {
+		return err
+	}
+	dist := new(DistPublic)
+	if err := f.load(f.distKeyFile, dist); errors.Is(err, ErrAbsent) {
+		dist = nil
+	} else if err != nil {
+		return err
+	}
+	groupHash := group.DistPublicHash()
+	if group.PublicKey != nil {
+		groupHash = group.PublicKey.Hash()
+	}
+	switch {
+	case groupHash == nil && dist == nil:
+		return nil
+	case groupHash == nil:
+		return f.syncGroupDistPublic(group, dist, nil)
+	case dist == nil:
+		return f.syncStandaloneDistPublic(group, nil)
+	case bytes.Equal(groupHash, dist.Hash()):
+		return nil
+	}
+
+	groupIsNewer, err := f.groupDistPublicIsNewer(group, dist)
+	if err != nil {
+		return err
+	}
+	if groupIsNewer {
+		return f.syncStandaloneDistPublic(group, dist)
+	}
+	return f.syncGroupDistPublic(group, dist, groupHash)
+}
+
+// groupDistPublicIsNewer returns true if the key embedded in the group is the
+// authoritative one.
+func (f *fileStore) groupDistPublicIsNewer(group *Group, dist *DistPublic) (bool, error) {
+	s := new(Share)
+	if err := f.load(f.shareFile, s); err == nil {
+		shareKey := &DistPublic{Coefficients: s.Commits}
+		switch {
+		case shareKey.Equal(dist):
+			return false, nil
+		case bytes.Equal(shareKey.Hash(), group.DistPublicHash()) || group.PublicKey != nil && shareKey.Equal(group.PublicKey):
+			return true, nil
+		}
+	} else if !errors.Is(err, ErrAbsent) {
+		return false, err
+	}
+	groupInfo, err := os.Stat(f.groupFile)
+	if err != nil {
+		return false, wrapFileError(f.groupFile, err)
+	}
+	distInfo, err := os.Stat(f.distKeyFile)
+	if err != nil {
+		return false, wrapFileError(f.distKeyFile, err)
+	}
+	return groupInfo.ModTime().After(distInfo.ModTime()), nil
+}
+
+// syncGroupDistPublic replaces the key embedded in the group, whose hash is
+// given, with the standalone one.
+func (f *fileStore) syncGroupDistPublic(group *Group, dist *DistPublic, stale []byte) error {
+	group.setDistPublic(dist)
+	if err := f.beforeSave(GroupKind, hex.EncodeToString(group.Hash())); err != nil {
+		return err
+	}
+	w := &atomicWrite{closed: f.closed, wal: f.wal, codecs: f.codecs}
+	if err := w.add(f.groupFile, f.groupFileObject(group), false); err != nil {
+		return err
+	}
+	if err := w.commitVerified(func() error { return f.checkGroupFile(group) }); err != nil {
+		return err
+	}
+	if err := f.archiveGroup(); err != nil {
+		return err
+	}
+	f.log.Infow("", "store", "synced the distributed public key of the group", "file", f.groupFile,
+		"old", hex.EncodeToString(stale), "new", hex.EncodeToString(dist.Hash()))
+	f.afterSave(GroupKind, f.hooks.OnGroupSaved, hex.EncodeToString(group.Hash()))
+	return nil
+}
+
+// syncStandaloneDistPublic replaces the standalone key, if any, with the one
+// embedded in the group.
+func (f *fileStore) syncStandaloneDistPublic(group *Group, stale *DistPublic) error {
+	if group.PublicKey == nil {
+		return fmt.Errorf("store: the group only references the distributed public key %x, which can't be restored",
+			group.DistPublicHash())
+	}
+	d := group.PublicKey
+	if err := f.beforeSave(DistPublicKind, hex.EncodeToString(d.Hash())); err != nil {
+		return err
+	}
+	w := &atomicWrite{closed: f.closed, wal: f.wal, codecs: f.codecs}
+	if err := w.add(f.distKeyFile, d, false); err != nil {
+		return err
+	}
+	if err := w.commitVerified(func() error { return f.checkDistPublicFile(d) }); err != nil {
+		return err
+	}
+	var old string
+	if stale != nil {
+		old = hex.EncodeToString(stale.Hash())
+	}
+	f.log.Infow("", "store", "synced the standalone distributed public key", "file", f.distKeyFile,
+		"old", old, "new", hex.EncodeToString(d.Hash()))
+	return nil
+}
+
+// checkDistPublicFile reads the distributed public key file back and checks
+// it holds the given key.
+func (f *fileStore) checkDistPublicFile(d *DistPublic) error {
+	loaded := new(DistPublic)
+	if err := f.load(f.distKeyFile, loaded); err != nil {
+		return fmt.Errorf("%w: reading back the distributed public key: %v", ErrCorrupted, err)
+	}
+	if !loaded.Equal(d) {
+		return fmt.Errorf("%w: distributed public key read back differs from the saved one", ErrCorrupted)
+	}
+	return nil
+}
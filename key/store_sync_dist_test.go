@@ -0,0 +1,69 @@
+package key
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreSyncDistPublic(t *testing.T) {
+	pairs, group := BatchIdentities(3)
+	shares, dist := dealShares(3, group.Threshold)
+	group.PublicKey = dist
+	store := NewFileStore(t.TempDir(), "")
+	f := store.(*fileStore)
+	syncer := store.(DistPublicSyncer)
+	require.NoError(t, store.SaveKeyPair(pairs[0]))
+	require.NoError(t, store.SaveGroup(group))
+	require.NoError(t, store.SaveDistPublic(dist))
+	require.NoError(t, store.SaveShare(shares[0]))
+
+	// nothing to do
+	before, err := os.ReadFile(f.groupFile)
+	require.NoError(t, err)
+	require.NoError(t, syncer.SyncDistPublic())
+	after, err := os.ReadFile(f.groupFile)
+	require.NoError(t, err)
+	require.Equal(t, before, after)
+
+	// an out-of-band resharing updated the share and the standalone key only
+	reshared, newDist := dealShares(3, group.Threshold)
+	require.NoError(t, f.save(f.distKeyFile, newDist, false))
+	require.NoError(t, f.save(f.shareFile, reshared[0], true))
+	require.ErrorIs(t, CheckConsistency(store), ErrInconsistent)
+	require.NoError(t, syncer.SyncDistPublic())
+	require.NoError(t, CheckConsistency(store))
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.PublicKey.Equal(newDist))
+	require.Equal(t, group.GenesisSeed, loaded.GenesisSeed)
+
+	// the standalone key lags behind the group
+	require.NoError(t, f.save(f.distKeyFile, dist, false))
+	require.NoError(t, syncer.SyncDistPublic())
+	require.NoError(t, CheckConsistency(store))
+	loadedDist, err := store.LoadDistPublic()
+	require.NoError(t, err)
+	require.True(t, loadedDist.Equal(newDist))
+
+	// without a share, the most recent file wins
+	require.NoError(t, os.Remove(f.shareFile))
+	require.NoError(t, f.save(f.distKeyFile, dist, false))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(f.groupFile, old, old))
+	require.NoError(t, syncer.SyncDistPublic())
+	loaded, err = store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.PublicKey.Equal(dist))
+
+	// a missing standalone key is restored from the group
+	require.NoError(t, os.Remove(f.distKeyFile))
+	require.NoError(t, syncer.SyncDistPublic())
+	loadedDist, err = store.LoadDistPublic()
+	require.NoError(t, err)
+	require.True(t, loadedDist.Equal(dist))
+
+	require.ErrorIs(t, NewFileStore(t.TempDir(), "").(DistPublicSyncer).SyncDistPublic(), ErrAbsent)
+}
@@ -1,15 +1,24 @@
 package key
 
 import (
+	"bytes"
+	"encoding/hex"
+	"errors"
 	"os"
+	"os/exec"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/drand/drand/common"
+	"github.com/drand/drand/log"
 
 	kyber "github.com/drand/kyber"
 	"github.com/drand/kyber/share"
+	"github.com/drand/kyber/util/random"
+	clock "github.com/jonboulle/clockwork"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
 )
 
 func TestKeysSaveLoad(t *testing.T) {
@@ -74,3 +83,296 @@ func TestKeysSaveLoad(t *testing.T) {
 	require.Equal(t, testShare.Share.V, loadedShare.Share.V)
 	require.Equal(t, testShare.Share.I, loadedShare.Share.I)
 }
+
+func TestStoreSplitBases(t *testing.T) {
+	pairs, group := BatchIdentities(2)
+	shares, dist := dealShares(2, 2)
+	base, private, public := t.TempDir(), t.TempDir(), t.TempDir()
+	store := NewFileStore(base, "", WithPrivateBase(private), WithPublicBase(public))
+
+	require.NoError(t, store.SaveKeyPair(pairs[0]))
+	require.NoError(t, store.SaveGroup(group))
+	require.NoError(t, store.SaveDKGResult(shares[0], dist))
+
+	id := common.DefaultBeaconID
+	for _, file := range []string{
+		path.Join(private, id, KeyFolderName, keyFileName+privateExtension),
+		path.Join(private, id, GroupFolderName, shareFileName),
+		path.Join(public, id, KeyFolderName, keyFileName+publicExtension),
+		path.Join(public, id, GroupFolderName, groupFileName),
+		path.Join(public, id, GroupFolderName, distKeyFileName),
+	} {
+		_, err := os.Stat(file)
+		require.NoError(t, err, file)
+	}
+	entries, err := os.ReadDir(base)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	loaded, err := store.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, loaded.Public.Equal(pairs[0].Public))
+	_, err = store.LoadShare()
+	require.NoError(t, err)
+	_, err = store.LoadGroup()
+	require.NoError(t, err)
+}
+
+func TestStoreCompareAndSwapGroup(t *testing.T) {
+	_, group := BatchIdentities(4)
+	_, other := BatchIdentities(4)
+	store := NewFileStore(t.TempDir(), "")
+
+	require.ErrorIs(t, store.CompareAndSwapGroup(group, group), ErrConflict)
+	require.NoError(t, store.CompareAndSwapGroup(nil, group))
+	require.ErrorIs(t, store.CompareAndSwapGroup(nil, other), ErrConflict)
+	require.ErrorIs(t, store.CompareAndSwapGroup(other, other), ErrConflict)
+
+	require.NoError(t, store.CompareAndSwapGroup(group, other))
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.Equal(t, other.Hash(), loaded.Hash())
+}
+
+func TestStoreHooks(t *testing.T) {
+	ps, group := BatchIdentities(4)
+	var savedAddr, savedGroup, savedShare string
+	hooks := Hooks{
+		OnKeyPairSaved: func(addr string) error { savedAddr = addr; return nil },
+		OnGroupSaved:   func(hash string) error { savedGroup = hash; return nil },
+		OnShareSaved: func(hash string) error {
+			savedShare = hash
+			return errors.New("hooks errors are not fatal")
+		},
+	}
+	store := NewFileStore(t.TempDir(), "", WithHooks(hooks))
+
+	require.NoError(t, store.SaveKeyPair(ps[0]))
+	require.Equal(t, ps[0].Public.Addr, savedAddr)
+	require.NoError(t, store.SaveGroup(group))
+	require.Equal(t, hex.EncodeToString(group.Hash()), savedGroup)
+	testShare := &Share{
+		Commits: []kyber.Point{ps[0].Public.Key},
+		Share:   &share.PriShare{V: ps[0].Key, I: 0},
+	}
+	require.NoError(t, store.SaveShare(testShare))
+	require.Equal(t, savedGroup, savedShare)
+
+	veto := errors.New("vetoed")
+	hooks.BeforeSave = func(kind StoreKind, _ string) error {
+		if kind == ShareKind {
+			return veto
+		}
+		return nil
+	}
+	tmp := t.TempDir()
+	store = NewFileStore(tmp, "", WithHooks(hooks))
+	require.ErrorIs(t, store.SaveShare(testShare), veto)
+	_, err := store.LoadShare()
+	require.Error(t, err)
+}
+
+func TestStoreDistPublicSync(t *testing.T) {
+	_, group := BatchIdentities(4)
+	var logs bytes.Buffer
+	logger := log.NewLogger(zapcore.AddSync(&logs), log.LogWarn)
+	store := NewFileStore(t.TempDir(), "", WithLogger(logger)).(*fileStore)
+	require.NoError(t, store.SaveGroup(group))
+	seed := group.GetGenesisSeed()
+
+	dist := &DistPublic{[]kyber.Point{KeyGroup.Point().Pick(random.New())}}
+	require.NoError(t, store.SaveDistPublic(dist))
+	loadedGroup, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loadedGroup.PublicKey.Equal(dist))
+	require.Equal(t, seed, loadedGroup.GenesisSeed)
+	loadedDist, err := store.LoadDistPublic()
+	require.NoError(t, err)
+	require.True(t, loadedDist.Equal(dist))
+	require.Empty(t, logs.String())
+
+	// a group file without a seed keeps the one of the previous key
+	raw := loadedGroup.TOML().(*GroupTOML)
+	raw.GenesisSeed = ""
+	writeTOML(t, store.groupFile, raw)
+	seed = loadedGroup.Hash()
+	require.NoError(t, store.SaveDistPublic(&DistPublic{[]kyber.Point{KeyGroup.Point().Pick(random.New())}}))
+	loadedGroup, err = store.LoadGroup()
+	require.NoError(t, err)
+	require.Equal(t, seed, loadedGroup.GenesisSeed)
+
+	// desync the two copies behind the store's back
+	require.NoError(t, Save(store.distKeyFile, group.PublicKey, false))
+	_, err = store.LoadDistPublic()
+	require.NoError(t, err)
+	require.Contains(t, logs.String(), "distributed public key differs")
+}
+
+func TestStoreSaveDKGResult(t *testing.T) {
+	ps, _ := BatchIdentities(2)
+	store := NewFileStore(t.TempDir(), "").(*fileStore)
+	newResult := func(i int) (*Share, *DistPublic) {
+		s := &Share{
+			Commits: []kyber.Point{ps[i].Public.Key},
+			Share:   &share.PriShare{V: ps[i].Key, I: i},
+		}
+		return s, s.Public()
+	}
+
+	share0, dist0 := newResult(0)
+	require.NoError(t, store.SaveDKGResult(share0, dist0))
+
+	// a failure while writing keeps the previous state
+	tmpDist := store.distKeyFile + tmpExtension
+	require.NoError(t, os.MkdirAll(path.Join(tmpDist, "busy"), 0740))
+	share1, dist1 := newResult(1)
+	require.Error(t, store.SaveDKGResult(share1, dist1, WithOverwrite(true)))
+	loadedShare, err := store.LoadShare()
+	require.NoError(t, err)
+	require.Equal(t, share0.Share.I, loadedShare.Share.I)
+	loadedDist, err := store.LoadDistPublic()
+	require.NoError(t, err)
+	require.True(t, loadedDist.Equal(dist0))
+
+	require.NoError(t, os.RemoveAll(tmpDist))
+	require.NoError(t, store.SaveDKGResult(share1, dist1, WithOverwrite(true)))
+	loadedShare, err = store.LoadShare()
+	require.NoError(t, err)
+	require.Equal(t, share1.Share.I, loadedShare.Share.I)
+	loadedDist, err = store.LoadDistPublic()
+	require.NoError(t, err)
+	require.True(t, loadedDist.Equal(dist1))
+	_, err = os.Stat(store.shareFile + backupExtension)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestStoreLazyGenesisSeed(t *testing.T) {
+	_, group := BatchIdentities(4)
+	store := NewFileStore(t.TempDir(), "").(*fileStore)
+	// the seed is computed lazily from the group and its embedded key
+	require.Nil(t, group.GenesisSeed)
+	require.NoError(t, store.SaveGroup(group))
+	saved, err := store.LoadGroup()
+	require.NoError(t, err)
+	seed := saved.GetGenesisSeed()
+	require.NotEqual(t, group.ComputeGenesisSeed(), seed)
+
+	_, dist := dealShares(4, group.Threshold)
+	require.NoError(t, store.SaveDistPublic(dist))
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.PublicKey.Equal(dist))
+	require.Equal(t, seed, loaded.GenesisSeed)
+
+	_, next := dealShares(4, group.Threshold)
+	require.NoError(t, store.save(store.distKeyFile, next, false))
+	require.NoError(t, store.SyncDistPublic())
+	loaded, err = store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.PublicKey.Equal(next))
+	require.Equal(t, seed, loaded.GenesisSeed)
+}
+
+func TestStoreSaveOverwrite(t *testing.T) {
+	pairs, group := BatchIdentities(2)
+	shares, dist := dealShares(2, 2)
+	store := NewFileStore(t.TempDir(), "")
+
+	require.NoError(t, store.SaveKeyPair(pairs[0]))
+	require.NoError(t, store.SaveShare(shares[0]))
+	require.NoError(t, store.SaveGroup(group))
+	require.NoError(t, store.SaveDistPublic(dist))
+
+	// private objects are kept unless explicitly replaced
+	require.ErrorIs(t, store.SaveKeyPair(pairs[1]), ErrExists)
+	require.ErrorIs(t, store.SaveShare(shares[1]), ErrExists)
+	require.ErrorIs(t, store.SaveDKGResult(shares[1], dist), ErrExists)
+	loaded, err := store.LoadShare()
+	require.NoError(t, err)
+	require.Equal(t, shares[0].Share.I, loaded.Share.I)
+
+	require.NoError(t, store.SaveShare(shares[1], WithOverwrite(true)))
+	loaded, err = store.LoadShare()
+	require.NoError(t, err)
+	require.Equal(t, shares[1].Share.I, loaded.Share.I)
+	require.NoError(t, store.SaveKeyPair(pairs[1], WithOverwrite(true)))
+
+	// public objects are replaced unless explicitly kept
+	require.NoError(t, store.SaveGroup(group))
+	require.NoError(t, store.SaveDistPublic(dist))
+	require.ErrorIs(t, store.SaveGroup(group, WithOverwrite(false)), ErrExists)
+	require.ErrorIs(t, store.SaveDistPublic(dist, WithOverwrite(false)), ErrExists)
+}
+
+func TestStoreCheckClockSkew(t *testing.T) {
+	_, group := BatchIdentities(3)
+	clk := clock.NewFakeClock()
+	group.GenesisTime = clk.Now().Unix()
+	group.Period = 3 * time.Second
+
+	var logs bytes.Buffer
+	logger := log.NewLogger(zapcore.AddSync(&logs), log.LogWarn)
+	store := NewFileStore(t.TempDir(), "", WithClock(clk), WithLogger(logger), WithMaxClockSkew(time.Second))
+	require.NoError(t, store.SaveGroup(group))
+	checker := store.(ClockSkewChecker)
+
+	clk.Advance(4 * time.Second)
+	skew, err := checker.CheckClockSkew(2)
+	require.NoError(t, err)
+	require.Zero(t, skew)
+	require.Empty(t, logs.String())
+
+	// the network is at round 5 while the local clock says round 2
+	skew, err = checker.CheckClockSkew(5)
+	require.ErrorIs(t, err, ErrClockSkew)
+	require.Equal(t, -8*time.Second, skew)
+	require.Contains(t, logs.String(), "clock skew detected")
+}
+
+func TestStoreWithoutSeparatePublicFile(t *testing.T) {
+	base := t.TempDir()
+	pairs, _ := BatchIdentities(2)
+	pair := NewTLSKeyPair("127.0.0.1:8080")
+	store := NewFileStore(base, "", WithoutSeparatePublicFile()).(*fileStore)
+	require.NoError(t, store.SaveKeyPair(pair))
+	_, err := os.Stat(store.publicKeyFile)
+	require.True(t, os.IsNotExist(err))
+
+	loaded, err := store.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(pair))
+	require.Equal(t, pair.Public.Signature, loaded.Public.Signature)
+	require.NoError(t, loaded.Public.ValidSignature())
+
+	// key pairs saved with a separate public file are still loaded
+	other := NewFileStore(t.TempDir(), "")
+	require.NoError(t, other.SaveKeyPair(pairs[0]))
+	store = NewFileStore(other.(*fileStore).baseFolder, "", WithoutSeparatePublicFile()).(*fileStore)
+	loaded, err = store.LoadKeyPair()
+	require.NoError(t, err)
+	require.True(t, loaded.Public.Equal(pairs[0].Public))
+}
+
+func TestStoreReadTimeout(t *testing.T) {
+	_, group := BatchIdentities(3)
+	store := NewFileStore(t.TempDir(), "", WithReadTimeout(time.Second))
+	require.NoError(t, store.SaveGroup(group))
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(group))
+
+	// opening a FIFO without writer blocks, as a read on a stalled mount
+	f := store.(*fileStore)
+	if err := exec.Command("mkfifo", f.distKeyFile).Run(); err != nil {
+		t.Skipf("can't create a FIFO: %v", err)
+	}
+	f.readTimeout = 50 * time.Millisecond
+	_, err = store.LoadDistPublic()
+	require.ErrorIs(t, err, ErrTimeout)
+	require.True(t, IsRetryable(err))
+
+	// unblock the abandoned read
+	w, err := os.OpenFile(f.distKeyFile, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+}
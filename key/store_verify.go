@@ -0,0 +1,50 @@
+package key
+
+import (
+	"fmt"
+)
+
+// verifySaved reads back the object of the given kind through load, if the
+// store verifies its writes, and checks it is equal to the saved one with
+// equal.
+func (f *fileStore) verifySaved(kind StoreKind, load func() (bool, error)) error {
+	if !f.verifyAfterWrite {
+		return nil
+	}
+	equal, err := load()
+	if err != nil {
+		return fmt.Errorf("%w: reading back the %s: %v", ErrCorrupted, kind, err)
+	}
+	if !equal {
+		return fmt.Errorf("%w: %s", ErrCorrupted, kind)
+	}
+	return nil
+}
+
+func (f *fileStore) verifyKeyPair(p *Pair) error {
+	return f.verifySaved(KeyPairKind, func() (bool, error) {
+		loaded, err := f.LoadKeyPair()
+		return err == nil && loaded.Equal(p), err
+	})
+}
+
+func (f *fileStore) verifyShare(s *Share) error {
+	return f.verifySaved(ShareKind, func() (bool, error) {
+		loaded, err := f.LoadShare()
+		return err == nil && loaded.Equal(s), err
+	})
+}
+
+func (f *fileStore) verifyGroup(g *Group) error {
+	return f.verifySaved(GroupKind, func() (bool, error) {
+		loaded, err := f.LoadGroup()
+		return err == nil && loaded.Equal(g), err
+	})
+}
+
+func (f *fileStore) verifyDistPublic(d *DistPublic) error {
+	return f.verifySaved(DistPublicKind, func() (bool, error) {
+		loaded, err := f.LoadDistPublic()
+		return err == nil && loaded.Equal(d), err
+	})
+}
@@ -0,0 +1,56 @@
+package key
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rewriteCodec stores the data as is but replaces old by new when reading it,
+// as a corrupted storage would.
+type rewriteCodec struct {
+	old, new string
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func (c rewriteCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+func (c rewriteCodec) NewReader(r io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader([]byte(strings.ReplaceAll(string(data), c.old, c.new))), nil
+}
+
+func TestStoreVerifyAfterWrite(t *testing.T) {
+	pairs, group := BatchIdentities(3)
+	shares, dist := dealShares(3, 2)
+	corrupt := rewriteCodec{old: "127.0.0.1", new: "127.0.0.2"}
+
+	// without verification, the corruption goes unnoticed
+	store := NewFileStore(t.TempDir(), "").(CodecStore).WithCodec(corrupt)
+	require.NoError(t, store.SaveKeyPair(pairs[0]))
+	require.NoError(t, store.SaveGroup(group))
+
+	store = NewFileStore(t.TempDir(), "", WithVerifyAfterWrite(true)).(CodecStore).WithCodec(corrupt)
+	require.ErrorIs(t, store.SaveKeyPair(pairs[0]), ErrCorrupted)
+	require.ErrorIs(t, store.SaveGroup(group), ErrCorrupted)
+	require.NoError(t, store.SaveDKGResult(shares[0], dist))
+
+	store = NewFileStore(t.TempDir(), "", WithVerifyAfterWrite(true))
+	require.NoError(t, store.SaveKeyPair(pairs[0]))
+	require.NoError(t, store.SaveGroup(group))
+	require.NoError(t, store.SaveDKGResult(shares[0], dist))
+	require.NoError(t, store.SaveDistPublic(dist))
+}
@@ -0,0 +1,307 @@
+package key
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	clock "github.com/jonboulle/clockwork"
+
+	"github.com/drand/drand/fs"
+)
+
+// walFileName is the name of the write-ahead log, kept in the beacon folder of
+// the private base.
+const walFileName = "store.wal"
+
+// walMaxSize is the size in bytes past which the write-ahead log is compacted.
+const walMaxSize = 256 << 10
+
+const (
+	walIntent = "intent"
+	walCommit = "commit"
+	walAbort  = "abort"
+)
+
+// walRecord is a line of the write-ahead log. An atomic write appends an
+// intent record for each file it replaces, before renaming any of them, then a
+// commit record once all of them are in place, or an abort record if they were
+// restored. The new contents are only referenced by the hash of the file, so
+// that the log never holds a secret.
+type walRecord struct {
+	Op string `json:"op"`
+	// Txn identifies the atomic write the record belongs to
+	Txn string `json:"txn"`
+	// Kind is the kind of the object held by the file, empty for the files
+	// not holding one of the objects of the store on their own, such as the
+	// group signature
+	Kind string `json:"kind,omitempty"`
+	File string `json:"file,omitempty"`
+	// Hash is the SHA-256 of the new content of the file, as written to disk
+	Hash string    `json:"hash,omitempty"`
+	Time time.Time `json:"time"`
+}
+
+// writeAheadLog records the atomic writes of a file store, so that a write
+// interrupted between the renames of its files, e.g. by a crash, is either
+// completed or rolled back when the store is opened again, see WithWriteAheadLog.
+type writeAheadLog struct {
+	mu    sync.Mutex
+	path  string
+	clock clock.Clock
+	// maxSize is the size past which the log is compacted, see walMaxSize
+	maxSize int64
+	// kinds maps the files of the store to the kind of object they hold
+	kinds map[string]StoreKind
+}
+
+// setup places the log of the store once its files are known.
+func (w *writeAheadLog) setup(f *fileStore) {
+	w.path = path.Join(f.privateBase, f.beaconID, walFileName)
+	w.clock = f.clock
+	w.maxSize = walMaxSize
+	w.kinds = map[string]StoreKind{
+		f.privateKeyFile: KeyPairKind,
+		f.publicKeyFile:  KeyPairKind,
+		f.shareFile:      ShareKind,
+		f.groupFile:      GroupKind,
+		f.distKeyFile:    DistPublicKind,
+	}
+}
+
+// newTxn returns the identifier of a new atomic write.
+func newTxn() (string, error) {
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id[:]), nil
+}
+
+// append writes the records to the log and syncs it to disk.
+func (w *writeAheadLog) append(records ...walRecord) error {
+	var buf bytes.Buffer
+	now := w.clock.Now().UTC()
+	for _, r := range records {
+		r.Time = now
+		if kind, ok := w.kinds[r.File]; ok {
+			r.Kind = kind.String()
+		}
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fd, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return wrapFileError(w.path, err)
+	}
+	defer fd.Close()
+	if _, err := fd.Write(buf.Bytes()); err != nil {
+		return wrapFileError(w.path, err)
+	}
+	return wrapFileError(w.path, fd.Sync())
+}
+
+// close appends the commit or abort record of the atomic write, then compacts
+// the log if it grew too large.
+func (w *writeAheadLog) close(txn, op string) error {
+	if err := w.append(walRecord{Op: op, Txn: txn}); err != nil {
+		return err
+	}
+	return w.compact()
+}
+
+// compact rewrites the log past its maximum size with only the records of the
+// atomic writes still in progress.
+func (w *writeAheadLog) compact() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	info, err := os.Stat(w.path)
+	if err != nil || info.Size() <= w.maxSize {
+		return nil
+	}
+	records, err := w.records()
+	if err != nil {
+		return err
+	}
+	closed := make(map[string]bool)
+	for _, r := range records {
+		if r.Op == walCommit || r.Op == walAbort {
+			closed[r.Txn] = true
+		}
+	}
+	var buf bytes.Buffer
+	for _, r := range records {
+		if closed[r.Txn] {
+			continue
+		}
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+	tmp := w.path + tmpExtension
+	fd, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return wrapFileError(w.path, err)
+	}
+	if _, err = fd.Write(buf.Bytes()); err == nil {
+		err = fd.Sync()
+	}
+	fd.Close()
+	if err != nil {
+		os.Remove(tmp)
+		return wrapFileError(w.path, err)
+	}
+	if err := os.Rename(tmp, w.path); err != nil {
+		os.Remove(tmp)
+		return wrapFileError(w.path, err)
+	}
+	syncDir(filepath.Dir(w.path))
+	return nil
+}
+
+// records reads the log. A truncated last line, left by a crash while it was
+// appended, is ignored.
+func (w *writeAheadLog) records() ([]walRecord, error) {
+	fd, err := os.Open(w.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, wrapFileError(w.path, err)
+	}
+	defer fd.Close()
+	var records []walRecord
+	r := bufio.NewReader(fd)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, wrapFileError(w.path, err)
+		}
+		var record walRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrStoreFile, w.path, err)
+		}
+		records = append(records, record)
+	}
+}
+
+// recover completes or rolls back the atomic writes of the log lacking a
+// commit or abort record. A write is completed if every file either is
+// already replaced or has its new content intact in its temporary file, as the
+// hash of the file tells; otherwise all its files are restored.
+func (w *writeAheadLog) recover() error {
+	records, err := w.records()
+	if err != nil {
+		return err
+	}
+	var order []string
+	intents := make(map[string][]walRecord)
+	for _, r := range records {
+		switch r.Op {
+		case walIntent:
+			if _, ok := intents[r.Txn]; !ok {
+				order = append(order, r.Txn)
+			}
+			intents[r.Txn] = append(intents[r.Txn], r)
+		case walCommit, walAbort:
+			delete(intents, r.Txn)
+		}
+	}
+	for _, txn := range order {
+		files, ok := intents[txn]
+		if !ok {
+			continue
+		}
+		complete := true
+		for _, r := range files {
+			if !hasHash(r.File+tmpExtension, r.Hash) && !hasHash(r.File, r.Hash) {
+				complete = false
+			}
+		}
+		op := walAbort
+		if complete {
+			op = walCommit
+			err = completeFiles(files)
+		} else {
+			err = rollbackFiles(files)
+		}
+		if err != nil {
+			return err
+		}
+		if err := w.close(txn, op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// completeFiles moves the intact new contents in place and drops the backups.
+func completeFiles(files []walRecord) error {
+	for _, r := range files {
+		if hasHash(r.File+tmpExtension, r.Hash) {
+			if err := os.Rename(r.File+tmpExtension, r.File); err != nil {
+				return wrapFileError(r.File, err)
+			}
+		}
+		os.Remove(r.File + backupExtension)
+		syncDir(filepath.Dir(r.File))
+	}
+	return nil
+}
+
+// rollbackFiles discards the new contents and restores the previous files.
+func rollbackFiles(files []walRecord) error {
+	for _, r := range files {
+		os.Remove(r.File + tmpExtension)
+		if exists, _ := fs.Exists(r.File + backupExtension); exists {
+			if err := os.Rename(r.File+backupExtension, r.File); err != nil {
+				return wrapFileError(r.File, err)
+			}
+		} else if hasHash(r.File, r.Hash) {
+			// the file did not exist before the write
+			os.Remove(r.File)
+		}
+		syncDir(filepath.Dir(r.File))
+	}
+	return nil
+}
+
+// fileHash returns the hex encoded SHA-256 of the content of the file.
+func fileHash(filePath string) (string, error) {
+	fd, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer fd.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, fd); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hasHash(filePath, hash string) bool {
+	h, err := fileHash(filePath)
+	return err == nil && h == hash
+}
@@ -0,0 +1,173 @@
+package key
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/drand/drand/fs"
+	clock "github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreWriteAheadLog(t *testing.T) {
+	clk := clock.NewFakeClockAt(time.Unix(1600000000, 0))
+	store := NewFileStore(t.TempDir(), "", WithWriteAheadLog(), WithClock(clk))
+	f := store.(*fileStore)
+	pair, err := Init(store, "127.0.0.1:8080")
+	require.NoError(t, err)
+	shares, dist := dealShares(3, 2)
+	require.NoError(t, store.SaveDKGResult(shares[0], dist))
+
+	records, err := f.wal.records()
+	require.NoError(t, err)
+	committed := make(map[string]bool)
+	kinds := make(map[string]bool)
+	for _, r := range records {
+		switch r.Op {
+		case walIntent:
+			kinds[r.Kind] = true
+			require.True(t, clk.Now().Equal(r.Time))
+		case walCommit:
+			committed[r.Txn] = true
+		}
+	}
+	for _, r := range records {
+		require.True(t, committed[r.Txn], "write %s not committed", r.Txn)
+		if r.File == f.shareFile {
+			hash, err := fileHash(f.shareFile)
+			require.NoError(t, err)
+			require.Equal(t, hash, r.Hash)
+		}
+	}
+	require.Equal(t, map[string]bool{"keypair": true, "share": true, "distpublic": true}, kinds)
+
+	// the secrets are never logged
+	log, err := os.ReadFile(f.wal.path)
+	require.NoError(t, err)
+	require.NotContains(t, string(log), ScalarToString(pair.Key))
+	require.NotContains(t, string(log), ScalarToString(shares[0].PrivateShare().V))
+}
+
+func TestStoreWriteAheadLogRecovery(t *testing.T) {
+	folder := t.TempDir()
+	store := NewFileStore(folder, "", WithWriteAheadLog())
+	f := store.(*fileStore)
+	_, group := BatchIdentities(3)
+	require.NoError(t, store.SaveGroup(group))
+	_, dist := dealShares(3, 2)
+	require.NoError(t, store.SaveDistPublic(dist))
+	saved, err := store.LoadGroup()
+	require.NoError(t, err)
+
+	// crash writes the new group and distributed public key aside, then
+	// stops after replacing the group
+	crash := func() (*Group, *DistPublic) {
+		_, next := dealShares(3, 2)
+		nextGroup := *saved
+		nextGroup.PublicKey = next
+		w := &atomicWrite{wal: f.wal, codecs: f.codecs}
+		require.NoError(t, w.add(f.groupFile, &nextGroup, false))
+		require.NoError(t, w.add(f.distKeyFile, next, false))
+		require.NoError(t, w.logIntents())
+		require.NoError(t, w.pending[0].replace())
+		return &nextGroup, next
+	}
+	lastOp := func() string {
+		records, err := f.wal.records()
+		require.NoError(t, err)
+		return records[len(records)-1].Op
+	}
+
+	// intact new contents are moved in place
+	nextGroup, next := crash()
+	_, err = OpenFileStore(folder, "", WithWriteAheadLog())
+	require.NoError(t, err)
+	require.Equal(t, walCommit, lastOp())
+	loaded, err := store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(nextGroup))
+	loadedDist, err := store.LoadDistPublic()
+	require.NoError(t, err)
+	require.True(t, loadedDist.Equal(next))
+	for _, file := range []string{f.groupFile, f.distKeyFile} {
+		exists, _ := fs.Exists(file + backupExtension)
+		require.False(t, exists)
+	}
+
+	// a damaged new content rolls the whole write back
+	saved = loaded
+	crash()
+	require.NoError(t, os.WriteFile(f.distKeyFile+tmpExtension, []byte("damaged"), 0600))
+	_, err = OpenFileStore(folder, "", WithWriteAheadLog())
+	require.NoError(t, err)
+	require.Equal(t, walAbort, lastOp())
+	loaded, err = store.LoadGroup()
+	require.NoError(t, err)
+	require.True(t, loaded.Equal(saved))
+	loadedDist, err = store.LoadDistPublic()
+	require.NoError(t, err)
+	require.True(t, loadedDist.Equal(next))
+	exists, _ := fs.Exists(f.distKeyFile + tmpExtension)
+	require.False(t, exists)
+
+	// nothing left to recover
+	_, err = OpenFileStore(folder, "", WithWriteAheadLog())
+	require.NoError(t, err)
+	require.Equal(t, walAbort, lastOp())
+}
+
+func TestStoreRecoveryByDefault(t *testing.T) {
+	folder := t.TempDir()
+	store := NewFileStore(folder, "")
+	f := store.(*fileStore)
+	_, err := Init(store, "127.0.0.1:8080")
+	require.NoError(t, err)
+	shares, dist := dealShares(3, 2)
+	require.NoError(t, store.SaveDKGResult(shares[0], dist))
+
+	// a crash between the renames of the share and the distributed key
+	reshared, next := dealShares(3, 2)
+	w := &atomicWrite{wal: f.wal, codecs: f.codecs}
+	require.NoError(t, w.add(f.shareFile, reshared[0], true))
+	require.NoError(t, w.add(f.distKeyFile, next, false))
+	require.NoError(t, w.logIntents())
+	require.NoError(t, w.pending[0].replace())
+
+	reopened := NewFileStore(folder, "")
+	loaded, err := reopened.LoadShare()
+	require.NoError(t, err)
+	require.True(t, loaded.PubPoly().Commit().Equal(next.Key()))
+	loadedDist, err := reopened.LoadDistPublic()
+	require.NoError(t, err)
+	require.True(t, loadedDist.Equal(next))
+	for _, file := range []string{f.shareFile, f.distKeyFile} {
+		exists, _ := fs.Exists(file + backupExtension)
+		require.False(t, exists)
+	}
+}
+
+func TestStoreWriteAheadLogCompaction(t *testing.T) {
+	store := NewFileStore(t.TempDir(), "", WithWriteAheadLog())
+	f := store.(*fileStore)
+	f.wal.maxSize = 1 << 10
+	_, group := BatchIdentities(3)
+	for i := 0; i < 20; i++ {
+		require.NoError(t, store.SaveGroup(group))
+	}
+	info, err := os.Stat(f.wal.path)
+	require.NoError(t, err)
+	require.LessOrEqual(t, info.Size(), f.wal.maxSize)
+
+	// the writes in progress are kept
+	w := &atomicWrite{wal: f.wal, codecs: f.codecs}
+	require.NoError(t, w.add(f.groupFile, group, false))
+	require.NoError(t, w.logIntents())
+	for i := 0; i < 20; i++ {
+		require.NoError(t, f.wal.close("done", walCommit))
+	}
+	records, err := f.wal.records()
+	require.NoError(t, err)
+	require.Equal(t, walIntent, records[0].Op)
+	require.Equal(t, w.txn, records[0].Txn)
+}
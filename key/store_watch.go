@@ -0,0 +1,109 @@
+package key
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// GroupUpdate is delivered by WatchGroup when the stored group changes. It
+// holds either the new group, which passed Group.Valid, or the error met while
+// loading or validating it.
+type GroupUpdate struct {
+	Group *Group
+	Err   error
+}
+
+// GroupWatcher is implemented by stores able to notify the changes of their
+// group.
+type GroupWatcher interface {
+	// WatchGroup returns a channel receiving an update each time the stored
+	// group changes, until ctx is done, when the channel is closed.
+	WatchGroup(ctx context.Context) (<-chan GroupUpdate, error)
+}
+
+// sameFileVersion returns true if both infos, nil for a missing file, describe
+// the same version of a file.
+func sameFileVersion(a, b os.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return os.SameFile(a, b) && a.ModTime().Equal(b.ModTime()) && a.Size() == b.Size()
+}
+
+// groupFileInfo returns the info of the group file, or nil if it is missing.
+func (f *fileStore) groupFileInfo() (os.FileInfo, error) {
+	info, err := os.Stat(f.groupFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return info, wrapFileError(f.groupFile, err)
+}
+
+// WatchGroup checks the group file at the watch interval of the store. A change
+// is delivered once the file has stayed the same for a whole interval, so that
+// a burst of writes, as done by a save, results in a single update. An error
+// checking the file is delivered once, until it changes or the file can be
+// checked again.
+func (f *fileStore) WatchGroup(ctx context.Context) (<-chan GroupUpdate, error) {
+	if f.watchInterval <= 0 {
+		return nil, fmt.Errorf("store: watch interval %s must be positive", f.watchInterval)
+	}
+	last, err := f.groupFileInfo()
+	if err != nil {
+		return nil, err
+	}
+	updates := make(chan GroupUpdate)
+	go func() {
+		defer close(updates)
+		ticker := f.clock.NewTicker(f.watchInterval)
+		defer ticker.Stop()
+		pending := false
+		var lastErr string
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.Chan():
+			}
+			current, err := f.groupFileInfo()
+			if err == nil {
+				lastErr = ""
+			}
+			switch {
+			case err != nil && err.Error() == lastErr:
+				continue
+			case err != nil:
+				lastErr = err.Error()
+			case !sameFileVersion(current, last):
+				last, pending = current, true
+				continue
+			case !pending:
+				continue
+			}
+			pending = false
+			update := GroupUpdate{Err: err}
+			if err == nil {
+				update = f.loadValidGroup()
+			}
+			select {
+			case updates <- update:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return updates, nil
+}
+
+func (f *fileStore) loadValidGroup() GroupUpdate {
+	g, err := f.LoadGroup()
+	if err == nil {
+		err = g.Valid()
+	}
+	if err != nil {
+		return GroupUpdate{Err: err}
+	}
+	return GroupUpdate{Group: g}
+}
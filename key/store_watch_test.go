@@ -0,0 +1,82 @@
+package key
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func nextGroupUpdate(t *testing.T, updates <-chan GroupUpdate) GroupUpdate {
+	t.Helper()
+	select {
+	case u, ok := <-updates:
+		require.True(t, ok, "channel closed")
+		return u
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no group update")
+		return GroupUpdate{}
+	}
+}
+
+func TestStoreWatchGroup(t *testing.T) {
+	interval := 20 * time.Millisecond
+	store := NewFileStore(t.TempDir(), "", WithWatchInterval(interval))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates, err := store.(GroupWatcher).WatchGroup(ctx)
+	require.NoError(t, err)
+
+	_, group := BatchIdentities(3)
+	require.NoError(t, store.SaveGroup(group))
+	u := nextGroupUpdate(t, updates)
+	require.NoError(t, u.Err)
+	require.Equal(t, group.Hash(), u.Group.Hash())
+
+	// successive saves result in a single update
+	invalid := group.Copy()
+	invalid.Nodes[1].Addr = invalid.Nodes[0].Addr
+	require.NoError(t, store.SaveGroup(group))
+	require.NoError(t, store.SaveGroup(invalid))
+	u = nextGroupUpdate(t, updates)
+	require.Error(t, u.Err)
+	require.Nil(t, u.Group)
+	select {
+	case u := <-updates:
+		require.FailNow(t, "unexpected update", "%+v", u)
+	case <-time.After(10 * interval):
+	}
+
+	// a persistent error checking the file is delivered once
+	groups := filepath.Dir(store.(*fileStore).groupFile)
+	require.NoError(t, os.RemoveAll(groups))
+	require.NoError(t, os.WriteFile(groups, nil, 0600))
+	u = nextGroupUpdate(t, updates)
+	require.Error(t, u.Err)
+	select {
+	case u := <-updates:
+		require.FailNow(t, "unexpected update", "%+v", u)
+	case <-time.After(10 * interval):
+	}
+	require.NoError(t, os.Remove(groups))
+	require.NoError(t, os.Mkdir(groups, 0700))
+	require.NoError(t, store.SaveGroup(group))
+	u = nextGroupUpdate(t, updates)
+	require.NoError(t, u.Err)
+
+	cancel()
+	select {
+	case _, ok := <-updates:
+		require.False(t, ok)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "channel not closed")
+	}
+
+	for _, d := range []time.Duration{0, -time.Second} {
+		_, err := NewFileStore(t.TempDir(), "", WithWatchInterval(d)).(GroupWatcher).WatchGroup(context.Background())
+		require.Error(t, err)
+	}
+}
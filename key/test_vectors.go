@@ -0,0 +1,151 @@
+package key
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/drand/drand/common/scheme"
+	"github.com/drand/kyber/share"
+	"github.com/drand/kyber/xof/blake2xb"
+)
+
+// Parameters of the network described by the test vectors.
+const (
+	testVectorNodes       = 3
+	testVectorThreshold   = 2
+	testVectorGenesisTime = 1600000000
+	testVectorPeriod      = 30 * time.Second
+	testVectorRound       = 1
+)
+
+// TestVectors are fixtures derived deterministically from a seed, shared with
+// other implementations of drand to check they encode, hash and sign the same
+// way. All binary values are hex encoded.
+type TestVectors struct {
+	Seed string `json:"seed"`
+	// KeyPair is the key pair of the first node of the group, in its TOML
+	// encoding, along with its public identity
+	PrivateKey string `json:"private_key"`
+	Identity   string `json:"identity"`
+	// Group is the TOML encoding of the group, holding the distributed key
+	Group       string `json:"group"`
+	GroupHash   string `json:"group_hash"`
+	GenesisSeed string `json:"genesis_seed"`
+	// Share is the TOML encoding of the share of the first node
+	Share          string         `json:"share"`
+	DistPublic     []string       `json:"dist_public"`
+	DistPublicHash string         `json:"dist_public_hash"`
+	ChainInfo      *ChainInfoJSON `json:"chain_info"`
+	Beacon         struct {
+		Round             uint64 `json:"round"`
+		PreviousSignature string `json:"previous_signature"`
+		Message           string `json:"message"`
+		Signature         string `json:"signature"`
+		Randomness        string `json:"randomness"`
+	} `json:"beacon"`
+}
+
+// GenerateTestVectors derives the test vectors from the seed: the keys of a
+// group of three nodes with a threshold of two under the default scheme, the
+// share of its first node after a DKG, and the first beacon of the chain. The
+// same seed always yields the same vectors.
+func GenerateTestVectors(seed []byte) (*TestVectors, error) {
+	if len(seed) == 0 {
+		return nil, errors.New("test vectors: empty seed")
+	}
+	sch, err := scheme.GetSchemeByIDWithDefault(scheme.DefaultSchemeID)
+	if err != nil {
+		return nil, err
+	}
+	stream := blake2xb.New(seed)
+
+	pairs := make([]*Pair, testVectorNodes)
+	ids := make([]*Identity, testVectorNodes)
+	for i := range pairs {
+		k := KeyGroup.Scalar().Pick(stream)
+		pairs[i] = &Pair{Key: k, Public: &Identity{
+			Key:  KeyGroup.Point().Mul(k, nil),
+			Addr: fmt.Sprintf("127.0.0.1:%d", 8080+i),
+		}}
+		pairs[i].SelfSign()
+		ids[i] = pairs[i].Public
+	}
+	group := NewGroup(ids, testVectorThreshold, testVectorGenesisTime, testVectorPeriod, testVectorPeriod/2, sch, "default")
+	group.GenesisSeed = group.ComputeGenesisSeed()
+
+	secret := KeyGroup.Scalar().Pick(stream)
+	priPoly := share.NewPriPoly(KeyGroup, testVectorThreshold, secret, stream)
+	_, commits := priPoly.Commit(KeyGroup.Point().Base()).Info()
+	dist := &DistPublic{Coefficients: commits}
+	group.PublicKey = dist
+	index, err := group.NodeIndex(pairs[0].Public.Key)
+	if err != nil {
+		return nil, err
+	}
+	first := &Share{Commits: commits, Share: priPoly.Eval(index)}
+
+	v := &TestVectors{Seed: hex.EncodeToString(seed)}
+	for _, field := range []struct {
+		dst *string
+		t   Tomler
+	}{
+		{&v.PrivateKey, pairs[0]}, {&v.Identity, pairs[0].Public}, {&v.Group, group}, {&v.Share, first},
+	} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, field.t); err != nil {
+			return nil, err
+		}
+		*field.dst = buf.String()
+	}
+	v.GroupHash = hex.EncodeToString(group.Hash())
+	v.GenesisSeed = hex.EncodeToString(group.GenesisSeed)
+	v.DistPublic = make([]string, len(commits))
+	for i, c := range commits {
+		v.DistPublic[i] = PointToString(c)
+	}
+	v.DistPublicHash = hex.EncodeToString(dist.Hash())
+	if v.ChainInfo, err = ChainInfo(group, dist); err != nil {
+		return nil, err
+	}
+
+	// the first round of a chained scheme signs over the genesis seed
+	msg := beaconMessage(testVectorRound, group.GenesisSeed)
+	sig, err := AuthScheme.Sign(secret, msg)
+	if err != nil {
+		return nil, err
+	}
+	randomness := sha256.Sum256(sig)
+	v.Beacon.Round = testVectorRound
+	v.Beacon.PreviousSignature = hex.EncodeToString(group.GenesisSeed)
+	v.Beacon.Message = hex.EncodeToString(msg)
+	v.Beacon.Signature = hex.EncodeToString(sig)
+	v.Beacon.Randomness = hex.EncodeToString(randomness[:])
+	return v, nil
+}
+
+// beaconMessage returns the message signed for the round of a chained scheme,
+// as computed by the chain package.
+func beaconMessage(round uint64, prevSig []byte) []byte {
+	h := sha256.New()
+	_, _ = h.Write(prevSig)
+	_ = binary.Write(h, binary.BigEndian, round)
+	return h.Sum(nil)
+}
+
+// WriteJSON writes the test vectors as indented JSON, the format of the
+// fixture files.
+func (v *TestVectors) WriteJSON(w io.Writer) error {
+	buff, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(buff, '\n'))
+	return err
+}
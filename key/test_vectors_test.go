@@ -0,0 +1,44 @@
+package key
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var updateVectors = flag.Bool("update-vectors", false, "rewrite the test vectors fixture")
+
+const testVectorsFixture = "testdata/test_vectors.json"
+
+func TestGenerateTestVectors(t *testing.T) {
+	seed := []byte("drand test vectors")
+	v, err := GenerateTestVectors(seed)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, v.WriteJSON(&buf))
+
+	again, err := GenerateTestVectors(seed)
+	require.NoError(t, err)
+	var buf2 bytes.Buffer
+	require.NoError(t, again.WriteJSON(&buf2))
+	require.Equal(t, buf.String(), buf2.String())
+
+	other, err := GenerateTestVectors([]byte("another seed"))
+	require.NoError(t, err)
+	require.NotEqual(t, v.GroupHash, other.GroupHash)
+
+	_, err = GenerateTestVectors(nil)
+	require.Error(t, err)
+
+	if *updateVectors {
+		require.NoError(t, os.MkdirAll(filepath.Dir(testVectorsFixture), 0o755))
+		require.NoError(t, os.WriteFile(testVectorsFixture, buf.Bytes(), 0o644))
+	}
+	fixture, err := os.ReadFile(testVectorsFixture)
+	require.NoError(t, err)
+	require.Equal(t, string(fixture), buf.String(), "test vectors changed, run with -update-vectors if intended")
+}
@@ -1,6 +1,10 @@
 package test
 
-import "github.com/drand/drand/key"
+import (
+	"bytes"
+
+	"github.com/drand/drand/key"
+)
 
 type KeyStore struct {
 	priv  *key.Pair
@@ -13,7 +17,7 @@ func NewKeyStore() key.Store {
 	return &KeyStore{}
 }
 
-func (k *KeyStore) SaveKeyPair(p *key.Pair) error {
+func (k *KeyStore) SaveKeyPair(p *key.Pair, _ ...key.SaveOption) error {
 	k.priv = p
 	return nil
 }
@@ -22,7 +26,7 @@ func (k *KeyStore) LoadKeyPair() (*key.Pair, error) {
 	return k.priv, nil
 }
 
-func (k *KeyStore) SaveShare(share *key.Share) error {
+func (k *KeyStore) SaveShare(share *key.Share, _ ...key.SaveOption) error {
 	k.share = share
 	return nil
 }
@@ -31,7 +35,7 @@ func (k *KeyStore) LoadShare() (*key.Share, error) {
 	return k.share, nil
 }
 
-func (k *KeyStore) SaveGroup(g *key.Group) error {
+func (k *KeyStore) SaveGroup(g *key.Group, _ ...key.SaveOption) error {
 	k.group = g
 	return nil
 }
@@ -40,10 +44,28 @@ func (k *KeyStore) LoadGroup() (*key.Group, error) {
 	return k.group, nil
 }
 
-func (k *KeyStore) SaveDistPublic(d *key.DistPublic) error {
+func (k *KeyStore) CompareAndSwapGroup(expected, g *key.Group) error {
+	switch {
+	case k.group == nil && expected == nil:
+	case k.group == nil || expected == nil:
+		return key.ErrConflict
+	case !bytes.Equal(k.group.Hash(), expected.Hash()):
+		return key.ErrConflict
+	}
+	k.group = g
+	return nil
+}
+
+func (k *KeyStore) SaveDistPublic(d *key.DistPublic, _ ...key.SaveOption) error {
 	k.dist = d
 	return nil
 }
+func (k *KeyStore) SaveDKGResult(share *key.Share, d *key.DistPublic, _ ...key.SaveOption) error {
+	k.share = share
+	k.dist = d
+	return nil
+}
+
 func (k *KeyStore) LoadDistPublic() (*key.DistPublic, error) {
 	return k.dist, nil
 }
@@ -54,3 +76,7 @@ func (k *KeyStore) Reset(...key.ResetOption) error {
 	k.share = nil
 	return nil
 }
+
+func (k *KeyStore) Close() error {
+	return nil
+}